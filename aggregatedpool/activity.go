@@ -21,6 +21,9 @@ const (
 	doNotCompleteOnReturn        = "doNotCompleteOnReturn"
 	RrMetricName          string = "rr_activities_pool_queue_size"
 	RrWorkflowsMetricName string = "rr_workflows_pool_queue_size"
+	// RrActivitiesStartedMetricName counts the activities sent to the workers, the rate shows the consumption
+	// against the configured rate limits
+	RrActivitiesStartedMetricName string = "rr_activities_started"
 )
 
 type Activity struct {
@@ -30,15 +33,21 @@ type Activity struct {
 	seqID   uint64
 	running sync.Map
 
+	// activity types with the reported heartbeat age, used by CheckHeartbeats only
+	heartbeatTypes map[string]struct{}
+
 	pldPool                *sync.Pool
 	disableActivityWorkers bool
+	// nil - no checks
+	limits *PayloadLimits
 }
 
-func NewActivityDefinition(ac api.Codec, p api.Pool, log *zap.Logger, disableActivityWorkers bool) *Activity {
+func NewActivityDefinition(ac api.Codec, p api.Pool, log *zap.Logger, disableActivityWorkers bool, limits *PayloadLimits) *Activity {
 	return &Activity{
-		log:   log,
-		codec: ac,
-		pool:  p,
+		log:    log,
+		codec:  ac,
+		pool:   p,
+		limits: limits,
 		pldPool: &sync.Pool{
 			New: func() any {
 				return new(payload.Payload)
@@ -55,7 +64,7 @@ func (a *Activity) GetActivityContext(taskToken []byte) (context.Context, error)
 		return nil, errors.E(op, errors.Str("heartbeat on non running activity"))
 	}
 
-	return c.(context.Context), nil
+	return c.(*runningActivity).ctx, nil
 }
 
 func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commonpb.Payloads, error) {
@@ -70,23 +79,28 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 	}
 
 	var info = tActivity.GetInfo(ctx)
-	a.running.Store(bytesToStr(info.TaskToken), ctx)
+	a.running.Store(bytesToStr(info.TaskToken), newRunningActivity(ctx, info))
 	mh := tActivity.GetMetricsHandler(ctx)
 	// if the mh is not nil, record the RR metric
 	if mh != nil {
+		mh.Counter(RrActivitiesStartedMetricName).Inc(1)
 		mh.Gauge(RrMetricName).Update(float64(a.pool.QueueSize()))
 		defer mh.Gauge(RrMetricName).Update(float64(a.pool.QueueSize()))
 	}
 
+	// the converter requested by the workflow is passed in the header
+	dataConverter, hdr := dataConverterFromHeader(api.ActivityHeadersFromCtx(ctx))
+
 	var msg = &internal.Message{
 		ID: atomic.AddUint64(&a.seqID, 1),
 		Command: internal.InvokeActivity{
 			Name:             info.ActivityType.Name,
 			Info:             info,
 			HeartbeatDetails: len(heartbeatDetails.Payloads),
+			DataConverter:    dataConverter,
 		},
 		Payloads: args,
-		Header:   api.ActivityHeadersFromCtx(ctx),
+		Header:   hdr,
 	}
 
 	if len(heartbeatDetails.Payloads) != 0 {
@@ -94,7 +108,13 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 	}
 
 	pl := a.getPld()
-	defer a.putPld(pl)
+	// after the exec timeout the pool might still write the frame, the buffer is reused only once the worker responded
+	responded := false
+	defer func() {
+		if responded {
+			a.putPld(pl)
+		}
+	}()
 
 	err := a.codec.Encode(
 		&internal.Context{
@@ -116,6 +136,7 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 
 	select {
 	case pld := <-result:
+		responded = true
 		if pld.Error() != nil {
 			return nil, errors.E(op, pld.Error())
 		}
@@ -150,6 +171,16 @@ func (a *Activity) execute(ctx context.Context, args *commonpb.Payloads) (*commo
 		return nil, temporal.GetDefaultFailureConverter().FailureToError(retPld.Failure)
 	}
 
+	err = checkActivityPayloads(dataConverter, retPld.Payloads)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), dataConverterErrType, nil)
+	}
+
+	err = a.limits.check(a.log, "activity", info.ActivityType.Name, retPld.Payloads)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), payloadSizeErrType, nil)
+	}
+
 	return retPld.Payloads, nil
 }
 
@@ -158,6 +189,7 @@ func (a *Activity) getPld() *payload.Payload {
 }
 
 func (a *Activity) putPld(pld *payload.Payload) {
+	a.codec.Release(pld)
 	pld.Codec = 0
 	pld.Context = nil
 	pld.Body = nil
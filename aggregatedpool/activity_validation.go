@@ -0,0 +1,65 @@
+package aggregatedpool
+
+import (
+	"strings"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+)
+
+const (
+	// ActivityValidationOff dispatches the activities as is, the invalid options are rejected by the server. Default.
+	ActivityValidationOff = "off"
+	// ActivityValidationWarn logs the invalid activity options and dispatches the activity.
+	ActivityValidationWarn = "warn"
+	// ActivityValidationStrict fails the activity with the non-retryable InvalidActivityOptions error without dispatching it.
+	ActivityValidationStrict = "strict"
+
+	// invalidActivityOptionsErrType is the application error type of the activity rejected by the strict validation
+	invalidActivityOptionsErrType string = "InvalidActivityOptions"
+)
+
+// validateActivity checks the ExecuteActivity command the same way the server does when the activity is scheduled:
+// the activity type, the timeouts and the retry policy. All the problems are reported at once.
+func validateActivity(cmd *internal.ExecuteActivity) error {
+	var problems []string
+
+	if cmd.Name == "" {
+		problems = append(problems, "activity type should not be empty")
+	}
+
+	opts := &cmd.Options
+	if opts.ScheduleToCloseTimeout < 0 || opts.ScheduleToStartTimeout < 0 || opts.StartToCloseTimeout < 0 || opts.HeartbeatTimeout < 0 {
+		problems = append(problems, "timeouts should not be negative")
+	}
+
+	if opts.ScheduleToCloseTimeout == 0 && opts.StartToCloseTimeout == 0 {
+		problems = append(problems, "either schedule_to_close or start_to_close timeout should be set")
+	}
+
+	if rp := opts.RetryPolicy; rp != nil {
+		// zero values are the server defaults
+		if rp.GetBackoffCoefficient() != 0 && rp.GetBackoffCoefficient() < 1 {
+			problems = append(problems, "retry policy backoff_coefficient should be >= 1")
+		}
+
+		if rp.GetMaximumAttempts() < 0 {
+			problems = append(problems, "retry policy maximum_attempts should not be negative")
+		}
+
+		initial, maximum := rp.GetInitialInterval().AsDuration(), rp.GetMaximumInterval().AsDuration()
+		if initial < 0 || maximum < 0 {
+			problems = append(problems, "retry policy intervals should not be negative")
+		}
+
+		if rp.GetMaximumInterval() != nil && maximum < initial {
+			problems = append(problems, "retry policy maximum_interval should not be lower than initial_interval")
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return errors.Errorf("invalid activity '%s' options: %s", cmd.Name, strings.Join(problems, "; "))
+}
@@ -0,0 +1,72 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	commonpb "go.temporal.io/api/common/v1"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func Test_ValidateActivity(t *testing.T) {
+	valid := &internal.ExecuteActivity{
+		Name: "Charge",
+		Options: bindings.ExecuteActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			RetryPolicy: &commonpb.RetryPolicy{
+				BackoffCoefficient: 2,
+				InitialInterval:    durationpb.New(time.Second),
+				MaximumInterval:    durationpb.New(time.Minute),
+			},
+		},
+	}
+	assert.NoError(t, validateActivity(valid))
+
+	// the server defaults
+	assert.NoError(t, validateActivity(&internal.ExecuteActivity{Name: "Charge", Options: bindings.ExecuteActivityOptions{
+		ScheduleToCloseTimeout: time.Minute,
+		RetryPolicy:            &commonpb.RetryPolicy{InitialInterval: durationpb.New(time.Second)},
+	}}))
+
+	err := validateActivity(&internal.ExecuteActivity{Options: bindings.ExecuteActivityOptions{
+		HeartbeatTimeout: -time.Second,
+		RetryPolicy: &commonpb.RetryPolicy{
+			BackoffCoefficient: 0.5,
+			InitialInterval:    durationpb.New(time.Minute),
+			MaximumInterval:    durationpb.New(time.Second),
+		},
+	}})
+	require.Error(t, err)
+	// all the problems are reported
+	assert.Contains(t, err.Error(), "activity type should not be empty")
+	assert.Contains(t, err.Error(), "timeouts should not be negative")
+	assert.Contains(t, err.Error(), "start_to_close timeout should be set")
+	assert.Contains(t, err.Error(), "backoff_coefficient")
+	assert.Contains(t, err.Error(), "maximum_interval")
+}
+
+func Test_ActivityValidationStrict(t *testing.T) {
+	wp := &Workflow{
+		env: &fakeEnv{},
+		log: zap.NewNop(),
+		mq:  queue.NewMessageQueue(seq),
+		cfg: &WorkflowConfig{ActivityValidation: ActivityValidationStrict},
+	}
+
+	// not dispatched, the environment would panic
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 4, Command: &internal.ExecuteActivity{Name: "Charge"}}))
+
+	require.Len(t, wp.mq.Messages(), 1)
+	f := wp.mq.Messages()[0].Failure
+	require.NotNil(t, f)
+	assert.Equal(t, invalidActivityOptionsErrType, f.GetApplicationFailureInfo().GetType())
+	assert.True(t, f.GetApplicationFailureInfo().GetNonRetryable())
+
+	assert.Error(t, (&WorkflowConfig{ActivityValidation: "loose"}).Validate())
+}
@@ -0,0 +1,75 @@
+package aggregatedpool
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// RrWorkflowCacheEvictionsMetricName is the number of the workflows replayed from the start of the history by the
+	// workflow type since the worker start, a high rate signals an undersized sticky cache
+	RrWorkflowCacheEvictionsMetricName string = "rr_workflow_cache_evictions"
+
+	cacheEvictionLogNone = "none"
+)
+
+// cacheEvictions counts the workflow cache evictions by the workflow type, shared by the workflow runs
+type cacheEvictions struct {
+	mu     sync.Mutex
+	counts map[string]int64
+}
+
+func newCacheEvictions() *cacheEvictions {
+	return &cacheEvictions{counts: make(map[string]int64)}
+}
+
+func (c *cacheEvictions) inc(workflowType string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.counts[workflowType]++
+	return c.counts[workflowType]
+}
+
+// reportCacheEviction reports the workflow replayed from the start of the history: evicted from the sticky cache or the
+// worker was restarted. The SDK metrics handler doesn't emit during replay, so it's reported with the first workflow
+// task processed after the replay.
+func (wp *Workflow) reportCacheEviction() {
+	if !wp.rebuilt || wp.env.IsReplaying() {
+		return
+	}
+
+	wp.rebuilt = false
+	if wp.evictions == nil {
+		return
+	}
+
+	info := wp.env.WorkflowInfo()
+	count := wp.evictions.inc(info.WorkflowType.Name)
+
+	if wp.mh != nil {
+		wp.mh.WithTags(map[string]string{"workflow_type": info.WorkflowType.Name}).Gauge(RrWorkflowCacheEvictionsMetricName).Update(float64(count))
+	}
+
+	level := zapcore.DebugLevel
+	if wp.cfg != nil && wp.cfg.CacheEvictionLogLevel != "" {
+		if wp.cfg.CacheEvictionLogLevel == cacheEvictionLogNone {
+			return
+		}
+
+		// validated with the configuration
+		level, _ = zapcore.ParseLevel(wp.cfg.CacheEvictionLogLevel)
+	}
+
+	if ce := wp.log.Check(level, "workflow replayed from the start of the history (cache eviction)"); ce != nil {
+		ce.Write(
+			zap.String("workflow_type", info.WorkflowType.Name),
+			zap.String("workflow_id", info.WorkflowExecution.ID),
+			zap.String("run_id", info.WorkflowExecution.RunID),
+			zap.Int("history_length", info.GetCurrentHistoryLength()),
+			zap.Int64("evictions", count),
+		)
+	}
+}
@@ -0,0 +1,57 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_CacheEviction(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+	mh := newRecordingHandler()
+	evictions := newCacheEvictions()
+	cfg := &WorkflowConfig{CacheEvictionLogLevel: "info"}
+
+	run := func(replaying bool) {
+		env := &fakeEnv{info: orderWorkflow(), replaying: replaying}
+		wp := &Workflow{env: env, log: zap.New(core), mh: mh, cfg: cfg, evictions: evictions, rebuilt: replaying}
+
+		// reported with the first workflow task after the replay
+		wp.reportCacheEviction()
+		env.replaying = false
+		wp.reportCacheEviction()
+		wp.reportCacheEviction()
+	}
+
+	// the new workflow is not replayed
+	run(false)
+	assert.Empty(t, mh.gauges)
+	assert.Zero(t, logs.Len())
+
+	run(true)
+	run(true)
+	assert.Equal(t, float64(2), mh.gauges[RrWorkflowCacheEvictionsMetricName+":OrderWorkflow"])
+	require.Equal(t, 2, logs.Len())
+	assert.Equal(t, zapcore.InfoLevel, logs.All()[1].Level)
+	assert.Equal(t, int64(2), logs.All()[1].ContextMap()["evictions"])
+
+	// counted, but not logged
+	cfg.CacheEvictionLogLevel = cacheEvictionLogNone
+	run(true)
+	assert.Equal(t, float64(3), mh.gauges[RrWorkflowCacheEvictionsMetricName+":OrderWorkflow"])
+	assert.Equal(t, 2, logs.Len())
+}
+
+func Test_CacheEvictionLogLevelValidation(t *testing.T) {
+	cfg := &WorkflowConfig{}
+	cfg.InitDefaults()
+	assert.Equal(t, "debug", cfg.CacheEvictionLogLevel)
+	assert.NoError(t, cfg.Validate())
+
+	cfg.CacheEvictionLogLevel = "trace"
+	assert.Error(t, cfg.Validate())
+}
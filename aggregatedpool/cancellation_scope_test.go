@@ -0,0 +1,84 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.uber.org/zap"
+)
+
+func Test_CleanupAfterWorkflowCancel(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{
+		env:       env,
+		log:       zap.NewNop(),
+		cfg:       &WorkflowConfig{},
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+	}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.ExecuteActivity{Name: "Charge"}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.CancellationScope{ScopeID: "main", CommandIDs: []uint64{1}}}))
+
+	// the workflow is cancelled, the worker cancels the main scope
+	wp.handleCancel()
+	require.Len(t, wp.mq.Messages(), 1)
+	assert.IsType(t, internal.CancelWorkflow{}, wp.mq.Messages()[0].Command)
+	wp.mq.Flush()
+
+	require.NoError(t, wp.canceller.CancelScope("main"))
+	assert.Equal(t, 1, env.cancelled)
+
+	// the cleanup runs in the disconnected scope nested into the cancelled one
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.ExecuteActivity{Name: "Refund"}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 4, Command: &internal.CancellationScope{
+		ScopeID:      "cleanup",
+		ParentID:     "main",
+		Disconnected: true,
+		CommandIDs:   []uint64{3},
+	}}))
+	assert.Equal(t, []string{"Charge", "Refund"}, env.scheduled)
+
+	// the parent scope is cancelled again (e.g. by the timeout), the cleanup is not affected
+	require.NoError(t, wp.canceller.CancelScope("main"))
+	assert.Equal(t, 1, env.cancelled)
+
+	require.NoError(t, wp.canceller.CancelScope("cleanup"))
+	assert.Equal(t, 2, env.cancelled)
+}
+
+func Test_CancelAllAfterFanOut(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{
+		env:       env,
+		log:       zap.NewNop(),
+		cfg:       &WorkflowConfig{},
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+	}
+
+	for i, name := range []string{"Charge", "Reserve", "Notify"} {
+		require.NoError(t, wp.handleMessage(&internal.Message{ID: uint64(i + 1), Command: &internal.ExecuteActivity{Name: name}}))
+	}
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 4, Command: &internal.CancellationScope{ScopeID: "main", CommandIDs: []uint64{1, 2}}}))
+	assert.Equal(t, []string{"Charge", "Reserve", "Notify"}, env.scheduled)
+
+	// the scoped and not scoped commands are cancelled
+	require.NoError(t, wp.canceller.CancelAll())
+	assert.Equal(t, 3, env.cancelled)
+
+	// already cancelled
+	require.NoError(t, wp.canceller.CancelAll())
+	require.NoError(t, wp.canceller.CancelScope("main"))
+	assert.Equal(t, 3, env.cancelled)
+
+	name, err := internal.CommandName(&internal.CancelAll{})
+	require.NoError(t, err)
+	cmd, err := internal.InitCommand(name)
+	require.NoError(t, err)
+	assert.IsType(t, &internal.CancelAll{}, cmd)
+}
@@ -0,0 +1,74 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func completeWorkflow(t *testing.T, cmd *internal.CompleteWorkflow) error {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop(), mq: queue.NewMessageQueue(seq)}
+
+	err := wp.handleMessage(&internal.Message{
+		ID:      1,
+		Command: cmd,
+		Failure: temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewApplicationError("boom", "ValidationError", "details")),
+	})
+	require.NoError(t, err)
+	require.Error(t, env.err)
+
+	return env.err
+}
+
+func Test_CompleteWorkflowApplicationFailure(t *testing.T) {
+	var appErr *temporal.ApplicationError
+
+	// the failure is passed as is
+	err := completeWorkflow(t, &internal.CompleteWorkflow{})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "ValidationError", appErr.Type())
+
+	err = completeWorkflow(t, &internal.CompleteWorkflow{FailureCategory: internal.FailureApplication, FailureType: "OrderRejected"})
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, "OrderRejected", appErr.Type())
+	assert.Contains(t, appErr.Error(), "boom")
+
+	var details string
+	require.NoError(t, appErr.Details(&details))
+	assert.Equal(t, "details", details)
+}
+
+func Test_CompleteWorkflowCanceledFailure(t *testing.T) {
+	err := completeWorkflow(t, &internal.CompleteWorkflow{FailureCategory: internal.FailureCanceled})
+
+	// the SDK completes the workflow as canceled
+	var canceledErr *temporal.CanceledError
+	require.ErrorAs(t, err, &canceledErr)
+	assert.True(t, temporal.IsCanceledError(err))
+
+	var details string
+	require.NoError(t, canceledErr.Details(&details))
+	assert.Equal(t, "details", details)
+}
+
+func Test_CompleteWorkflowTimeoutFailure(t *testing.T) {
+	err := completeWorkflow(t, &internal.CompleteWorkflow{FailureCategory: internal.FailureTimeout})
+	assert.True(t, temporal.IsTimeoutError(err))
+}
+
+func Test_CompleteWorkflowUnknownFailureCategory(t *testing.T) {
+	wp := &Workflow{env: &fakeEnv{}, log: zap.NewNop(), mq: queue.NewMessageQueue(seq)}
+
+	err := wp.handleMessage(&internal.Message{
+		ID:      1,
+		Command: &internal.CompleteWorkflow{FailureCategory: "terminated"},
+		Failure: temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewApplicationError("boom", "")),
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,227 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	commonpb "go.temporal.io/api/common/v1"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// WorkflowConfig tunes the way workflow commands are processed.
+type WorkflowConfig struct {
+	// PrioritizeCancellation moves Cancel commands ahead of the other commands received from the worker in the same batch.
+	// Changes the order of the commands in the history, should not be toggled while workflows are running.
+	PrioritizeCancellation bool `mapstructure:"prioritize_cancellation"`
+	// SkipUnknownCommands logs and skips commands this RoadRunner version doesn't support instead of failing the workflow task.
+	SkipUnknownCommands bool `mapstructure:"skip_unknown_commands"`
+	// MaxLocalActivitiesPerTask postpones local activities over the limit to the next workflow task (using a timer), 0 - no limit.
+	// Changes the history, do not change while workflows are running.
+	MaxLocalActivitiesPerTask int `mapstructure:"max_local_activities_per_task"`
+	// MaxConcurrentLocalActivities limits the number of the local activities dispatched to the activity workers at once,
+	// the excess ones wait in RR (the wait counts towards their timeouts), 0 - no limit. Unlike the SDK
+	// MaxConcurrentLocalActivityExecutionSize (per Temporal worker), the limit is shared by all the task queues.
+	MaxConcurrentLocalActivities int `mapstructure:"max_concurrent_local_activities"`
+	// MaxStreamedLocalActivityResult allows the worker to stream the local activity result in chunks (e.g. large reports),
+	// reassembled by RR up to this size in bytes, the local activity fails with a non-retryable error on overflow.
+	// 0 - streaming is not supported (default), the result is sent in a single frame.
+	MaxStreamedLocalActivityResult int `mapstructure:"max_streamed_local_activity_result"`
+	// ContinueAsNewHistoryLength is the history length (events) after which continue-as-new is suggested, 0 - server suggestion only.
+	ContinueAsNewHistoryLength int `mapstructure:"continue_as_new_history_length"`
+	// ContinueAsNewHistorySize is the history size (bytes) after which continue-as-new is suggested, 0 - server suggestion only.
+	ContinueAsNewHistorySize int `mapstructure:"continue_as_new_history_size"`
+	// ContinueAsNewSignal is the signal RR sends to the workflow (once per run) when the history crosses one of the
+	// thresholds above, for the loops which don't check the suggestion. The signal is not recorded in the history,
+	// the workflow must handle it (e.g. by calling continue-as-new) or it's dropped by the worker. Disabled when empty.
+	// On replay the signal is sent again only if the current thresholds are crossed at the same point: changing
+	// continue_as_new_history_length/_size (or this option) while the workflows are running makes their replay
+	// non-deterministic.
+	ContinueAsNewSignal string `mapstructure:"continue_as_new_signal"`
+	// MaxInFlight limits the number of concurrent requests (workflow tasks, queries) to the workflow worker, 0 - no limit.
+	MaxInFlight int `mapstructure:"max_in_flight"`
+	// InFlightWaitTimeout is the time to wait for a free slot, the workflow task fails after that. Default: 1m.
+	InFlightWaitTimeout time.Duration `mapstructure:"in_flight_wait_timeout"`
+	// MaxInFlightUpdates rejects the new updates of the workflow (with a retryable failure) while the number of the
+	// accepted but not completed updates reaches the limit, 0 - no limit.
+	MaxInFlightUpdates int `mapstructure:"max_in_flight_updates"`
+	// StrictUpdateCallbacks fails the workflow task when the worker completes or validates an unknown update instead of
+	// logging it, to surface the protocol bugs in development.
+	StrictUpdateCallbacks bool `mapstructure:"strict_update_callbacks"`
+	// ExecTimeout bounds the workflow worker execution (workflow task batch, query), the worker is killed and restarted
+	// if it doesn't respond in time. 0 - not bounded, the result is awaited for 10s after the execution (or the
+	// deadlock_detection_timeout of the worker if longer).
+	ExecTimeout time.Duration `mapstructure:"exec_timeout"`
+	// ExecRetry retries the workflow task batches failed before they were sent to the worker (no free workers, worker
+	// allocation errors), the network and the workflow logic failures are never retried. Disabled when not set.
+	ExecRetry *ExecRetry `mapstructure:"exec_retry"`
+	// PropagateHeaders limits the workflow header keys propagated to the activities and child workflows (all keys by
+	// default) and propagates them to the external signals as well, e.g. the W3C baggage used for correlation.
+	PropagateHeaders []string `mapstructure:"propagate_headers"`
+	// RetryPolicies are the default retry policies of the child workflows started without one, key is the workflow type.
+	// Applied to the new child workflows only, the started ones keep their policy.
+	RetryPolicies map[string]*RetryPolicy `mapstructure:"retry_policies"`
+	// CacheEvictionLogLevel is the log level of the workflow cache eviction reports: debug (default), info, warn or none.
+	CacheEvictionLogLevel string `mapstructure:"cache_eviction_log_level"`
+	// ActivityValidation checks the activity options before dispatching the activity: off (default), warn or strict.
+	ActivityValidation string `mapstructure:"activity_validation"`
+	// DebugCommands enables the diagnostic commands (GetPendingCommands) and records the workflow worker PIDs
+	// which handled the updates, should not be enabled in production.
+	DebugCommands bool `mapstructure:"debug_commands"`
+	// ContextFields are the optional fields of the execution context sent with every message batch: tick_time,
+	// history_length, history_size, continue_as_new_suggested and meta, all by default. Sent only if used by the worker
+	// (negotiated with the worker info). The task queue, RR ID and replay flag are always sent.
+	ContextFields []string `mapstructure:"context_fields"`
+	// PanicRedaction redacts the configured patterns in the panic failures (message and stack trace) before they are
+	// recorded in the workflow history. Disabled when not set.
+	PanicRedaction *PanicRedaction `mapstructure:"panic_redaction"`
+}
+
+// RetryPolicy is the workflow retry policy, zero values are replaced with the server defaults.
+type RetryPolicy struct {
+	InitialInterval        time.Duration `mapstructure:"initial_interval"`
+	BackoffCoefficient     float64       `mapstructure:"backoff_coefficient"`
+	MaximumInterval        time.Duration `mapstructure:"maximum_interval"`
+	MaximumAttempts        int32         `mapstructure:"maximum_attempts"`
+	NonRetryableErrorTypes []string      `mapstructure:"non_retryable_error_types"`
+}
+
+func (c *WorkflowConfig) InitDefaults() {
+	if c.MaxInFlight > 0 && c.InFlightWaitTimeout == 0 {
+		c.InFlightWaitTimeout = time.Minute
+	}
+
+	if c.ExecRetry != nil {
+		c.ExecRetry.InitDefaults()
+	}
+
+	if c.CacheEvictionLogLevel == "" {
+		c.CacheEvictionLogLevel = "debug"
+	}
+
+	if c.PanicRedaction != nil {
+		c.PanicRedaction.InitDefaults()
+	}
+}
+
+// Validate checks the in-flight requests, updates and local activities limits, the continue-as-new signal, the exec timeout and retry, the propagated headers, the retry policies, the
+// cache eviction log level, the activity validation mode, the context fields and the panic redaction patterns.
+func (c *WorkflowConfig) Validate() error {
+	const op = errors.Op("workflow_config_validate")
+
+	if c.MaxInFlight < 0 {
+		return errors.E(op, errors.Str("max_in_flight should be positive"))
+	}
+
+	if c.InFlightWaitTimeout < 0 {
+		return errors.E(op, errors.Str("in_flight_wait_timeout should be positive"))
+	}
+
+	if c.MaxInFlightUpdates < 0 {
+		return errors.E(op, errors.Str("max_in_flight_updates should be positive"))
+	}
+
+	if c.MaxConcurrentLocalActivities < 0 {
+		return errors.E(op, errors.Str("max_concurrent_local_activities should be positive"))
+	}
+
+	if c.MaxStreamedLocalActivityResult < 0 {
+		return errors.E(op, errors.Str("max_streamed_local_activity_result should be positive"))
+	}
+
+	if c.ContinueAsNewSignal != "" && c.ContinueAsNewHistoryLength <= 0 && c.ContinueAsNewHistorySize <= 0 {
+		return errors.E(op, errors.Str("continue_as_new_signal requires continue_as_new_history_length or continue_as_new_history_size"))
+	}
+
+	if c.ExecTimeout < 0 {
+		return errors.E(op, errors.Str("exec_timeout should be positive"))
+	}
+
+	for _, k := range c.PropagateHeaders {
+		if k == "" {
+			return errors.E(op, errors.Str("propagate_headers should not contain empty keys"))
+		}
+	}
+
+	if c.ExecRetry != nil {
+		if c.ExecRetry.MaxAttempts < 0 {
+			return errors.E(op, errors.Str("exec_retry.max_attempts should be positive"))
+		}
+
+		if c.ExecRetry.MaxInterval < c.ExecRetry.InitialInterval {
+			return errors.E(op, errors.Str("exec_retry.max_interval should not be lower than initial_interval"))
+		}
+	}
+
+	switch c.CacheEvictionLogLevel {
+	case "", "debug", "info", "warn", cacheEvictionLogNone:
+	default:
+		return errors.E(op, errors.Errorf("cache_eviction_log_level should be one of: debug, info, warn, none, got: %s", c.CacheEvictionLogLevel))
+	}
+
+	_, err := ParseContextFields(c.ContextFields)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if c.PanicRedaction != nil {
+		err = c.PanicRedaction.compile()
+		if err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	switch c.ActivityValidation {
+	case "", ActivityValidationOff, ActivityValidationWarn, ActivityValidationStrict:
+	default:
+		return errors.E(op, errors.Errorf("activity_validation should be one of: off, warn, strict, got: %s", c.ActivityValidation))
+	}
+
+	for name, rp := range c.RetryPolicies {
+		if rp == nil {
+			continue
+		}
+
+		// 0 means the server default (2.0)
+		if rp.BackoffCoefficient != 0 && rp.BackoffCoefficient < 1 {
+			return errors.E(op, errors.Errorf("workflow '%s': retry policy backoff_coefficient should be >= 1, got: %v", name, rp.BackoffCoefficient))
+		}
+
+		if rp.InitialInterval < 0 || rp.MaximumInterval < 0 || rp.MaximumAttempts < 0 {
+			return errors.E(op, errors.Errorf("workflow '%s': retry policy intervals and maximum_attempts should be positive", name))
+		}
+
+		if rp.MaximumInterval != 0 && rp.MaximumInterval < rp.InitialInterval {
+			return errors.E(op, errors.Errorf("workflow '%s': retry policy maximum_interval should not be lower than initial_interval", name))
+		}
+	}
+
+	return nil
+}
+
+// retryPolicy returns the default retry policy of the workflow type, nil if not configured
+func (c *WorkflowConfig) retryPolicy(name string) *commonpb.RetryPolicy {
+	if c == nil {
+		return nil
+	}
+
+	rp, ok := c.RetryPolicies[name]
+	if !ok || rp == nil {
+		return nil
+	}
+
+	res := &commonpb.RetryPolicy{
+		BackoffCoefficient:     rp.BackoffCoefficient,
+		MaximumAttempts:        rp.MaximumAttempts,
+		NonRetryableErrorTypes: rp.NonRetryableErrorTypes,
+	}
+
+	if rp.InitialInterval > 0 {
+		res.InitialInterval = durationpb.New(rp.InitialInterval)
+	}
+
+	if rp.MaximumInterval > 0 {
+		res.MaximumInterval = durationpb.New(rp.MaximumInterval)
+	}
+
+	return res
+}
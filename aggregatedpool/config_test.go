@@ -0,0 +1,69 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_WorkflowConfigRetryPolicy(t *testing.T) {
+	cfg := &WorkflowConfig{
+		RetryPolicies: map[string]*RetryPolicy{
+			"ChildWorkflow": {
+				InitialInterval:        time.Second,
+				BackoffCoefficient:     1.5,
+				MaximumInterval:        time.Minute,
+				MaximumAttempts:        5,
+				NonRetryableErrorTypes: []string{"InvalidInput"},
+			},
+		},
+	}
+	require.NoError(t, cfg.Validate())
+
+	rp := cfg.retryPolicy("ChildWorkflow")
+	require.NotNil(t, rp)
+	assert.Equal(t, time.Second, rp.GetInitialInterval().AsDuration())
+	assert.Equal(t, time.Minute, rp.GetMaximumInterval().AsDuration())
+	assert.Equal(t, 1.5, rp.GetBackoffCoefficient())
+	assert.Equal(t, int32(5), rp.GetMaximumAttempts())
+	assert.Equal(t, []string{"InvalidInput"}, rp.GetNonRetryableErrorTypes())
+
+	assert.Nil(t, cfg.retryPolicy("OtherWorkflow"))
+	assert.Nil(t, (*WorkflowConfig)(nil).retryPolicy("ChildWorkflow"))
+}
+
+func Test_WorkflowConfigRetryPolicyValidation(t *testing.T) {
+	cfg := &WorkflowConfig{RetryPolicies: map[string]*RetryPolicy{"ChildWorkflow": {BackoffCoefficient: 0.5}}}
+	assert.Error(t, cfg.Validate())
+
+	cfg = &WorkflowConfig{RetryPolicies: map[string]*RetryPolicy{"ChildWorkflow": {InitialInterval: time.Minute, MaximumInterval: time.Second}}}
+	assert.Error(t, cfg.Validate())
+
+	// zero coefficient is the server default
+	cfg = &WorkflowConfig{RetryPolicies: map[string]*RetryPolicy{"ChildWorkflow": {MaximumAttempts: 3}}}
+	assert.NoError(t, cfg.Validate())
+}
+
+func Test_WorkflowConfigContinueAsNewSignal(t *testing.T) {
+	// the signal is sent when a threshold is crossed, at least one should be configured
+	cfg := &WorkflowConfig{ContinueAsNewSignal: "continueAsNew"}
+	assert.Error(t, cfg.Validate())
+
+	cfg.ContinueAsNewHistoryLength = 10000
+	assert.NoError(t, cfg.Validate())
+}
+
+func Test_WorkflowConfigMaxInFlight(t *testing.T) {
+	cfg := &WorkflowConfig{MaxInFlight: -1}
+	assert.Error(t, cfg.Validate())
+
+	cfg = &WorkflowConfig{MaxInFlight: 10, InFlightWaitTimeout: -time.Second}
+	assert.Error(t, cfg.Validate())
+
+	cfg = &WorkflowConfig{MaxInFlight: 10}
+	cfg.InitDefaults()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, time.Minute, cfg.InFlightWaitTimeout)
+}
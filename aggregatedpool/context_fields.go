@@ -0,0 +1,87 @@
+package aggregatedpool
+
+import (
+	"strings"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// ContextFieldsFlag is the worker info flag listing (comma-separated) the optional context fields used by the SDK
+const ContextFieldsFlag = "ContextFields"
+
+// ContextFields is the set of the optional fields of the workflow execution context sent with every message batch.
+// The task queue, RR ID and replay flag are always sent.
+type ContextFields uint32
+
+const (
+	ContextTickTime ContextFields = 1 << iota
+	ContextHistoryLength
+	ContextHistorySize
+	ContextContinueAsNewSuggested
+	ContextMeta
+
+	AllContextFields = ContextTickTime | ContextHistoryLength | ContextHistorySize | ContextContinueAsNewSuggested | ContextMeta
+)
+
+var contextFieldNames = map[string]ContextFields{ //nolint:gochecknoglobals
+	"tick_time":                 ContextTickTime,
+	"history_length":            ContextHistoryLength,
+	"history_size":              ContextHistorySize,
+	"continue_as_new_suggested": ContextContinueAsNewSuggested,
+	"meta":                      ContextMeta,
+}
+
+// ParseContextFields returns the set of the named fields, the empty list means all the fields.
+func ParseContextFields(names []string) (ContextFields, error) {
+	if len(names) == 0 {
+		return AllContextFields, nil
+	}
+
+	var f ContextFields
+	for _, name := range names {
+		field, ok := contextFieldNames[strings.TrimSpace(name)]
+		if !ok {
+			return 0, errors.Errorf("unknown context field: %s, supported: tick_time, history_length, history_size, continue_as_new_suggested, meta", name)
+		}
+		f |= field
+	}
+
+	return f, nil
+}
+
+// NegotiateContextFields returns the configured context fields used by the worker. The worker advertises the fields
+// it uses with the ContextFieldsFlag, all the configured fields are sent to the workers not advertising them.
+func NegotiateContextFields(configured []string, flags map[string]string) (ContextFields, error) {
+	f, err := ParseContextFields(configured)
+	if err != nil {
+		return 0, err
+	}
+
+	advertised, ok := flags[ContextFieldsFlag]
+	if !ok {
+		return f, nil
+	}
+
+	var used ContextFields
+	for name := range strings.SplitSeq(advertised, ",") {
+		// the fields unknown to this RR version are not sent anyway
+		used |= contextFieldNames[strings.TrimSpace(name)]
+	}
+
+	return f & used, nil
+}
+
+// SetContextFields sets the optional context fields sent to the worker by the workflows of the definition, including
+// the running ones.
+func (wp *Workflow) SetContextFields(f ContextFields) {
+	wp.ctxFields.Store(uint32(f))
+}
+
+// hasContextField returns true if the optional context field should be sent, all the fields are sent by default
+func (wp *Workflow) hasContextField(f ContextFields) bool {
+	if wp.ctxFields == nil {
+		return true
+	}
+
+	return ContextFields(wp.ctxFields.Load())&f != 0
+}
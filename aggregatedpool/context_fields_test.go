@@ -0,0 +1,47 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_NegotiateContextFields(t *testing.T) {
+	f, err := NegotiateContextFields(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, AllContextFields, f)
+
+	// the worker doesn't use the history size
+	f, err = NegotiateContextFields(nil, map[string]string{ContextFieldsFlag: "tick_time, history_length,continue_as_new_suggested,meta,future_field"})
+	require.NoError(t, err)
+	assert.Equal(t, AllContextFields&^ContextHistorySize, f)
+
+	// intersected with the configured ones
+	f, err = NegotiateContextFields([]string{"tick_time", "history_size"}, map[string]string{ContextFieldsFlag: "tick_time,history_length"})
+	require.NoError(t, err)
+	assert.Equal(t, ContextTickTime, f)
+
+	_, err = NegotiateContextFields([]string{"history"}, nil)
+	assert.Error(t, err)
+	assert.Error(t, (&WorkflowConfig{ContextFields: []string{"history"}}).Validate())
+}
+
+func Test_ReducedContext(t *testing.T) {
+	wp := NewWorkflowDefinition(nil, nil, nil, zap.NewNop(), nil, nil, nil)
+	run := wp.NewWorkflowDefinition().(*Workflow)
+	run.env = &fakeEnv{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), replaying: true}
+
+	ctx := run.getContext()
+	assert.Equal(t, "2026-01-02T03:04:05Z", ctx.TickTime)
+
+	// applied to the running workflows as well
+	wp.SetContextFields(ContextHistoryLength)
+	ctx = run.getContext()
+	assert.Empty(t, ctx.TickTime)
+	assert.Equal(t, "default", ctx.TaskQueue)
+	assert.True(t, ctx.Replay)
+	assert.NotEmpty(t, ctx.RrID)
+}
@@ -0,0 +1,28 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.uber.org/zap"
+)
+
+func Test_ContinueAsNewSignalBelowThreshold(t *testing.T) {
+	wp := &Workflow{
+		env: &fakeEnv{},
+		log: zap.NewNop(),
+		mq:  queue.NewMessageQueue(seq),
+		cfg: &WorkflowConfig{ContinueAsNewHistoryLength: 10000, ContinueAsNewSignal: "continueAsNew"},
+	}
+
+	wp.signalContinueAsNew()
+	assert.Empty(t, wp.mq.Messages())
+	assert.False(t, wp.continueAsNewSignaled)
+	assert.False(t, wp.getContext().ContinueAsNewThresholdReached)
+
+	// the suggestion reports the configured thresholds
+	s := wp.continueAsNewSuggestion()
+	assert.False(t, s.Suggested)
+	assert.Equal(t, 10000, s.HistoryLengthThreshold)
+}
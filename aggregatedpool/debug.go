@@ -0,0 +1,86 @@
+package aggregatedpool
+
+import (
+	"slices"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+)
+
+// debugCommandsErrType is the application error type of the diagnostic command sent while the debug commands are disabled
+const debugCommandsErrType string = "DebugCommandsDisabled"
+
+// maxUpdateWorkers is the number of the recent updates the worker PIDs are recorded for
+const maxUpdateWorkers int = 100
+
+// pendingCommands describes the messages queued to be sent to the worker and the worker commands not handled yet,
+// the queues are not modified
+func (wp *Workflow) pendingCommands() *internal.PendingCommands {
+	return &internal.PendingCommands{
+		Queue:    describeMessages(wp.mq.Messages()),
+		Pipeline: describeMessages(wp.pipeline),
+		Updates:  slices.Clone(wp.updateWorkers),
+	}
+}
+
+// recordUpdateWorker records the PID of the workflow worker which validated (or completed) the update, recorded only
+// when the debug commands are enabled. The last maxUpdateWorkers updates are kept.
+func (wp *Workflow) recordUpdateWorker(id string, completed bool) {
+	if wp.cfg == nil || !wp.cfg.DebugCommands {
+		return
+	}
+
+	i := slices.IndexFunc(wp.updateWorkers, func(uw internal.UpdateWorker) bool { return uw.UpdateID == id })
+	if i == -1 {
+		if len(wp.updateWorkers) == maxUpdateWorkers {
+			wp.updateWorkers = slices.Delete(wp.updateWorkers, 0, 1)
+		}
+
+		wp.updateWorkers = append(wp.updateWorkers, internal.UpdateWorker{UpdateID: id})
+		i = len(wp.updateWorkers) - 1
+	}
+
+	pid := wp.workerPID()
+	if completed {
+		wp.updateWorkers[i].CompletedBy = pid
+		return
+	}
+
+	wp.updateWorkers[i].ValidatedBy = pid
+}
+
+// workerPID returns the PID of the workflow worker, 0 if unknown
+func (wp *Workflow) workerPID() int {
+	if wp.pool == nil {
+		return 0
+	}
+
+	w := wp.pool.Workers()
+	if len(w) == 0 {
+		return 0
+	}
+
+	return int(w[0].Pid())
+}
+
+func describeMessages(msgs []*internal.Message) []internal.PendingCommand {
+	res := make([]internal.PendingCommand, 0, len(msgs))
+	for _, msg := range msgs {
+		pc := internal.PendingCommand{ID: msg.ID}
+		switch {
+		case msg.IsCommand():
+			name, err := internal.CommandName(msg.Command)
+			if err != nil {
+				name = "unknown"
+			}
+			pc.Command = name
+		case msg.Failure != nil:
+			pc.Command = "failure"
+		default:
+			pc.Command = "response"
+		}
+
+		res = append(res, pc)
+	}
+
+	return res
+}
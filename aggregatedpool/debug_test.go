@@ -0,0 +1,62 @@
+package aggregatedpool
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func Test_PendingCommands(t *testing.T) {
+	wp := &Workflow{env: &fakeEnv{}, log: zap.NewNop(), mq: queue.NewMessageQueue(seq)}
+
+	wp.mq.PushResponse(3, nil)
+	wp.mq.PushError(4, temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewCanceledError()))
+	wp.pipeline = []*internal.Message{
+		{ID: 5, Command: &internal.NewTimer{Milliseconds: 100}},
+		{ID: 6, Command: &internal.ExecuteActivity{Name: "Charge"}},
+	}
+
+	pc := wp.pendingCommands()
+	assert.Equal(t, []internal.PendingCommand{{ID: 3, Command: "response"}, {ID: 4, Command: "failure"}}, pc.Queue)
+	assert.Equal(t, []internal.PendingCommand{{ID: 5, Command: "NewTimer"}, {ID: 6, Command: "ExecuteActivity"}}, pc.Pipeline)
+
+	// read-only
+	assert.Len(t, wp.mq.Messages(), 2)
+	assert.Len(t, wp.pipeline, 2)
+}
+
+func Test_UpdateWorkers(t *testing.T) {
+	wp := &Workflow{
+		env:              &fakeEnv{},
+		log:              zap.NewNop(),
+		mq:               queue.NewMessageQueue(seq),
+		cfg:              &WorkflowConfig{DebugCommands: true},
+		updateCompleteCb: map[string]func(res *internal.Message){"u1": func(*internal.Message) {}},
+		updateValidateCb: map[string]func(res *internal.Message){"u1": func(*internal.Message) {}},
+	}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.UpdateValidated{ID: "u1"}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.UpdateCompleted{ID: "u1"}}))
+	// unknown update, not recorded
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.UpdateCompleted{ID: "u2"}}))
+	// no pool - the PID is unknown
+	assert.Equal(t, []internal.UpdateWorker{{UpdateID: "u1"}}, wp.pendingCommands().Updates)
+
+	// the recent updates are kept
+	for i := range maxUpdateWorkers + 1 {
+		wp.recordUpdateWorker(fmt.Sprintf("update-%d", i), true)
+	}
+	updates := wp.pendingCommands().Updates
+	require.Len(t, updates, maxUpdateWorkers)
+	assert.Equal(t, "update-1", updates[0].UpdateID)
+
+	wp.cfg.DebugCommands = false
+	wp.recordUpdateWorker("update-x", false)
+	assert.Len(t, wp.updateWorkers, maxUpdateWorkers)
+}
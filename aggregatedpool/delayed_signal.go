@@ -0,0 +1,88 @@
+package aggregatedpool
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// signalExternalWorkflow sends the signal, the delayed signal is sent when its timer fires
+func (wp *Workflow) signalExternalWorkflow(msg *internal.Message, command *internal.SignalExternalWorkflow) {
+	if command.DelayMilliseconds > 0 {
+		wp.delaySignal(msg, command)
+		return
+	}
+
+	wp.env.SignalExternalWorkflow(
+		command.Namespace,
+		command.WorkflowID,
+		command.RunID,
+		command.Signal,
+		msg.Payloads,
+		nil,
+		wp.signalHeader(msg.Header),
+		command.ChildWorkflowOnly,
+		wp.createCallback(msg.ID, "SignalExternalWorkflow"),
+	)
+}
+
+// delaySignal starts the timer sending the signal when fired. The delay is orchestrated by the workflow (the timer is
+// recorded in the history), it's not a server feature: the signal is sent only if the workflow is still running.
+// The pending delayed signals are cancelled with the command (Cancel) or the workflow.
+func (wp *Workflow) delaySignal(msg *internal.Message, command *internal.SignalExternalWorkflow) {
+	wp.log.Debug("delayed signal", zap.Uint64("ID", msg.ID), zap.String("signal", command.Signal), zap.Int("delay_ms", command.DelayMilliseconds))
+
+	timerID := wp.env.NewTimer(time.Duration(command.DelayMilliseconds)*time.Millisecond, workflow.TimerOptions{Summary: "delayed signal " + command.Signal}, func(_ *commonpb.Payloads, err error) {
+		cb := func() {
+			wp.delayedSignals = slices.DeleteFunc(wp.delayedSignals, func(id uint64) bool { return id == msg.ID })
+			wp.canceller.Discard(msg.ID)
+
+			if err != nil {
+				// timer (and so the signal) was canceled
+				wp.mq.PushError(msg.ID, temporal.GetDefaultFailureConverter().ErrorToFailure(err))
+				wp.resolveSelect(msg.ID, "")
+				return
+			}
+
+			sig := *command
+			sig.DelayMilliseconds = 0
+			wp.signalExternalWorkflow(msg, &sig)
+		}
+
+		if atomic.LoadUint32(&wp.inLoop) == 1 {
+			cb()
+			return
+		}
+
+		wp.callbacks = append(wp.callbacks, func() error {
+			cb()
+			return nil
+		})
+	})
+
+	wp.delayedSignals = append(wp.delayedSignals, msg.ID)
+	wp.canceller.Register(msg.ID, func() error {
+		if timerID != nil {
+			wp.env.RequestCancelTimer(*timerID)
+		}
+		return nil
+	})
+}
+
+// cancelDelayedSignals cancels the pending delayed signals in the order they were sent
+func (wp *Workflow) cancelDelayedSignals() {
+	if len(wp.delayedSignals) == 0 {
+		return
+	}
+
+	err := wp.canceller.Cancel(slices.Clone(wp.delayedSignals)...)
+	if err != nil {
+		wp.log.Error("failed to cancel the delayed signals", zap.Error(err))
+	}
+}
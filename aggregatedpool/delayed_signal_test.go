@@ -0,0 +1,59 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func Test_DelayedSignal(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop(), mq: queue.NewMessageQueue(seq), canceller: new(canceller.Canceller)}
+
+	wp.signalExternalWorkflow(&internal.Message{ID: 1}, &internal.SignalExternalWorkflow{Signal: "now"})
+	wp.signalExternalWorkflow(&internal.Message{ID: 2}, &internal.SignalExternalWorkflow{Signal: "later", DelayMilliseconds: 1500})
+
+	// the delayed signal waits for the timer
+	assert.Equal(t, []string{"now"}, env.signals)
+	require.Len(t, env.timers, 1)
+	assert.Equal(t, time.Millisecond*1500, env.timers[0])
+	assert.Equal(t, []uint64{2}, wp.delayedSignals)
+
+	// fired outside the workflow task loop, the signal is sent with the callbacks
+	env.timerCallbacks[0](nil, nil)
+	assert.Equal(t, []string{"now"}, env.signals)
+	require.Len(t, wp.callbacks, 1)
+	require.NoError(t, wp.callbacks[0]())
+	assert.Equal(t, []string{"now", "later"}, env.signals)
+	assert.Empty(t, wp.delayedSignals)
+	assert.Empty(t, wp.mq.Messages())
+}
+
+func Test_DelayedSignalCanceledWithWorkflow(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop(), mq: queue.NewMessageQueue(seq), canceller: new(canceller.Canceller), inLoop: 1}
+
+	wp.signalExternalWorkflow(&internal.Message{ID: 3}, &internal.SignalExternalWorkflow{Signal: "reminder", DelayMilliseconds: 60000})
+	wp.signalExternalWorkflow(&internal.Message{ID: 4}, &internal.SignalExternalWorkflow{Signal: "escalate", DelayMilliseconds: 120000})
+
+	wp.cancelDelayedSignals()
+	assert.Len(t, env.canceledTimers, 2)
+
+	// the canceled timers report the cancellation, the signals are never sent
+	for _, h := range env.timerCallbacks {
+		h(nil, temporal.NewCanceledError())
+	}
+
+	assert.Empty(t, env.signals)
+	assert.Empty(t, wp.delayedSignals)
+	require.Len(t, wp.mq.Messages(), 2)
+	assert.Equal(t, uint64(3), wp.mq.Messages()[0].ID)
+	assert.NotNil(t, wp.mq.Messages()[0].Failure)
+}
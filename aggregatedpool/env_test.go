@@ -0,0 +1,153 @@
+package aggregatedpool
+
+import (
+	"fmt"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/log"
+	"go.temporal.io/sdk/workflow"
+)
+
+// fakeEnv is the workflow environment of the tests: the workflow info, time and replay state are configured by the
+// fields, the calls are recorded. The parts of the environment not implemented here panic.
+type fakeEnv struct {
+	bindings.WorkflowEnvironment
+
+	// info defaults to the workflow polled on the default task queue
+	info      *workflow.Info
+	now       time.Time
+	replaying bool
+	logger    *recordingLogger
+
+	// Complete
+	completed bool
+	err       error
+	// UpsertMemo, memo is the last upserted one
+	memo    map[string]any
+	upserts int
+	// ExecuteActivity, RequestCancelActivity
+	scheduled []string
+	params    []bindings.ExecuteActivityParams
+	cancelled int
+	// NewTimer, RequestCancelTimer; the timers are fired by the tests with the recorded callbacks
+	timers         []time.Duration
+	timerCallbacks []bindings.ResultHandler
+	canceledTimers []bindings.TimerID
+	// SignalExternalWorkflow, the targets as "namespace/workflowID/runID"
+	signals       []string
+	signalTargets []string
+	// SideEffect, the recorded results are returned on replay
+	sideEffects    []*commonpb.Payloads
+	nextSideEffect int
+}
+
+func (e *fakeEnv) WorkflowInfo() *workflow.Info {
+	if e.info == nil {
+		return &workflow.Info{TaskQueueName: "default"}
+	}
+
+	return e.info
+}
+
+func (e *fakeEnv) GetDataConverter() converter.DataConverter {
+	return converter.GetDefaultDataConverter()
+}
+
+func (e *fakeEnv) Now() time.Time {
+	return e.now
+}
+
+func (e *fakeEnv) IsReplaying() bool {
+	return e.replaying
+}
+
+func (e *fakeEnv) GetLogger() log.Logger {
+	if e.logger == nil {
+		e.logger = &recordingLogger{}
+	}
+
+	return e.logger
+}
+
+func (e *fakeEnv) Complete(_ *commonpb.Payloads, err error) {
+	e.completed = true
+	e.err = err
+}
+
+func (e *fakeEnv) UpsertMemo(memo map[string]any) error {
+	e.upserts++
+	e.memo = memo
+	return nil
+}
+
+func (e *fakeEnv) ExecuteActivity(params bindings.ExecuteActivityParams, _ bindings.ResultHandler) bindings.ActivityID {
+	e.scheduled = append(e.scheduled, params.ActivityType.Name)
+	e.params = append(e.params, params)
+	return bindings.ActivityID{}
+}
+
+func (e *fakeEnv) RequestCancelActivity(bindings.ActivityID) {
+	e.cancelled++
+}
+
+func (e *fakeEnv) NewTimer(d time.Duration, _ workflow.TimerOptions, cb bindings.ResultHandler) *bindings.TimerID {
+	e.timers = append(e.timers, d)
+	e.timerCallbacks = append(e.timerCallbacks, cb)
+	return &bindings.TimerID{}
+}
+
+func (e *fakeEnv) RequestCancelTimer(id bindings.TimerID) {
+	e.canceledTimers = append(e.canceledTimers, id)
+}
+
+func (e *fakeEnv) SignalExternalWorkflow(namespace, workflowID, runID, signal string, _ *commonpb.Payloads, _ any, _ *commonpb.Header, _ bool, _ bindings.ResultHandler) {
+	e.signals = append(e.signals, signal)
+	e.signalTargets = append(e.signalTargets, namespace+"/"+workflowID+"/"+runID)
+}
+
+func (e *fakeEnv) SideEffect(f func() (*commonpb.Payloads, error), callback bindings.ResultHandler) {
+	if e.replaying {
+		e.nextSideEffect++
+		callback(e.sideEffects[e.nextSideEffect-1], nil)
+		return
+	}
+
+	res, err := f()
+	e.sideEffects = append(e.sideEffects, res)
+	callback(res, err)
+}
+
+// QueueUpdate runs the update right away
+func (e *fakeEnv) QueueUpdate(_ string, f func()) {
+	f()
+}
+
+func (e *fakeEnv) DrainUnhandledUpdates() bool {
+	return false
+}
+
+// orderWorkflow is the info of the OrderWorkflow run used by the tests
+func orderWorkflow() *workflow.Info {
+	info := &workflow.Info{TaskQueueName: "default"}
+	info.WorkflowType.Name = "OrderWorkflow"
+	info.WorkflowExecution.ID = "order-1"
+	info.WorkflowExecution.RunID = "run-1"
+	return info
+}
+
+// recordingLogger records the entries as "level: message keyvals"
+type recordingLogger struct {
+	entries []string
+}
+
+func (l *recordingLogger) record(level, msg string, keyvals []any) {
+	l.entries = append(l.entries, fmt.Sprintf("%s: %s %v", level, msg, keyvals))
+}
+
+func (l *recordingLogger) Debug(msg string, keyvals ...any) { l.record("debug", msg, keyvals) }
+func (l *recordingLogger) Info(msg string, keyvals ...any)  { l.record("info", msg, keyvals) }
+func (l *recordingLogger) Warn(msg string, keyvals ...any)  { l.record("warn", msg, keyvals) }
+func (l *recordingLogger) Error(msg string, keyvals ...any) { l.record("error", msg, keyvals) }
@@ -0,0 +1,69 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// ExecRetry configures the retries of the workflow worker calls failed with a transient pool error.
+type ExecRetry struct {
+	// MaxAttempts is the number of the retries after the first failed attempt, 0 - no retries.
+	MaxAttempts int `mapstructure:"max_attempts"`
+	// InitialInterval before the first retry, doubled after each attempt. Default: 100ms.
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval between the retries. Default: 1s.
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+}
+
+func (r *ExecRetry) InitDefaults() {
+	if r.InitialInterval == 0 {
+		r.InitialInterval = time.Millisecond * 100
+	}
+
+	if r.MaxInterval == 0 {
+		r.MaxInterval = time.Second
+	}
+}
+
+// transientExecError reports whether the pool error is transient, i.e. the batch was not written to the worker:
+//   - NoFreeWorkers: no worker was released in time (allocate_timeout);
+//   - WorkerAllocate: the worker could not be (re)allocated.
+//
+// Everything else is never retried: Network (the response might not be read after the worker processed the batch,
+// the stateful workflow worker must not receive it twice), SoftJob (the worker application error, e.g. workflow
+// logic failure), ExecTTL (the worker was killed while processing the batch), Encode/Decode errors and the protocol
+// errors.
+func transientExecError(err error) bool {
+	return errors.Is(errors.NoFreeWorkers, err) || errors.Is(errors.WorkerAllocate, err)
+}
+
+// retryExec calls exec and retries it with the exponential backoff while it fails with a transient error.
+// Disabled if the exec_retry is not configured.
+func (wp *Workflow) retryExec(exec func() error) error {
+	err := exec()
+	if err == nil || wp.cfg == nil || wp.cfg.ExecRetry == nil {
+		return err
+	}
+
+	interval := wp.cfg.ExecRetry.InitialInterval
+	for attempt := 1; attempt <= wp.cfg.ExecRetry.MaxAttempts && transientExecError(err); attempt++ {
+		wp.log.Warn("transient worker error, retrying",
+			zap.String("workflow_id", wp.env.WorkflowInfo().WorkflowExecution.ID),
+			zap.Int("attempt", attempt),
+			zap.Duration("backoff", interval),
+			zap.Error(err),
+		)
+
+		time.Sleep(interval)
+		interval = min(interval*2, wp.cfg.ExecRetry.MaxInterval)
+
+		err = exec()
+		if err == nil {
+			return nil
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,82 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func Test_TransientExecError(t *testing.T) {
+	const op = errors.Op("flush_queue")
+
+	assert.True(t, transientExecError(errors.E(errors.NoFreeWorkers)))
+	assert.True(t, transientExecError(errors.E(op, errors.E(errors.WorkerAllocate, errors.Str("failed")))))
+
+	// the batch might be processed by the worker
+	assert.False(t, transientExecError(errors.E(op, errors.Network, errors.Str("broken pipe"))))
+	assert.False(t, transientExecError(errors.E(op, errors.SoftJob, errors.Str("workflow failed"))))
+	assert.False(t, transientExecError(errors.E(op, errors.ExecTTL)))
+	assert.False(t, transientExecError(errors.Str("worker empty response")))
+}
+
+func Test_RetryExec(t *testing.T) {
+	wp := &Workflow{
+		env: &fakeEnv{},
+		log: zap.NewNop(),
+		cfg: &WorkflowConfig{ExecRetry: &ExecRetry{MaxAttempts: 3, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}},
+	}
+
+	calls := 0
+	err := wp.retryExec(func() error {
+		calls++
+		if calls < 3 {
+			return errors.E(errors.NoFreeWorkers)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	// attempts exhausted
+	calls = 0
+	err = wp.retryExec(func() error {
+		calls++
+		return errors.E(errors.WorkerAllocate)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 4, calls)
+
+	// the response was not read, the batch is not resent to the worker which might have processed it
+	calls = 0
+	err = wp.retryExec(func() error {
+		calls++
+		return errors.E(errors.Network, errors.Str("broken pipe"))
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+
+	// worker application errors are not retried
+	calls = 0
+	err = wp.retryExec(func() error {
+		calls++
+		return errors.E(errors.SoftJob, errors.Str("workflow failed"))
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func Test_RetryExecDisabled(t *testing.T) {
+	wp := &Workflow{env: &fakeEnv{}, log: zap.NewNop(), cfg: &WorkflowConfig{}}
+
+	calls := 0
+	err := wp.retryExec(func() error {
+		calls++
+		return errors.E(errors.NoFreeWorkers)
+	})
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
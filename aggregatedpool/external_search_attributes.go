@@ -0,0 +1,58 @@
+package aggregatedpool
+
+import (
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+// UpsertSearchAttributesSignal is the reserved signal used to update the search attributes of a running workflow from
+// outside (UpsertSearchAttributes RPC). The server has no API to change the search attributes of a running workflow, only
+// the workflow itself can upsert them, so the signal is handled by RR and never reaches the worker. The upsert is
+// recorded in the history after the signal, so the replay is deterministic.
+const UpsertSearchAttributesSignal string = "__rr_upsert_search_attributes"
+
+// ValidateTypedSearchAttributes checks the typed search attributes the same way the workflow does before upserting them.
+func ValidateTypedSearchAttributes(attrs map[string]*internal.TypedSearchAttribute) error {
+	const op = errors.Op("validate_typed_search_attributes")
+
+	if len(attrs) == 0 {
+		return errors.E(op, errors.Str("search attributes should not be empty"))
+	}
+
+	_, err := typedSearchAttributes(attrs)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// upsertExternalSearchAttributes applies the search attributes received with the UpsertSearchAttributesSignal.
+// A signal can't be rejected, so the invalid attributes are logged and skipped instead of failing the workflow task.
+func (wp *Workflow) upsertExternalSearchAttributes(input *commonpb.Payloads) {
+	var attrs map[string]*internal.TypedSearchAttribute
+	err := wp.env.GetDataConverter().FromPayloads(input, &attrs)
+	if err != nil {
+		wp.log.Error("failed to decode the external search attributes", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.Error(err))
+		return
+	}
+
+	sau, err := typedSearchAttributes(attrs)
+	if err != nil {
+		wp.log.Error("external search attributes not applied", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.Error(err))
+		return
+	}
+
+	if len(sau) == 0 {
+		wp.log.Warn("external search attributes signal received, but no attributes were set")
+		return
+	}
+
+	err = wp.env.UpsertTypedSearchAttributes(temporal.NewSearchAttributes(sau...))
+	if err != nil {
+		wp.log.Error("failed to upsert the external search attributes", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.Error(err))
+	}
+}
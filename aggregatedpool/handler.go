@@ -1,17 +1,19 @@
 package aggregatedpool
 
 import (
-	"context"
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
+	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/roadrunner-server/errors"
-	"github.com/roadrunner-server/goridge/v3/pkg/frame"
 	"github.com/roadrunner-server/pool/payload"
 	"github.com/temporalio/roadrunner-temporal/v5/internal"
 	commonpb "go.temporal.io/api/common/v1"
+	sdkpb "go.temporal.io/api/sdk/v1"
 	bindings "go.temporal.io/sdk/internalbindings"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
@@ -22,19 +24,68 @@ const (
 	completed string = "completed"
 	// update types
 	valExec string = "validate_execute"
+	// execute only, used for the updates without validator
+	exec string = "exec"
+	// built-in query used by the UI, sync with the sdk-go/internal/internal_workflow.go
+	workflowMetadataQuery string = "__temporal_workflow_metadata"
+	// tooManyUpdatesErrType is the application error type of the updates rejected by the max_in_flight_updates limit
+	tooManyUpdatesErrType string = "TooManyInFlightUpdates"
+
+	// RrWorkflowInFlightUpdatesMetricName is the number of the accepted but not completed updates of the workflow
+	RrWorkflowInFlightUpdatesMetricName string = "rr_workflow_in_flight_updates"
 )
 
 // execution context.
 func (wp *Workflow) getContext() *internal.Context {
-	return &internal.Context{
-		TaskQueue:              wp.env.WorkflowInfo().TaskQueueName,
-		TickTime:               wp.env.Now().Format(time.RFC3339),
-		Replay:                 wp.env.IsReplaying(),
-		HistoryLen:             wp.env.WorkflowInfo().GetCurrentHistoryLength(),
-		HistorySize:            wp.env.WorkflowInfo().GetCurrentHistorySize(),
-		ContinueAsNewSuggested: wp.env.WorkflowInfo().GetContinueAsNewSuggested(),
-		RrID:                   wp.rrID,
+	// the optional fields are sent if used by the worker, see ContextFields
+	ctx := &internal.Context{
+		TaskQueue: wp.taskQueue(),
+		Replay:    wp.env.IsReplaying(),
+		RrID:      wp.rrID,
 	}
+
+	if wp.hasContextField(ContextTickTime) {
+		ctx.TickTime = wp.env.Now().Format(time.RFC3339)
+	}
+	if wp.hasContextField(ContextHistoryLength) {
+		ctx.HistoryLen = wp.env.WorkflowInfo().GetCurrentHistoryLength()
+	}
+	if wp.hasContextField(ContextHistorySize) {
+		ctx.HistorySize = wp.env.WorkflowInfo().GetCurrentHistorySize()
+	}
+	if wp.hasContextField(ContextContinueAsNewSuggested) {
+		ctx.ContinueAsNewSuggested = wp.env.WorkflowInfo().GetContinueAsNewSuggested()
+		ctx.ContinueAsNewThresholdReached = wp.continueAsNewThresholdReached()
+	}
+
+	if len(wp.decorators) > 0 && wp.hasContextField(ContextMeta) {
+		ctx.Meta = make(map[string]string)
+		for i := range wp.decorators {
+			wp.decorators[i].Decorate(wp.env.WorkflowInfo(), wp.header, ctx.Meta)
+		}
+	}
+
+	return ctx
+}
+
+// taskQueue returns the task queue the workflow is registered on in the PHP worker, it differs from the polled one
+// when the workflow type is routed to another task queue
+func (wp *Workflow) taskQueue() string {
+	if info, ok := wp.workflows[wp.env.WorkflowInfo().WorkflowType.Name]; ok && info.RegisteredTaskQueue != "" {
+		return info.RegisteredTaskQueue
+	}
+
+	return wp.env.WorkflowInfo().TaskQueueName
+}
+
+// workflowTaskQueue returns the default task queue of the child (or continued as new) workflow: the routed workflow
+// types are started on their task queues, the others on the task queue the current workflow is registered on
+func (wp *Workflow) workflowTaskQueue(name string) string {
+	if info, ok := wp.workflows[name]; ok && info.RegisteredTaskQueue != "" {
+		return info.TaskQueue
+	}
+
+	return wp.taskQueue()
 }
 
 func (wp *Workflow) handleUpdate(name string, id string, input *commonpb.Payloads, header *commonpb.Header, callbacks bindings.UpdateCallbacks) {
@@ -46,39 +97,56 @@ func (wp *Workflow) handleUpdate(name string, id string, input *commonpb.Payload
 
 	// this callback executed in the OnTick function
 	updatesQueueCb := func() {
-		// validate callback
-		wp.updateValidateCb[id] = func(msg *internal.Message) {
-			wp.log.Debug("validate request callback", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.String("name", name), zap.String("id", id), zap.Bool("is_replaying", wp.env.IsReplaying()), zap.Any("result", msg))
-			if !wp.env.IsReplaying() {
-				// before acceptance, we have only one option - reject
-				if msg.Failure != nil {
-					callbacks.Reject(temporal.GetDefaultFailureConverter().FailureToError(msg.Failure))
-					return
-				}
-			}
+		if wp.updatesLimitReached() {
+			wp.log.Warn("too many in-flight updates, update rejected", zap.String("RunID", rid), zap.String("name", name), zap.String("id", id), zap.Int("limit", wp.cfg.MaxInFlightUpdates))
+			callbacks.Reject(temporal.NewApplicationError(fmt.Sprintf("too many in-flight updates, limit: %d", wp.cfg.MaxInFlightUpdates), tooManyUpdatesErrType))
+			wp.recordUpdateRejected(name)
+			return
+		}
 
-			// update should be accepted on validating
+		var accepted time.Time
+		tp := valExec
+		if wp.execOnlyUpdate(name) {
+			// nothing to validate, accept right away and save the round-trip to the worker
+			tp = exec
 			callbacks.Accept()
+			accepted = wp.recordUpdateAccepted(name)
+		} else {
+			started := time.Now()
+			validate := wp.updateValidateCallback(name, id, callbacks)
+			wp.updateValidateCb[id] = func(msg *internal.Message) {
+				validate(msg)
+				accepted = wp.recordUpdateValidated(name, started, msg.Failure == nil)
+			}
 		}
 
 		// execute callback
 		wp.updateCompleteCb[id] = func(msg *internal.Message) {
 			wp.log.Debug("update request callback", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.String("name", name), zap.String("id", id), zap.Any("result", msg))
+			wp.recordUpdateCompleted(name, accepted)
 			if msg.Failure != nil {
 				callbacks.Complete(nil, temporal.GetDefaultFailureConverter().FailureToError(msg.Failure))
 				return
 			}
 
+			err := wp.limits.check(wp.log, "update", name, msg.Payloads)
+			if err != nil {
+				callbacks.Complete(nil, temporal.NewNonRetryableApplicationError(err.Error(), payloadSizeErrType, nil))
+				return
+			}
+
 			callbacks.Complete(msg.Payloads, nil)
 		}
 
-		// push validate command
+		wp.reportInFlightUpdates()
+
+		// push validate (or execute) command
 		wp.mq.PushCommand(
 			&internal.InvokeUpdate{
 				RunID:    rid,
 				UpdateID: id,
 				Name:     name,
-				Type:     valExec,
+				Type:     tp,
 			},
 			input,
 			header,
@@ -88,8 +156,64 @@ func (wp *Workflow) handleUpdate(name string, id string, input *commonpb.Payload
 	wp.env.QueueUpdate(name, updatesQueueCb)
 }
 
+func (wp *Workflow) updateValidateCallback(name string, id string, callbacks bindings.UpdateCallbacks) func(msg *internal.Message) {
+	return func(msg *internal.Message) {
+		wp.log.Debug("validate request callback", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.String("name", name), zap.String("id", id), zap.Bool("is_replaying", wp.env.IsReplaying()), zap.Any("result", msg))
+		if !wp.env.IsReplaying() {
+			// before acceptance, we have only one option - reject
+			if msg.Failure != nil {
+				callbacks.Reject(temporal.GetDefaultFailureConverter().FailureToError(msg.Failure))
+				return
+			}
+		}
+
+		// update should be accepted on validating
+		callbacks.Accept()
+	}
+}
+
+// updatesLimitReached returns true if the workflow has the configured number of in-flight updates.
+// Updates are not rejected on replay, the accepted updates are recorded in the history.
+func (wp *Workflow) updatesLimitReached() bool {
+	return wp.cfg != nil && wp.cfg.MaxInFlightUpdates > 0 && !wp.env.IsReplaying() && len(wp.updateCompleteCb) >= wp.cfg.MaxInFlightUpdates
+}
+
+// reportInFlightUpdates updates the in-flight updates gauge of the workflow, not reported during replay
+func (wp *Workflow) reportInFlightUpdates() {
+	if wp.mh == nil || wp.env.IsReplaying() {
+		return
+	}
+
+	wp.mh.Gauge(RrWorkflowInFlightUpdatesMetricName).Update(float64(len(wp.updateCompleteCb)))
+}
+
+// execOnlyUpdate returns true if the update is declared without validator by the worker
+func (wp *Workflow) execOnlyUpdate(name string) bool {
+	info, ok := wp.workflows[wp.env.WorkflowInfo().WorkflowType.Name]
+	if !ok {
+		return false
+	}
+
+	return slices.Contains(info.ExecOnlyUpdates, name)
+}
+
+// missingUpdateCallback handles the update command with unknown update ID: logged and skipped, fails the workflow task
+// with the strict_update_callbacks option to surface the protocol bugs
+func (wp *Workflow) missingUpdateCallback(action string, id string, callbacks map[string]func(res *internal.Message)) error {
+	known := slices.Sorted(maps.Keys(callbacks))
+	if wp.cfg != nil && wp.cfg.StrictUpdateCallbacks {
+		return errors.Errorf("no such update ID, can't %s update: %s, known IDs: [%s]", action, id, strings.Join(known, ", "))
+	}
+
+	wp.log.Warn(fmt.Sprintf("no such update ID, can't %s update", action), zap.String("requested id", id), zap.Strings("known ids", known))
+	return nil
+}
+
 // schedule cancel command
 func (wp *Workflow) handleCancel() {
+	// the delayed signals are orchestrated by the workflow, not sent if the workflow is canceled
+	wp.cancelDelayedSignals()
+
 	wp.mq.PushCommand(
 		internal.CancelWorkflow{RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID},
 		nil,
@@ -100,6 +224,12 @@ func (wp *Workflow) handleCancel() {
 // schedule the signal processing
 func (wp *Workflow) handleSignal(name string, input *commonpb.Payloads, header *commonpb.Header) error {
 	wp.log.Debug("signal request", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.String("name", name))
+
+	if name == UpsertSearchAttributesSignal {
+		wp.upsertExternalSearchAttributes(input)
+		return nil
+	}
+
 	wp.mq.PushCommand(
 		internal.InvokeSignal{
 			RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID,
@@ -109,6 +239,8 @@ func (wp *Workflow) handleSignal(name string, input *commonpb.Payloads, header *
 		header,
 	)
 
+	wp.selectSignal(name)
+
 	return nil
 }
 
@@ -118,6 +250,15 @@ func (wp *Workflow) handleQuery(queryType string, queryArgs *commonpb.Payloads,
 
 	wp.log.Debug("query request", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.String("name", queryType))
 
+	if queryType == workflowMetadataQuery {
+		return wp.env.GetDataConverter().ToPayloads(wp.workflowMetadata())
+	}
+
+	if res, ok := wp.publishedQuery(queryType, queryArgs); ok {
+		wp.log.Debug("query answered with the published state", zap.String("name", queryType))
+		return res, nil
+	}
+
 	result, err := wp.runCommand(internal.InvokeQuery{
 		RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID,
 		Name:  queryType,
@@ -134,6 +275,98 @@ func (wp *Workflow) handleQuery(queryType string, queryArgs *commonpb.Payloads,
 	return result.Payloads, nil
 }
 
+// workflowMetadata describes the workflow for the metadata query
+func (wp *Workflow) workflowMetadata() *sdkpb.WorkflowMetadata {
+	name := wp.env.WorkflowInfo().WorkflowType.Name
+	def := &sdkpb.WorkflowDefinition{
+		Type: name,
+	}
+
+	if info, ok := wp.workflows[name]; ok {
+		for i := range info.Queries {
+			def.QueryDefinitions = append(def.QueryDefinitions, &sdkpb.WorkflowInteractionDefinition{Name: info.Queries[i]})
+		}
+
+		for i := range info.Signals {
+			def.SignalDefinitions = append(def.SignalDefinitions, &sdkpb.WorkflowInteractionDefinition{Name: info.Signals[i]})
+		}
+	}
+
+	return &sdkpb.WorkflowMetadata{
+		Definition:     def,
+		CurrentDetails: wp.currentDetails,
+	}
+}
+
+func (wp *Workflow) continueAsNewSuggestion() *internal.ContinueAsNewSuggestion {
+	info := wp.env.WorkflowInfo()
+	return &internal.ContinueAsNewSuggestion{
+		HistoryLength:          info.GetCurrentHistoryLength(),
+		HistorySize:            info.GetCurrentHistorySize(),
+		Suggested:              info.GetContinueAsNewSuggested() || wp.continueAsNewThresholdReached(),
+		HistoryLengthThreshold: wp.cfg.ContinueAsNewHistoryLength,
+		HistorySizeThreshold:   wp.cfg.ContinueAsNewHistorySize,
+	}
+}
+
+// signalContinueAsNew sends the configured continue-as-new signal (with the suggestion as the input) to the workflow
+// once the history crosses the threshold. The signal is not in the history, it's sent again on replay when the
+// history crosses the threshold of the current config, so the workflow reacts to it the same way only if the
+// continue_as_new_* options weren't changed since the workflow task was executed.
+func (wp *Workflow) signalContinueAsNew() {
+	if wp.cfg == nil || wp.cfg.ContinueAsNewSignal == "" || wp.continueAsNewSignaled || !wp.continueAsNewThresholdReached() {
+		return
+	}
+
+	wp.continueAsNewSignaled = true
+
+	input, err := wp.env.GetDataConverter().ToPayloads(wp.continueAsNewSuggestion())
+	if err != nil {
+		wp.log.Error("failed to encode the continue-as-new suggestion", zap.Error(err))
+		return
+	}
+
+	wp.log.Debug("history threshold reached, sending the continue-as-new signal", zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID), zap.String("signal", wp.cfg.ContinueAsNewSignal))
+	wp.mq.PushCommand(
+		internal.InvokeSignal{
+			RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID,
+			Name:  wp.cfg.ContinueAsNewSignal,
+		},
+		input,
+		nil,
+	)
+}
+
+// parentWorkflowExecution returns the parent execution of the child workflow, nil for the root workflows
+func (wp *Workflow) parentWorkflowExecution() *internal.ParentWorkflowExecution {
+	info := wp.env.WorkflowInfo()
+	if info.ParentWorkflowExecution == nil {
+		return nil
+	}
+
+	return &internal.ParentWorkflowExecution{
+		Namespace:  info.ParentWorkflowNamespace,
+		WorkflowID: info.ParentWorkflowExecution.ID,
+		RunID:      info.ParentWorkflowExecution.RunID,
+	}
+}
+
+// continueAsNewThresholdReached returns true if the history crossed the configured length or size threshold,
+// the history length and size are the same on replay
+func (wp *Workflow) continueAsNewThresholdReached() bool {
+	if wp.cfg == nil {
+		return false
+	}
+
+	info := wp.env.WorkflowInfo()
+
+	if wp.cfg.ContinueAsNewHistoryLength > 0 && info.GetCurrentHistoryLength() >= wp.cfg.ContinueAsNewHistoryLength {
+		return true
+	}
+
+	return wp.cfg.ContinueAsNewHistorySize > 0 && info.GetCurrentHistorySize() >= wp.cfg.ContinueAsNewHistorySize
+}
+
 // Workflow incoming command
 func (wp *Workflow) handleMessage(msg *internal.Message) error {
 	const op = errors.Op("handleMessage")
@@ -141,8 +374,40 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 	switch command := msg.Command.(type) {
 	case *internal.ExecuteActivity:
 		wp.log.Debug("activity request", zap.Uint64("ID", msg.ID))
-		params := command.ActivityParams(wp.env, msg.Payloads, msg.Header)
-		activityID := wp.env.ExecuteActivity(params, wp.createCallback(msg.ID, "activity"))
+		command.InheritOptions(wp.activityOptions)
+		if wp.cfg != nil && wp.cfg.ActivityValidation != "" && wp.cfg.ActivityValidation != ActivityValidationOff {
+			err := validateActivity(command)
+			if err != nil && wp.cfg.ActivityValidation == ActivityValidationStrict {
+				wp.mq.PushError(msg.ID, temporal.GetDefaultFailureConverter().ErrorToFailure(
+					temporal.NewNonRetryableApplicationError(err.Error(), invalidActivityOptionsErrType, nil)))
+				return nil
+			}
+
+			if err != nil {
+				wp.log.Warn("activity options validation failed", zap.Uint64("ID", msg.ID), zap.Error(err))
+			}
+		}
+
+		err := checkActivityPayloads(command.DataConverter, msg.Payloads)
+		if err != nil {
+			wp.mq.PushError(msg.ID, temporal.GetDefaultFailureConverter().ErrorToFailure(
+				temporal.NewNonRetryableApplicationError(err.Error(), dataConverterErrType, nil)))
+			return nil
+		}
+
+		params := command.ActivityParams(wp.env, msg.Payloads, withDataConverter(wp.commandHeader(msg.Header), command.DataConverter))
+		if command.VersioningIntent != "" {
+			params.VersioningIntent, err = internal.VersioningIntent(command.VersioningIntent)
+			if err != nil {
+				return errors.E(op, err)
+			}
+		}
+		// activities stay on the task queue they are registered on when the workflow is routed
+		if command.Options.TaskQueueName == "" {
+			params.TaskQueueName = wp.taskQueue()
+		}
+
+		activityID := wp.env.ExecuteActivity(params, wp.activityMetricsCallback(command.Name, wp.createCallback(msg.ID, "activity")))
 
 		wp.canceller.Register(msg.ID, func() error {
 			wp.log.Debug("registering activity canceller", zap.String("activityID", activityID.String()))
@@ -152,17 +417,45 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 
 	case *internal.ExecuteLocalActivity:
 		wp.log.Debug("local activity request", zap.Uint64("ID", msg.ID))
-		params := command.LocalActivityParams(wp.env, wp.la, msg.Payloads, msg.Header)
-		activityID := wp.env.ExecuteLocalActivity(params, wp.createLocalActivityCallback(msg.ID))
-		wp.canceller.Register(msg.ID, func() error {
-			wp.log.Debug("registering local activity canceller", zap.String("activityID", activityID.String()))
-			wp.env.RequestCancelLocalActivity(activityID)
+		// the input is validated before scheduling, the activity worker may rely on the converter
+		err := checkLocalActivityPayloads(command.DataConverter, msg.Payloads)
+		if err != nil {
+			wp.mq.PushError(msg.ID, temporal.GetDefaultFailureConverter().ErrorToFailure(
+				temporal.NewNonRetryableApplicationError(err.Error(), dataConverterErrType, nil)))
 			return nil
-		})
+		}
+
+		wp.scheduleLocalActivity(msg, command)
 
 	case *internal.ExecuteChildWorkflow:
 		wp.log.Debug("execute child workflow request", zap.Uint64("ID", msg.ID))
-		params := command.WorkflowParams(wp.env, msg.Payloads, msg.Header)
+		// parent header is propagated to the child and passed to its StartWorkflow command
+		params := command.WorkflowParams(wp.env, msg.Payloads, wp.commandHeader(msg.Header))
+		if command.Options.TaskQueueName == "" {
+			params.TaskQueueName = wp.workflowTaskQueue(command.Name)
+		}
+
+		if command.VersioningIntent != "" {
+			vi, err := internal.VersioningIntent(command.VersioningIntent)
+			if err != nil {
+				return errors.E(op, err)
+			}
+
+			params.VersioningIntent = vi
+		}
+
+		if params.RetryPolicy == nil {
+			params.RetryPolicy = wp.cfg.retryPolicy(command.Name)
+		}
+
+		if len(command.SearchAttributes) > 0 {
+			sau, err := typedSearchAttributes(command.SearchAttributes)
+			if err != nil {
+				return errors.E(op, err)
+			}
+
+			params.TypedSearchAttributes = temporal.NewSearchAttributes(sau...)
+		}
 
 		// always use deterministic id
 		if params.WorkflowID == "" {
@@ -210,6 +503,10 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			return nil
 		})
 
+		if command.Name != "" {
+			wp.canceller.Name(command.Name, msg.ID)
+		}
+
 	case *internal.GetVersion:
 		wp.log.Debug("get version request", zap.Uint64("ID", msg.ID))
 		version := wp.env.GetVersion(
@@ -229,6 +526,83 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			return errors.E(op, err)
 		}
 
+	case *internal.GetWorkflowInfo:
+		wp.log.Debug("get workflow info request", zap.Uint64("ID", msg.ID))
+		// the info is a part of the workflow state, the same on replay
+		result, err := wp.env.GetDataConverter().ToPayloads(wp.env.WorkflowInfo())
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.mq.PushResponse(msg.ID, result)
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.GetParentWorkflowExecution:
+		wp.log.Debug("get parent workflow execution request", zap.Uint64("ID", msg.ID))
+		result, err := wp.env.GetDataConverter().ToPayloads(wp.parentWorkflowExecution())
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.mq.PushResponse(msg.ID, result)
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.GetCurrentTime:
+		wp.log.Debug("get current time request", zap.Uint64("ID", msg.ID), zap.String("timezone", command.Timezone))
+		now, err := currentTime(wp.env.Now(), command.Timezone)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		result, err := wp.env.GetDataConverter().ToPayloads(now)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.mq.PushResponse(msg.ID, result)
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.GetContinueAsNewSuggestion:
+		wp.log.Debug("get continue-as-new suggestion request", zap.Uint64("ID", msg.ID))
+		// history length and size are the same on replay
+		result, err := wp.env.GetDataConverter().ToPayloads(wp.continueAsNewSuggestion())
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.mq.PushResponse(msg.ID, result)
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.GetRandom:
+		wp.log.Debug("get random request", zap.Uint64("ID", msg.ID), zap.Int("count", command.Count))
+		values, err := wp.randomValues(command.Count, command.Max)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		result, err := wp.env.GetDataConverter().ToPayloads(values)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.mq.PushResponse(msg.ID, result)
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
 	case *internal.SideEffect:
 		wp.log.Debug("side-effect request", zap.Uint64("ID", msg.ID))
 		wp.env.SideEffect(
@@ -247,13 +621,13 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 		}
 
 		if _, ok := wp.updateCompleteCb[command.ID]; !ok {
-			wp.log.Warn("no such update ID, can't complete update", zap.String("requested id", command.ID))
-			// TODO(rustatian): error here?
-			return nil
+			return wp.missingUpdateCallback("complete", command.ID, wp.updateCompleteCb)
 		}
 
 		wp.updateCompleteCb[command.ID](msg)
 		delete(wp.updateCompleteCb, command.ID)
+		wp.recordUpdateWorker(command.ID, true)
+		wp.reportInFlightUpdates()
 
 	case *internal.UpdateValidated:
 		wp.log.Debug("validate update request", zap.String("update id", command.ID))
@@ -264,16 +638,16 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 		}
 
 		if _, ok := wp.updateValidateCb[command.ID]; !ok {
-			wp.log.Warn("no such update ID, can't validate update", zap.String("requested id", command.ID))
-			// TODO(rustatian): error here?
-			return nil
+			return wp.missingUpdateCallback("validate", command.ID, wp.updateValidateCb)
 		}
 
 		wp.updateValidateCb[command.ID](msg)
 		delete(wp.updateValidateCb, command.ID)
+		wp.recordUpdateWorker(command.ID, false)
 		// delete updateCompleteCb in case of error
 		if msg.Failure != nil {
 			delete(wp.updateCompleteCb, command.ID)
+			wp.reportInFlightUpdates()
 		}
 
 	case *internal.CompleteWorkflow:
@@ -282,14 +656,43 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 		wp.mq.PushResponse(msg.ID, result)
 
 		if msg.Failure == nil {
+			err := wp.limits.check(wp.log, "workflow", wp.env.WorkflowInfo().WorkflowType.Name, msg.Payloads)
+			if err != nil {
+				return errors.E(op, err)
+			}
+
 			wp.env.Complete(msg.Payloads, nil)
 			return nil
 		}
 
-		wp.env.Complete(nil, temporal.GetDefaultFailureConverter().FailureToError(msg.Failure))
+		// canceled failure completes the workflow as canceled, the other ones as failed
+		f, err := command.Failure(msg.Failure)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.env.Complete(nil, temporal.GetDefaultFailureConverter().FailureToError(f))
 
 	case *internal.ContinueAsNew:
 		wp.log.Debug("continue-as-new request", zap.Uint64("ID", msg.ID), zap.String("name", command.Name))
+		err := wp.limits.check(wp.log, "continue-as-new", command.Name, msg.Payloads)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		// memo is inherited by the new run, nil values remove the keys
+		if len(command.Options.Memo) > 0 {
+			err = wp.env.UpsertMemo(command.Options.Memo)
+			if err != nil {
+				return errors.E(op, err)
+			}
+		}
+
+		taskQueue := command.Options.TaskQueueName
+		if taskQueue == "" {
+			taskQueue = wp.workflowTaskQueue(command.Name)
+		}
+
 		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
 		wp.mq.PushResponse(msg.ID, result)
 
@@ -299,7 +702,7 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			},
 			Input:               msg.Payloads,
 			Header:              msg.Header,
-			TaskQueueName:       command.Options.TaskQueueName,
+			TaskQueueName:       taskQueue,
 			WorkflowRunTimeout:  command.Options.WorkflowRunTimeout,
 			WorkflowTaskTimeout: command.Options.WorkflowTaskTimeout,
 		})
@@ -313,158 +716,9 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 
 	case *internal.UpsertWorkflowTypedSearchAttributes:
 		wp.log.Debug("upsert typed search attributes request", zap.Uint64("ID", msg.ID), zap.Any("search_attributes", command.SearchAttributes))
-		var sau []temporal.SearchAttributeUpdate
-
-		for k, v := range command.SearchAttributes {
-			switch v.Type {
-			case internal.BoolType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyBool(k).ValueUnset())
-					continue
-				}
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				if tt, ok := v.Value.(bool); ok {
-					sau = append(sau, temporal.NewSearchAttributeKeyBool(k).ValueSet(tt))
-				} else {
-					wp.log.Warn("field value is not a bool type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-
-			case internal.FloatType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyFloat64(k).ValueUnset())
-					continue
-				}
-
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				if tt, ok := v.Value.(float64); ok {
-					sau = append(sau, temporal.NewSearchAttributeKeyFloat64(k).ValueSet(tt))
-				} else {
-					wp.log.Warn("field value is not a float64 type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-
-			case internal.IntType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueUnset())
-					continue
-				}
-
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				switch ti := v.Value.(type) {
-				case float64:
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(int64(ti)))
-				case int:
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(int64(ti)))
-				case int64:
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(ti))
-				case int32:
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(int64(ti)))
-				case int16:
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(int64(ti)))
-				case int8:
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(int64(ti)))
-				case string:
-					i, err := strconv.ParseInt(ti, 10, 64)
-					if err != nil {
-						wp.log.Warn("failed to parse int", zap.Error(err))
-						continue
-					}
-					sau = append(sau, temporal.NewSearchAttributeKeyInt64(k).ValueSet(i))
-				default:
-					wp.log.Warn("field value is not an int type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-
-			case internal.KeywordType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyKeyword(k).ValueUnset())
-					continue
-				}
-
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				if tt, ok := v.Value.(string); ok {
-					sau = append(sau, temporal.NewSearchAttributeKeyKeyword(k).ValueSet(tt))
-				} else {
-					wp.log.Warn("field value is not a string type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-			case internal.KeywordListType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyKeywordList(k).ValueUnset())
-					continue
-				}
-
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				switch tt := v.Value.(type) {
-				case []string:
-					sau = append(sau, temporal.NewSearchAttributeKeyKeywordList(k).ValueSet(tt))
-				case []any:
-					var res []string
-					for _, v := range tt {
-						if s, ok := v.(string); ok {
-							res = append(res, s)
-						}
-					}
-					sau = append(sau, temporal.NewSearchAttributeKeyKeywordList(k).ValueSet(res))
-				default:
-					wp.log.Warn("field value is not a []string (strings array) type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-
-			case internal.StringType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyString(k).ValueUnset())
-					continue
-				}
-
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				if tt, ok := v.Value.(string); ok {
-					sau = append(sau, temporal.NewSearchAttributeKeyString(k).ValueSet(tt))
-				} else {
-					wp.log.Warn("field value is not a string type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-			case internal.DatetimeType:
-				if v.Operation == internal.TypedSearchAttributeOperationUnset {
-					sau = append(sau, temporal.NewSearchAttributeKeyTime(k).ValueUnset())
-					continue
-				}
-
-				if v.Value == nil {
-					wp.log.Warn("field value is not set", zap.String("key", k))
-					continue
-				}
-
-				if tt, ok := v.Value.(string); ok {
-					tm, err := time.Parse(time.RFC3339, tt)
-					if err != nil {
-						return errors.E(op, fmt.Errorf("failed to parse time into RFC3339: %w", err))
-					}
-
-					sau = append(sau, temporal.NewSearchAttributeKeyTime(k).ValueSet(tm))
-				} else {
-					wp.log.Warn("bool field value is not a bool type", zap.String("key", k), zap.Any("value", v.Value))
-				}
-			}
+		sau, err := typedSearchAttributes(command.SearchAttributes)
+		if err != nil {
+			return errors.E(op, err)
 		}
 
 		if len(sau) == 0 {
@@ -472,24 +726,14 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			return nil
 		}
 
-		err := wp.env.UpsertTypedSearchAttributes(temporal.NewSearchAttributes(sau...))
+		err = wp.env.UpsertTypedSearchAttributes(temporal.NewSearchAttributes(sau...))
 		if err != nil {
 			return errors.E(op, err)
 		}
 
 	case *internal.SignalExternalWorkflow:
 		wp.log.Debug("signal external workflow request", zap.Uint64("ID", msg.ID))
-		wp.env.SignalExternalWorkflow(
-			command.Namespace,
-			command.WorkflowID,
-			command.RunID,
-			command.Signal,
-			msg.Payloads,
-			nil,
-			msg.Header,
-			command.ChildWorkflowOnly,
-			wp.createCallback(msg.ID, "SignalExternalWorkflow"),
-		)
+		wp.signalExternalWorkflow(msg, command)
 
 	case *internal.CancelExternalWorkflow:
 		wp.log.Debug("cancel external workflow request", zap.Uint64("ID", msg.ID))
@@ -502,6 +746,13 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			return errors.E(op, err)
 		}
 
+		for i := range command.Scopes {
+			err = wp.canceller.CancelScope(command.Scopes[i])
+			if err != nil {
+				return errors.E(op, err)
+			}
+		}
+
 		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
 		wp.mq.PushResponse(msg.ID, result)
 
@@ -511,9 +762,149 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 		}
 
 	case *internal.Panic:
-		wp.log.Debug("panic", zap.String("failure", msg.Failure.String()))
-		// do not wrap error to pass it directly to Temporal
-		return temporal.GetDefaultFailureConverter().FailureToError(msg.Failure)
+		wp.log.Debug("panic", zap.String("failure", msg.Failure.String()), zap.String("policy", string(command.Policy)))
+		var pr *PanicRedaction
+		if wp.cfg != nil {
+			pr = wp.cfg.PanicRedaction
+		}
+
+		// the failure is recorded in the history, redacted before the conversion
+		var perr error
+		if msg.Failure != nil {
+			perr = temporal.GetDefaultFailureConverter().FailureToError(pr.redact(msg.Failure))
+		} else {
+			perr = errors.Str(pr.redactString(command.Message))
+		}
+
+		switch command.Policy {
+		case internal.PanicFailWorkflow:
+			// terminal, the execution is completed with the failure
+			wp.env.Complete(nil, perr)
+			return nil
+		case "", internal.PanicFailTask:
+			// do not wrap error to pass it directly to Temporal
+			return perr
+		default:
+			return errors.E(op, errors.Errorf("unknown panic policy: %s, failure: %v", command.Policy, perr))
+		}
+
+	case *internal.CancellationScope:
+		wp.log.Debug("cancellation scope request", zap.Uint64("ID", msg.ID), zap.String("scope", command.ScopeID), zap.String("parent", command.ParentID), zap.Bool("disconnected", command.Disconnected))
+		if command.ScopeID == "" {
+			return errors.E(op, errors.Str("cancellation scope id should not be empty"))
+		}
+
+		if command.Disconnected {
+			wp.canceller.Disconnect(command.ScopeID)
+		}
+
+		wp.canceller.Scope(command.ScopeID, command.ParentID, command.CommandIDs...)
+
+	case *internal.CancelAll:
+		wp.log.Debug("cancel all request", zap.Uint64("ID", msg.ID))
+		err := wp.canceller.CancelAll()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+		wp.mq.PushResponse(msg.ID, result)
+
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.CancelTimer:
+		wp.log.Debug("cancel timer request", zap.Uint64("ID", msg.ID), zap.String("name", command.Name))
+		if command.Name == "" {
+			return errors.E(op, errors.Str("timer name should not be empty"))
+		}
+
+		err := wp.canceller.CancelName(command.Name)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+		wp.mq.PushResponse(msg.ID, result)
+
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.SetActivityOptions:
+		wp.log.Debug("set activity options request", zap.Uint64("ID", msg.ID))
+		// the worker sends the command in the same order on replay, nothing is recorded in the history;
+		// the empty options inherit nothing, so they remove the defaults
+		wp.activityOptions = &command.Options
+
+		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+		wp.mq.PushResponse(msg.ID, result)
+
+		err := wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.Select:
+		wp.log.Debug("select request", zap.Uint64("ID", msg.ID), zap.Uint64s("ids", command.CommandIDs), zap.Strings("signals", command.Signals))
+		if len(command.CommandIDs) == 0 && len(command.Signals) == 0 {
+			return errors.E(op, errors.Str("select should contain at least one command id or signal"))
+		}
+
+		wp.registerSelect(msg.ID, command)
+
+	case *internal.AcquireSemaphore:
+		wp.log.Debug("acquire semaphore request", zap.Uint64("ID", msg.ID), zap.String("name", command.Name), zap.Int64("permits", command.Permits))
+		err := wp.acquireSemaphore(msg.ID, command)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.ReleaseSemaphore:
+		wp.log.Debug("release semaphore request", zap.Uint64("ID", msg.ID), zap.String("name", command.Name), zap.Int64("permits", command.Permits))
+		err := wp.releaseSemaphore(command)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+		wp.mq.PushResponse(msg.ID, result)
+
+		err = wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.EmitMetric:
+		wp.log.Debug("emit metric request", zap.Uint64("ID", msg.ID), zap.String("name", command.Name), zap.String("type", string(command.Type)))
+		err := wp.emitMetric(command)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.Log:
+		wp.log.Debug("log request", zap.Uint64("ID", msg.ID), zap.String("level", string(command.Level)))
+		wp.workerLog(command)
+
+	case *internal.PublishQueryState:
+		wp.log.Debug("publish query state request", zap.Uint64("ID", msg.ID), zap.Strings("queries", command.Queries), zap.Strings("remove", command.Remove))
+		err := wp.publishQueryState(command, msg.Payloads)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.SetCurrentDetails:
+		wp.log.Debug("set current details request", zap.Uint64("ID", msg.ID))
+		// not a history event, should be restored on replay as well
+		wp.currentDetails = command.Details
 
 	case *internal.UpsertMemo:
 		wp.log.Debug("upsert memo request", zap.Uint64("ID", msg.ID), zap.Any("memos", command.Memo))
@@ -526,26 +917,106 @@ func (wp *Workflow) handleMessage(msg *internal.Message) error {
 			return errors.E(op, err)
 		}
 
+	case *internal.ReportUpdateProgress:
+		wp.log.Debug("report update progress request", zap.Uint64("ID", msg.ID), zap.String("update_id", command.UpdateID))
+		if command.UpdateID == "" {
+			return errors.E(op, errors.Str("update id should not be empty"))
+		}
+
+		// nil value removes the memo key
+		err := wp.env.UpsertMemo(map[string]any{command.MemoKey(): command.Progress})
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.ReportProgress:
+		wp.log.Debug("report progress request", zap.Uint64("ID", msg.ID), zap.Float64("percent", command.Percent), zap.String("stage", command.Stage))
+		err := command.Validate()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		// the unchanged progress is not upserted again. The check doesn't depend on the replay state (the memo upsert
+		// is a command), so the same commands are produced on replay.
+		if wp.progress != nil && *wp.progress == *command {
+			return nil
+		}
+
+		err = wp.env.UpsertMemo(map[string]any{internal.ProgressMemoKey: &internal.WorkflowProgress{
+			Percent:   command.Percent,
+			Stage:     command.Stage,
+			Message:   command.Message,
+			UpdatedAt: wp.env.Now(),
+		}})
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		wp.progress = command
+
+	case *internal.GetPendingCommands:
+		wp.log.Debug("get pending commands request", zap.Uint64("ID", msg.ID))
+		if wp.cfg == nil || !wp.cfg.DebugCommands {
+			wp.mq.PushError(msg.ID, temporal.GetDefaultFailureConverter().ErrorToFailure(
+				temporal.NewNonRetryableApplicationError("GetPendingCommands requires the workflows.debug_commands option", debugCommandsErrType, nil)))
+		} else {
+			// collected before the response is queued
+			result, err := wp.env.GetDataConverter().ToPayloads(wp.pendingCommands())
+			if err != nil {
+				return errors.E(op, err)
+			}
+
+			wp.mq.PushResponse(msg.ID, result)
+		}
+
+		err := wp.flushQueue()
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.ForceNewWorkflowTask:
+		wp.log.Debug("force new workflow task request", zap.Uint64("ID", msg.ID), zap.Int("task_commands", wp.taskCommands))
+		err := wp.forceNewWorkflowTask(msg.ID)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+	case *internal.UnknownCommand:
+		if !wp.cfg.SkipUnknownCommands {
+			return errors.E(op, errors.Errorf("unknown command: %s, possible outdated RoadRunner version", command.Name))
+		}
+
+		// no response is sent, the worker should not wait for it
+		wp.log.Warn("unknown command skipped, possible outdated RoadRunner version", zap.Uint64("ID", msg.ID), zap.String("command", command.Name))
+
 	default:
-		return errors.E(op, errors.Str("undefined command"))
+		return errors.E(op, errors.Errorf("undefined command: %T", msg.Command))
 	}
 
 	return nil
 }
 
-func (wp *Workflow) createLocalActivityCallback(id uint64) bindings.LocalActivityResultHandler {
+// createLocalActivityCallback creates the local activity result handler, retry (optional) schedules the retry of the
+// failed attempt and returns true if it was scheduled.
+func (wp *Workflow) createLocalActivityCallback(id uint64, retry func(lar *bindings.LocalActivityResultWrapper) bool) bindings.LocalActivityResultHandler {
 	callback := func(lar *bindings.LocalActivityResultWrapper) {
 		wp.log.Debug("executing local activity callback", zap.Uint64("ID", id))
 		wp.canceller.Discard(id)
 
+		if lar.Err != nil && retry != nil && retry(lar) {
+			return
+		}
+
 		if lar.Err != nil {
 			wp.log.Debug("error", zap.Error(lar.Err), zap.Int32("attempt", lar.Attempt), zap.Duration("backoff", lar.Backoff))
 			wp.mq.PushError(id, temporal.GetDefaultFailureConverter().ErrorToFailure(lar.Err))
+			wp.resolveSelect(id, "")
 			return
 		}
 
 		wp.log.Debug("pushing local activity response", zap.Uint64("ID", id))
 		wp.mq.PushResponse(id, lar.Result)
+		wp.resolveSelect(id, "")
 	}
 
 	return func(lar *bindings.LocalActivityResultWrapper) {
@@ -572,12 +1043,14 @@ func (wp *Workflow) createCallback(id uint64, t string) bindings.ResultHandler {
 		if err != nil {
 			wp.log.Debug("error", zap.Error(err), zap.String("type", t))
 			wp.mq.PushError(id, temporal.GetDefaultFailureConverter().ErrorToFailure(err))
+			wp.resolveSelect(id, "")
 			return
 		}
 
 		wp.log.Debug("pushing response", zap.Uint64("ID", id), zap.String("type", t))
 		// fetch original payload
 		wp.mq.PushResponse(id, result)
+		wp.resolveSelect(id, "")
 	}
 
 	return func(result *commonpb.Payloads, err error) {
@@ -604,10 +1077,12 @@ func (wp *Workflow) createContinuableCallback(id uint64, t string) bindings.Resu
 
 		if err != nil {
 			wp.mq.PushError(id, temporal.GetDefaultFailureConverter().ErrorToFailure(err))
+			wp.resolveSelect(id, "")
 			return
 		}
 
 		wp.mq.PushResponse(id, result)
+		wp.resolveSelect(id, "")
 		err = wp.flushQueue()
 		if err != nil {
 			panic(err)
@@ -633,41 +1108,60 @@ func (wp *Workflow) flushQueue() error {
 	}
 
 	pl := wp.getPld()
-	defer wp.putPld(pl)
+	// after the exec timeout the pool might still write the frame, the buffer is reused only once the worker responded
+	responded := false
+	defer func() {
+		if responded {
+			wp.putPld(pl)
+		}
+	}()
 	err := wp.codec.Encode(wp.getContext(), pl, wp.mq.Messages()...)
 	if err != nil {
 		return err
 	}
 
-	ch := make(chan struct{}, 1)
-	result, err := wp.pool.Exec(context.Background(), pl, ch)
-	if err != nil {
-		return err
+	if wp.limiter != nil {
+		release, errL := wp.limiter.acquire(wp.mh)
+		if errL != nil {
+			return errors.E(op, errL)
+		}
+		defer release()
 	}
 
 	var r *payload.Payload
-	select {
-	case pld := <-result:
-		if pld.Error() != nil {
-			return errors.E(op, pld.Error())
+	// the batch is resent only when it was not processed by the worker, see transientExecError
+	err = wp.retryExec(func() error {
+		ctx, cancel := wp.execContext()
+		defer cancel()
+
+		ch := make(chan struct{}, 1)
+		result, errE := wp.pool.Exec(ctx, pl, ch)
+		if errE != nil {
+			return errE
 		}
-		// streaming is not supported
-		if pld.Payload().Flags&frame.STREAM != 0 {
-			ch <- struct{}{}
-			return errors.E(op, errors.Str("streaming is not supported"))
+
+		r, errE = wp.receiveResult(result, ch, wp.mq.Messages())
+		if errE != nil {
+			return errors.E(op, errE)
 		}
 
-		// assign the payload
-		r = pld.Payload()
-	default:
-		return errors.E(op, errors.Str("worker empty response"))
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	responded = true
 
 	msgs := make([]*internal.Message, 0, 2)
 	err = wp.codec.Decode(r, &msgs)
 	if err != nil {
 		return err
 	}
+
+	if wp.cfg.PrioritizeCancellation {
+		prioritize(msgs)
+	}
+
 	wp.mq.Flush()
 	wp.pipeline = append(wp.pipeline, msgs...)
 
@@ -692,30 +1186,28 @@ func (wp *Workflow) runCommand(cmd any, payloads *commonpb.Payloads, header *com
 		return nil, err
 	}
 
-	// todo(rustatian): do we need a timeout here??
+	if wp.limiter != nil {
+		release, errL := wp.limiter.acquire(wp.mh)
+		if errL != nil {
+			wp.putPld(pl)
+			return nil, errors.E(op, errL)
+		}
+		defer release()
+	}
+
+	ctx, cancel := wp.execContext()
+	defer cancel()
+
 	ch := make(chan struct{}, 1)
-	result, err := wp.pool.Exec(context.Background(), pl, ch)
+	result, err := wp.pool.Exec(ctx, pl, ch)
 	if err != nil {
-		wp.putPld(pl)
+		// the buffer is not reused, the pool might still write it after the timeout
 		return nil, err
 	}
 
-	var r *payload.Payload
-	select {
-	case pld := <-result:
-		if pld.Error() != nil {
-			return nil, errors.E(op, pld.Error())
-		}
-		// streaming is not supported
-		if pld.Payload().Flags&frame.STREAM != 0 {
-			ch <- struct{}{}
-			return nil, errors.E(op, errors.Str("streaming is not supported"))
-		}
-
-		// assign the payload
-		r = pld.Payload()
-	default:
-		return nil, errors.E(op, errors.Str("worker empty response"))
+	r, err := wp.receiveResult(result, ch, []*internal.Message{msg})
+	if err != nil {
+		return nil, errors.E(op, err)
 	}
 
 	msgs := make([]*internal.Message, 0, 2)
@@ -739,6 +1231,7 @@ func (wp *Workflow) getPld() *payload.Payload {
 }
 
 func (wp *Workflow) putPld(pld *payload.Payload) {
+	wp.codec.Release(pld)
 	pld.Codec = 0
 	pld.Context = nil
 	pld.Body = nil
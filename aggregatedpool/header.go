@@ -0,0 +1,95 @@
+package aggregatedpool
+
+import (
+	"maps"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// mergeHeaders propagates the workflow header to the command (activity, child workflow) header, the command fields take precedence
+func mergeHeaders(wfHeader *commonpb.Header, cmdHeader *commonpb.Header) *commonpb.Header {
+	if len(wfHeader.GetFields()) == 0 {
+		return cmdHeader
+	}
+
+	fields := make(map[string]*commonpb.Payload, len(wfHeader.GetFields())+len(cmdHeader.GetFields()))
+	for k, v := range wfHeader.GetFields() {
+		fields[k] = v
+	}
+
+	for k, v := range cmdHeader.GetFields() {
+		fields[k] = v
+	}
+
+	return &commonpb.Header{Fields: fields}
+}
+
+// commandHeader is the header of the activity, local activity or child workflow command: the workflow header
+// (the propagated keys only, if configured) merged with the command header
+func (wp *Workflow) commandHeader(cmdHeader *commonpb.Header) *commonpb.Header {
+	return mergeHeaders(wp.cfg.propagatedHeader(wp.header), cmdHeader)
+}
+
+// signalHeader is the header of the external signal, the workflow header is propagated only if the keys are configured
+func (wp *Workflow) signalHeader(cmdHeader *commonpb.Header) *commonpb.Header {
+	if wp.cfg == nil || len(wp.cfg.PropagateHeaders) == 0 {
+		return cmdHeader
+	}
+
+	return mergeHeaders(wp.cfg.propagatedHeader(wp.header), cmdHeader)
+}
+
+// propagatedHeader returns the workflow header fields to propagate, the whole header if the keys are not configured
+func (c *WorkflowConfig) propagatedHeader(wfHeader *commonpb.Header) *commonpb.Header {
+	if c == nil || len(c.PropagateHeaders) == 0 || len(wfHeader.GetFields()) == 0 {
+		return wfHeader
+	}
+
+	fields := make(map[string]*commonpb.Payload, len(c.PropagateHeaders))
+	for _, k := range c.PropagateHeaders {
+		if v, ok := wfHeader.GetFields()[k]; ok {
+			fields[k] = v
+		}
+	}
+
+	return &commonpb.Header{Fields: fields}
+}
+
+// withDataConverter returns the activity header with the requested data converter, the converter propagated from the
+// workflow header is removed when the default one is requested
+func withDataConverter(hdr *commonpb.Header, dataConverter string) *commonpb.Header {
+	if dataConverter == "" {
+		_, res := dataConverterFromHeader(hdr)
+		return res
+	}
+
+	fields := maps.Clone(hdr.GetFields())
+	if fields == nil {
+		fields = make(map[string]*commonpb.Payload, 1)
+	}
+
+	fields[internal.DataConverterHeader] = &commonpb.Payload{
+		Metadata: map[string][]byte{converter.MetadataEncoding: []byte(converter.MetadataEncodingBinary)},
+		Data:     []byte(dataConverter),
+	}
+
+	return &commonpb.Header{Fields: fields}
+}
+
+// dataConverterFromHeader returns the data converter requested for the activity and the header without it
+func dataConverterFromHeader(hdr *commonpb.Header) (string, *commonpb.Header) {
+	dc, ok := hdr.GetFields()[internal.DataConverterHeader]
+	if !ok {
+		return "", hdr
+	}
+
+	fields := maps.Clone(hdr.GetFields())
+	delete(fields, internal.DataConverterHeader)
+	if len(fields) == 0 {
+		return string(dc.GetData()), nil
+	}
+
+	return string(dc.GetData()), &commonpb.Header{Fields: fields}
+}
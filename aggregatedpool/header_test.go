@@ -0,0 +1,137 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/api"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+func Test_MergeHeaders(t *testing.T) {
+	wf := &commonpb.Header{Fields: map[string]*commonpb.Payload{
+		"auth":  {Data: []byte("token")},
+		"trace": {Data: []byte("wf")},
+	}}
+	cmd := &commonpb.Header{Fields: map[string]*commonpb.Payload{
+		"trace": {Data: []byte("activity")},
+	}}
+
+	hdr := mergeHeaders(wf, cmd)
+	require.Len(t, hdr.GetFields(), 2)
+	assert.Equal(t, []byte("token"), hdr.GetFields()["auth"].GetData())
+	assert.Equal(t, []byte("activity"), hdr.GetFields()["trace"].GetData())
+
+	// the workflow header is not modified
+	assert.Equal(t, []byte("wf"), wf.GetFields()["trace"].GetData())
+
+	assert.Equal(t, cmd, mergeHeaders(nil, cmd))
+	assert.Equal(t, wf.GetFields(), mergeHeaders(wf, nil).GetFields())
+}
+
+func Test_WorkflowHeaderReachesActivity(t *testing.T) {
+	wf := &commonpb.Header{Fields: map[string]*commonpb.Payload{
+		"auth": {Data: []byte("token")},
+	}}
+
+	// the activity interceptor stores the header fields under the RR key, the activity handler reads them from there
+	ctx := context.WithValue(context.Background(), api.HeaderContextKey, mergeHeaders(wf, nil).GetFields())
+
+	hdr := api.ActivityHeadersFromCtx(ctx)
+	require.NotNil(t, hdr)
+	assert.Equal(t, []byte("token"), hdr.GetFields()["auth"].GetData())
+}
+
+func Test_ParentHeaderReachesChild(t *testing.T) {
+	parent := &commonpb.Header{Fields: map[string]*commonpb.Payload{
+		"tenant": {Data: []byte("acme")},
+		"locale": {Data: []byte("en")},
+	}}
+	cmdHeader := &commonpb.Header{Fields: map[string]*commonpb.Payload{
+		"locale": {Data: []byte("de")},
+	}}
+
+	cmd := internal.ExecuteChildWorkflow{Name: "ChildWorkflow"}
+	params := cmd.WorkflowParams(&fakeEnv{}, nil, mergeHeaders(parent, cmdHeader))
+
+	// the child receives this header in Execute and sends it with the StartWorkflow command
+	require.NotNil(t, params.Header)
+	assert.Equal(t, []byte("acme"), params.Header.GetFields()["tenant"].GetData())
+	assert.Equal(t, []byte("de"), params.Header.GetFields()["locale"].GetData())
+}
+
+func Test_CorrelationHeaderTwoHops(t *testing.T) {
+	cfg := &WorkflowConfig{PropagateHeaders: []string{"baggage"}}
+	parent := &Workflow{cfg: cfg, header: &commonpb.Header{Fields: map[string]*commonpb.Payload{
+		"baggage": {Data: []byte("correlation-id=42")},
+		"auth":    {Data: []byte("token")},
+	}}}
+
+	// hop 1: parent -> child workflow, only the configured keys are propagated
+	childCmd := internal.ExecuteChildWorkflow{Name: "ChildWorkflow"}
+	childParams := childCmd.WorkflowParams(&fakeEnv{}, nil, parent.commandHeader(nil))
+	assert.Equal(t, []byte("correlation-id=42"), childParams.Header.GetFields()["baggage"].GetData())
+	assert.NotContains(t, childParams.Header.GetFields(), "auth")
+
+	// hop 2: the child receives the header in Execute -> activity and external signal
+	child := &Workflow{cfg: cfg, header: childParams.Header}
+	actCmd := internal.ExecuteActivity{Name: "SendEmail"}
+	actParams := actCmd.ActivityParams(&fakeEnv{}, nil, child.commandHeader(nil))
+	assert.Equal(t, []byte("correlation-id=42"), actParams.Header.GetFields()["baggage"].GetData())
+
+	signal := child.signalHeader(&commonpb.Header{Fields: map[string]*commonpb.Payload{"locale": {Data: []byte("de")}}})
+	assert.Equal(t, []byte("correlation-id=42"), signal.GetFields()["baggage"].GetData())
+	assert.Equal(t, []byte("de"), signal.GetFields()["locale"].GetData())
+
+	// not configured: the whole header goes to the commands, nothing to the signals
+	wp := &Workflow{cfg: &WorkflowConfig{}, header: parent.header}
+	assert.Len(t, wp.commandHeader(nil).GetFields(), 2)
+	assert.Nil(t, wp.signalHeader(nil))
+}
+
+func Test_ActivityDataConverter(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{
+		env:       env,
+		log:       zap.NewNop(),
+		cfg:       &WorkflowConfig{},
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+		header:    &commonpb.Header{Fields: map[string]*commonpb.Payload{"tenant": {Data: []byte("acme")}}},
+	}
+
+	// encrypted and plaintext calls in the same workflow
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.ExecuteActivity{Name: "StoreCard", DataConverter: "encrypted"}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.ExecuteActivity{Name: "SendEmail"}}))
+	require.Len(t, env.params, 2)
+
+	// the activity handler passes the converter to the worker and strips it from the header
+	dc, hdr := dataConverterFromHeader(env.params[0].Header)
+	assert.Equal(t, "encrypted", dc)
+	assert.Equal(t, []byte("acme"), hdr.GetFields()["tenant"].GetData())
+	assert.NotContains(t, hdr.GetFields(), internal.DataConverterHeader)
+
+	dc, hdr = dataConverterFromHeader(env.params[1].Header)
+	assert.Empty(t, dc)
+	assert.Len(t, hdr.GetFields(), 1)
+
+	// the converter propagated with the workflow header is not inherited
+	wp.header = env.params[0].Header
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.ExecuteActivity{Name: "SendEmail"}}))
+	assert.NotContains(t, env.params[2].Header.GetFields(), internal.DataConverterHeader)
+
+	// the raw converter accepts the binary payloads only, the activity is not scheduled
+	pls, err := converter.GetDefaultDataConverter().ToPayloads("json")
+	require.NoError(t, err)
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 4, Command: &internal.ExecuteActivity{Name: "Upload", DataConverter: internal.RawDataConverter}, Payloads: pls}))
+	assert.Len(t, env.params, 3)
+	require.Len(t, wp.mq.Messages(), 1)
+	assert.Equal(t, dataConverterErrType, wp.mq.Messages()[0].Failure.GetApplicationFailureInfo().GetType())
+}
@@ -0,0 +1,106 @@
+package aggregatedpool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	tActivity "go.temporal.io/sdk/activity"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+// RrActivitiesHeartbeatAgeMetricName is the age (seconds) of the oldest last heartbeat of the running activities
+// per activity type, only the activities with the heartbeat timeout are tracked
+const RrActivitiesHeartbeatAgeMetricName string = "rr_activities_heartbeat_age"
+
+// runningActivity is the activity executed by the worker
+type runningActivity struct {
+	ctx              context.Context
+	name             string
+	id               string
+	workflowID       string
+	heartbeatTimeout time.Duration
+	// unix nano of the start or the last heartbeat
+	lastHeartbeat atomic.Int64
+	// stale is set when the activity is reported, reset on the next heartbeat
+	stale atomic.Bool
+}
+
+func newRunningActivity(ctx context.Context, info tActivity.Info) *runningActivity {
+	ra := &runningActivity{
+		ctx:              ctx,
+		name:             info.ActivityType.Name,
+		id:               info.ActivityID,
+		workflowID:       info.WorkflowExecution.ID,
+		heartbeatTimeout: info.HeartbeatTimeout,
+	}
+
+	ra.lastHeartbeat.Store(time.Now().UnixNano())
+
+	return ra
+}
+
+// MarkHeartbeat updates the last heartbeat time of the running activity.
+func (a *Activity) MarkHeartbeat(taskToken []byte) {
+	c, ok := a.running.Load(bytesToStr(taskToken))
+	if !ok {
+		return
+	}
+
+	ra := c.(*runningActivity)
+	ra.lastHeartbeat.Store(time.Now().UnixNano())
+	ra.stale.Store(false)
+}
+
+// CheckHeartbeats reports the age of the oldest last heartbeat per activity type (mh might be nil) and warns about
+// the activities not heartbeating longer than threshold * heartbeat timeout. Returns the reported ages.
+// Should not be called concurrently.
+func (a *Activity) CheckHeartbeats(now time.Time, threshold float64, mh temporalClient.MetricsHandler) map[string]time.Duration {
+	ages := make(map[string]time.Duration)
+
+	a.running.Range(func(_, value any) bool {
+		ra := value.(*runningActivity)
+		// activity is not expected to heartbeat
+		if ra.heartbeatTimeout <= 0 {
+			return true
+		}
+
+		age := now.Sub(time.Unix(0, ra.lastHeartbeat.Load()))
+		if age > ages[ra.name] {
+			ages[ra.name] = age
+		}
+
+		if age > time.Duration(float64(ra.heartbeatTimeout)*threshold) && ra.stale.CompareAndSwap(false, true) {
+			a.log.Warn("activity is not heartbeating",
+				zap.String("activity_type", ra.name),
+				zap.String("activity_id", ra.id),
+				zap.String("workflow_id", ra.workflowID),
+				zap.Duration("last_heartbeat", age),
+				zap.Duration("heartbeat_timeout", ra.heartbeatTimeout),
+			)
+		}
+
+		return true
+	})
+
+	if mh != nil {
+		for name, age := range ages {
+			mh.WithTags(map[string]string{"activity_type": name}).Gauge(RrActivitiesHeartbeatAgeMetricName).Update(age.Seconds())
+		}
+
+		// no running activities of the type anymore
+		for name := range a.heartbeatTypes {
+			if _, ok := ages[name]; !ok {
+				mh.WithTags(map[string]string{"activity_type": name}).Gauge(RrActivitiesHeartbeatAgeMetricName).Update(0)
+			}
+		}
+	}
+
+	a.heartbeatTypes = make(map[string]struct{}, len(ages))
+	for name := range ages {
+		a.heartbeatTypes[name] = struct{}{}
+	}
+
+	return ages
+}
@@ -0,0 +1,48 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tActivity "go.temporal.io/sdk/activity"
+	"go.uber.org/zap"
+)
+
+func Test_CheckHeartbeats(t *testing.T) {
+	a := &Activity{log: zap.NewNop()}
+
+	now := time.Now()
+	run := func(token, name string, timeout, age time.Duration) *runningActivity {
+		info := tActivity.Info{TaskToken: []byte(token), HeartbeatTimeout: timeout}
+		info.ActivityType.Name = name
+
+		ra := newRunningActivity(context.Background(), info)
+		ra.lastHeartbeat.Store(now.Add(-age).UnixNano())
+		a.running.Store(token, ra)
+
+		return ra
+	}
+
+	stuck := run("1", "Upload", time.Minute, time.Second*40)
+	run("2", "Upload", time.Minute, time.Second*5)
+	run("3", "Notify", time.Second*10, time.Second)
+	// no heartbeat timeout, not tracked
+	run("4", "Report", 0, time.Hour)
+
+	ages := a.CheckHeartbeats(now, 0.5, nil)
+	require.Len(t, ages, 2)
+	assert.Equal(t, time.Second*40, ages["Upload"])
+	assert.Equal(t, time.Second, ages["Notify"])
+	assert.True(t, stuck.stale.Load())
+
+	// heartbeat resets the staleness
+	a.MarkHeartbeat([]byte("1"))
+	assert.False(t, stuck.stale.Load())
+
+	ctx, err := a.GetActivityContext([]byte("3"))
+	require.NoError(t, err)
+	assert.Equal(t, context.Background(), ctx)
+}
@@ -0,0 +1,57 @@
+package aggregatedpool
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	temporalClient "go.temporal.io/sdk/client"
+)
+
+const (
+	RrWorkflowsInFlightMetricName string = "rr_workflows_exec_in_flight"
+	RrWorkflowsWaitMetricName     string = "rr_workflows_exec_wait_latency"
+)
+
+// execLimiter limits the number of concurrent Exec calls to the workflow worker
+type execLimiter struct {
+	sem      chan struct{}
+	timeout  time.Duration
+	inFlight atomic.Int64
+}
+
+func newExecLimiter(limit int, timeout time.Duration) *execLimiter {
+	return &execLimiter{
+		sem:     make(chan struct{}, limit),
+		timeout: timeout,
+	}
+}
+
+// acquire waits for a free slot up to the configured timeout, returned function should be called to release the slot
+func (l *execLimiter) acquire(mh temporalClient.MetricsHandler) (func(), error) {
+	start := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	select {
+	case l.sem <- struct{}{}:
+	case <-ctx.Done():
+		return nil, errors.Errorf("workflow worker is busy, no free Exec slot after %s, in-flight: %d", l.timeout, l.inFlight.Load())
+	}
+
+	inFlight := l.inFlight.Add(1)
+	if mh != nil {
+		mh.Timer(RrWorkflowsWaitMetricName).Record(time.Since(start))
+		mh.Gauge(RrWorkflowsInFlightMetricName).Update(float64(inFlight))
+	}
+
+	return func() {
+		inFlight := l.inFlight.Add(-1)
+		<-l.sem
+		if mh != nil {
+			mh.Gauge(RrWorkflowsInFlightMetricName).Update(float64(inFlight))
+		}
+	}, nil
+}
@@ -0,0 +1,27 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ExecLimiterTimeout(t *testing.T) {
+	l := newExecLimiter(1, time.Millisecond*10)
+
+	release, err := l.acquire(nil)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), l.inFlight.Load())
+
+	_, err = l.acquire(nil)
+	require.Error(t, err)
+
+	release()
+	assert.Equal(t, int64(0), l.inFlight.Load())
+
+	release, err = l.acquire(nil)
+	require.NoError(t, err)
+	release()
+}
@@ -13,26 +13,82 @@ import (
 	"github.com/temporalio/roadrunner-temporal/v5/internal"
 	commonpb "go.temporal.io/api/common/v1"
 	tActivity "go.temporal.io/sdk/activity"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/temporal"
 	"go.uber.org/zap"
 )
 
+const (
+	// dataConverterErrType is the application error type of the payloads not matching the (local) activity data converter
+	dataConverterErrType string = "DataConverterMismatch"
+	// RrLocalActivitiesInFlightMetricName is the number of the local activities dispatched to the activity workers
+	RrLocalActivitiesInFlightMetricName string = "rr_local_activities_in_flight"
+)
+
 type LocalActivityFn struct {
 	codec api.Codec
 	pool  api.Pool
 	log   *zap.Logger
 	seqID uint64
+	// nil - no checks
+	limits *PayloadLimits
+	// nil - the concurrent executions are not limited
+	sem      chan struct{}
+	inFlight atomic.Int64
+	// maximum size of the streamed result, 0 - streaming is not supported
+	maxStreamedResult int
+}
+
+// NewLocalActivityFn creates the local activity function, maxConcurrent limits the number of the local activities
+// dispatched to the activity workers at once (0 - no limit), see WorkflowConfig.MaxConcurrentLocalActivities.
+// maxStreamedResult is the size limit of the result streamed by the worker (0 - streaming is not supported), see
+// WorkflowConfig.MaxStreamedLocalActivityResult.
+func NewLocalActivityFn(codec api.Codec, pool api.Pool, log *zap.Logger, limits *PayloadLimits, maxConcurrent int, maxStreamedResult int) *LocalActivityFn {
+	la := &LocalActivityFn{
+		codec:             codec,
+		pool:              pool,
+		log:               log,
+		limits:            limits,
+		maxStreamedResult: maxStreamedResult,
+	}
+
+	if maxConcurrent > 0 {
+		la.sem = make(chan struct{}, maxConcurrent)
+	}
+
+	return la
 }
 
-func NewLocalActivityFn(codec api.Codec, pool api.Pool, log *zap.Logger) *LocalActivityFn {
-	return &LocalActivityFn{
-		codec: codec,
-		pool:  pool,
-		log:   log,
+// acquire waits for the dispatch slot while the local activity context is alive, the returned function releases it
+func (la *LocalActivityFn) acquire(ctx context.Context, mh temporalClient.MetricsHandler) (func(), error) {
+	if la.sem != nil {
+		select {
+		case la.sem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, errors.Errorf("no free local activity slot, in-flight: %d: %v", la.inFlight.Load(), ctx.Err())
+		}
+	}
+
+	inFlight := la.inFlight.Add(1)
+	if mh != nil {
+		mh.Gauge(RrLocalActivitiesInFlightMetricName).Update(float64(inFlight))
 	}
+
+	return func() {
+		inFlight := la.inFlight.Add(-1)
+		if la.sem != nil {
+			<-la.sem
+		}
+
+		if mh != nil {
+			mh.Gauge(RrLocalActivitiesInFlightMetricName).Update(float64(inFlight))
+		}
+	}, nil
 }
 
-func (la *LocalActivityFn) ExecuteLA(ctx context.Context, hdr *commonpb.Header, args *commonpb.Payloads) (*commonpb.Payloads, error) {
+// ExecuteLA executes the local activity, dataConverter is the converter requested by the ExecuteLocalActivity command.
+func (la *LocalActivityFn) ExecuteLA(ctx context.Context, hdr *commonpb.Header, args *commonpb.Payloads, dataConverter string) (*commonpb.Payloads, error) {
 	const op = errors.Op("activity_pool_execute_activity")
 
 	var info = tActivity.GetInfo(ctx)
@@ -44,11 +100,19 @@ func (la *LocalActivityFn) ExecuteLA(ctx context.Context, hdr *commonpb.Header,
 		defer mh.Gauge(RrMetricName).Update(float64(la.pool.QueueSize()))
 	}
 
+	// the excess local activities wait here, the time counts towards the local activity timeouts
+	release, err := la.acquire(ctx, mh)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	defer release()
+
 	var msg = &internal.Message{
 		ID: atomic.AddUint64(&la.seqID, 1),
 		Command: internal.InvokeLocalActivity{
-			Name: info.ActivityType.Name,
-			Info: info,
+			Name:          info.ActivityType.Name,
+			Info:          info,
+			DataConverter: dataConverter,
 		},
 		Payloads: args,
 		Header:   hdr,
@@ -57,9 +121,16 @@ func (la *LocalActivityFn) ExecuteLA(ctx context.Context, hdr *commonpb.Header,
 	la.log.Debug("executing local activity fn", zap.Uint64("ID", msg.ID), zap.String("task-queue", info.TaskQueue), zap.String("la ID", info.ActivityID))
 
 	pl := getPld()
-	defer putPld(pl)
+	// after the exec timeout the pool might still write the frame, the buffer is reused only once the worker responded
+	responded := false
+	defer func() {
+		if responded {
+			la.codec.Release(pl)
+			putPld(pl)
+		}
+	}()
 
-	err := la.codec.Encode(
+	err = la.codec.Encode(
 		&internal.Context{
 			TaskQueue: info.TaskQueue,
 		}, pl, msg)
@@ -74,21 +145,31 @@ func (la *LocalActivityFn) ExecuteLA(ctx context.Context, hdr *commonpb.Header,
 	}
 
 	var r *payload.Payload
-	select {
-	case pld := <-result:
-		if pld.Error() != nil {
-			return nil, errors.E(op, pld.Error())
+	if la.maxStreamedResult > 0 {
+		// the worker might stream the result, the chunks are reassembled here
+		r, err = streamedResult(ctx, result, ch, la.maxStreamedResult)
+		if err != nil {
+			return nil, err
 		}
-		// streaming is not supported
-		if pld.Payload().Flags&frame.STREAM != 0 {
-			ch <- struct{}{}
-			return nil, errors.E(op, errors.Str("streaming is not supported"))
+		responded = true
+	} else {
+		select {
+		case pld := <-result:
+			responded = true
+			if pld.Error() != nil {
+				return nil, errors.E(op, pld.Error())
+			}
+			// streaming is not supported
+			if pld.Payload().Flags&frame.STREAM != 0 {
+				ch <- struct{}{}
+				return nil, errors.E(op, errors.Str("streaming is not supported, see the max_streamed_local_activity_result option"))
+			}
+
+			// assign the payload
+			r = pld.Payload()
+		default:
+			return nil, errors.E(op, errors.Str("worker empty response"))
 		}
-
-		// assign the payload
-		r = pld.Payload()
-	default:
-		return nil, errors.E(op, errors.Str("worker empty response"))
 	}
 
 	out := make([]*internal.Message, 0, 2)
@@ -110,9 +191,47 @@ func (la *LocalActivityFn) ExecuteLA(ctx context.Context, hdr *commonpb.Header,
 		return nil, temporal.GetDefaultFailureConverter().FailureToError(retPld.Failure)
 	}
 
+	err = checkLocalActivityPayloads(dataConverter, retPld.Payloads)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), dataConverterErrType, nil)
+	}
+
+	err = la.limits.check(la.log, "local activity", info.ActivityType.Name, retPld.Payloads)
+	if err != nil {
+		return nil, temporal.NewNonRetryableApplicationError(err.Error(), payloadSizeErrType, nil)
+	}
+
 	return retPld.Payloads, nil
 }
 
+// checkLocalActivityPayloads verifies that the payloads match the converter requested for the local activity
+func checkLocalActivityPayloads(dataConverter string, pls *commonpb.Payloads) error {
+	switch dataConverter {
+	case "":
+		return nil
+	case internal.RawDataConverter:
+		for i, pl := range pls.GetPayloads() {
+			if enc := string(pl.GetMetadata()[converter.MetadataEncoding]); enc != converter.MetadataEncodingBinary {
+				return errors.Errorf("payload %d has %q encoding, %q converter accepts %s only", i, enc, dataConverter, converter.MetadataEncodingBinary)
+			}
+		}
+
+		return nil
+	default:
+		return errors.Errorf("unknown local activity data converter: %s", dataConverter)
+	}
+}
+
+// checkActivityPayloads verifies that the payloads match the converter requested for the activity, unlike the local
+// activities the converters registered in the worker are passed as is
+func checkActivityPayloads(dataConverter string, pls *commonpb.Payloads) error {
+	if dataConverter != internal.RawDataConverter {
+		return nil
+	}
+
+	return checkLocalActivityPayloads(dataConverter, pls)
+}
+
 var pldP = sync.Pool{ //nolint:gochecknoglobals
 	New: func() any {
 		return &payload.Payload{}
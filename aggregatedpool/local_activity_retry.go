@@ -0,0 +1,103 @@
+package aggregatedpool
+
+import (
+	"hash/fnv"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// runLocalActivity executes the local activity attempt, the failed attempts are retried by the workflow after the
+// jittered backoff if the RetryJitter option is set
+func (wp *Workflow) runLocalActivity(id uint64, params bindings.ExecuteLocalActivityParams, jitter float64) {
+	activityID := wp.env.ExecuteLocalActivity(params, wp.createLocalActivityCallback(id, wp.localActivityRetry(id, params, jitter)))
+	wp.canceller.Register(id, func() error {
+		wp.log.Debug("registering local activity canceller", zap.String("activityID", activityID.String()))
+		wp.env.RequestCancelLocalActivity(activityID)
+		return nil
+	})
+}
+
+// localActivityRetry returns the retry of the failed local activity attempt, nil if the jitter is not set.
+//
+// The SDK retries the local activity in the worker while the backoff is short, the longer backoff is returned with
+// the failure (lar.Backoff) and the attempt is retried by the workflow using a timer. Without the jitter the failure
+// is returned to the worker instead.
+func (wp *Workflow) localActivityRetry(id uint64, params bindings.ExecuteLocalActivityParams, jitter float64) func(lar *bindings.LocalActivityResultWrapper) bool {
+	if jitter <= 0 {
+		return nil
+	}
+
+	return func(lar *bindings.LocalActivityResultWrapper) bool {
+		if lar.Backoff <= 0 || temporal.IsCanceledError(lar.Err) {
+			return false
+		}
+
+		var maxInterval time.Duration
+		if params.RetryPolicy != nil {
+			maxInterval = params.RetryPolicy.MaximumInterval
+		}
+
+		// reported by the callback
+		lar.Backoff = jitteredBackoff(lar.Backoff, jitter, maxInterval, wp.env.WorkflowInfo().WorkflowExecution.RunID, id, lar.Attempt)
+		wp.log.Debug("local activity retry scheduled", zap.Uint64("ID", id), zap.Int32("attempt", lar.Attempt), zap.Duration("backoff", lar.Backoff), zap.Error(lar.Err))
+
+		timerID := wp.env.NewTimer(lar.Backoff, workflow.TimerOptions{Summary: "local activity retry"}, func(_ *commonpb.Payloads, err error) {
+			cb := func() {
+				if err != nil {
+					// timer (and so the local activity) was canceled
+					wp.canceller.Discard(id)
+					wp.mq.PushError(id, temporal.GetDefaultFailureConverter().ErrorToFailure(err))
+					wp.resolveSelect(id, "")
+					return
+				}
+
+				next := params
+				next.Attempt = lar.Attempt + 1
+				wp.runLocalActivity(id, next, jitter)
+			}
+
+			if atomic.LoadUint32(&wp.inLoop) == 1 {
+				cb()
+				return
+			}
+
+			wp.callbacks = append(wp.callbacks, func() error {
+				cb()
+				return nil
+			})
+		})
+
+		wp.canceller.Register(id, func() error {
+			if timerID != nil {
+				wp.env.RequestCancelTimer(*timerID)
+			}
+			return nil
+		})
+
+		return true
+	}
+}
+
+// jitteredBackoff randomly shortens the backoff by up to the jitter part (0-1) and caps it by maxInterval (0 - no cap).
+// The random source is seeded with the run ID, the command ID and the attempt: the value is the same on replay and
+// differs between the attempts and the workflows.
+func jitteredBackoff(backoff time.Duration, jitter float64, maxInterval time.Duration, runID string, id uint64, attempt int32) time.Duration {
+	if maxInterval > 0 && backoff > maxInterval {
+		backoff = maxInterval
+	}
+
+	jitter = min(jitter, 1)
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(runID))
+	r := rand.New(rand.NewPCG(h.Sum64(), id<<32|uint64(uint32(attempt)))) //nolint:gosec
+
+	return backoff - time.Duration(float64(backoff)*jitter*r.Float64())
+}
@@ -0,0 +1,65 @@
+package aggregatedpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func Test_JitteredBackoff(t *testing.T) {
+	const backoff = time.Second * 10
+
+	seen := make(map[time.Duration]struct{})
+	for attempt := int32(1); attempt <= 20; attempt++ {
+		d := jitteredBackoff(backoff, 0.5, 0, "run-id", 5, attempt)
+		assert.GreaterOrEqual(t, d, backoff/2)
+		assert.LessOrEqual(t, d, backoff)
+		seen[d] = struct{}{}
+
+		// the same value on replay
+		assert.Equal(t, d, jitteredBackoff(backoff, 0.5, 0, "run-id", 5, attempt))
+	}
+
+	// successive attempts use varying backoffs
+	assert.Greater(t, len(seen), 10)
+
+	// capped by the maximum interval
+	d := jitteredBackoff(time.Minute, 0.2, time.Second*30, "run-id", 5, 1)
+	assert.GreaterOrEqual(t, d, time.Second*24)
+	assert.LessOrEqual(t, d, time.Second*30)
+}
+
+func Test_LocalActivityRetry(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop(), canceller: new(canceller.Canceller)}
+
+	params := bindings.ExecuteLocalActivityParams{}
+	params.RetryPolicy = &temporal.RetryPolicy{MaximumInterval: time.Second * 20}
+
+	// no jitter, the failure is returned to the worker
+	assert.Nil(t, wp.localActivityRetry(1, params, 0))
+
+	retry := wp.localActivityRetry(1, params, 0.3)
+	require.NotNil(t, retry)
+
+	lar := &bindings.LocalActivityResultWrapper{Err: errors.New("boom"), Attempt: 3, Backoff: time.Minute}
+	assert.True(t, retry(lar))
+
+	// the reported backoff is the jittered one, the timer uses it
+	require.Len(t, env.timers, 1)
+	assert.Equal(t, lar.Backoff, env.timers[0])
+	assert.GreaterOrEqual(t, lar.Backoff, time.Second*14)
+	assert.LessOrEqual(t, lar.Backoff, time.Second*20)
+
+	// the short backoffs are retried by the SDK, canceled attempts are not retried
+	assert.False(t, retry(&bindings.LocalActivityResultWrapper{Err: errors.New("boom"), Attempt: 1}))
+	assert.False(t, retry(&bindings.LocalActivityResultWrapper{Err: temporal.NewCanceledError(), Attempt: 1, Backoff: time.Minute}))
+	assert.Len(t, env.timers, 1)
+}
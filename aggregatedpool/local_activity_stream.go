@@ -0,0 +1,76 @@
+package aggregatedpool
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/goridge/v3/pkg/frame"
+	"github.com/roadrunner-server/pool/payload"
+	"go.temporal.io/sdk/temporal"
+)
+
+// streamedResultErrType is the application error type of the streamed local activity result over the size limit
+const streamedResultErrType string = "StreamedResultTooLarge"
+
+// streamedResult receives the local activity result which might be streamed by the worker: the chunks are sent with
+// the STREAM flag, the last one without it. The chunks bodies are concatenated while they fit into maxSize bytes,
+// the stream is stopped and the local activity fails with the non-retryable error on overflow.
+// The result is awaited while the local activity context is alive, the streamed chunks are pushed after Exec returns.
+func streamedResult[T execResult](ctx context.Context, result chan T, stopCh chan struct{}, maxSize int) (*payload.Payload, error) {
+	const op = errors.Op("local_activity_streamed_result")
+
+	var body []byte
+	for chunks := 0; ; chunks++ {
+		var pld *payload.Payload
+		select {
+		case r, ok := <-result:
+			if !ok {
+				if chunks == 0 {
+					return nil, errors.E(op, errors.Str("worker empty response"))
+				}
+
+				return nil, errors.E(op, errors.Errorf("stream closed after %d chunks without the last one", chunks))
+			}
+
+			if r.Error() != nil {
+				return nil, errors.E(op, r.Error())
+			}
+
+			pld = r.Payload()
+			if pld == nil {
+				return nil, errors.E(op, errors.Str("worker empty response"))
+			}
+		case <-ctx.Done():
+			stopStream(stopCh)
+			return nil, errors.E(op, errors.Errorf("result not received after %d chunks: %v", chunks, ctx.Err()))
+		}
+
+		// not streamed, returned as is
+		if chunks == 0 && pld.Flags&frame.STREAM == 0 {
+			return pld, nil
+		}
+
+		if len(body)+len(pld.Body) > maxSize {
+			stopStream(stopCh)
+			return nil, temporal.NewNonRetryableApplicationError(
+				fmt.Sprintf("streamed local activity result exceeds %d bytes (max_streamed_local_activity_result)", maxSize),
+				streamedResultErrType,
+				nil,
+			)
+		}
+
+		body = append(body, pld.Body...)
+		if pld.Flags&frame.STREAM == 0 {
+			return &payload.Payload{Context: pld.Context, Body: body, Codec: pld.Codec}, nil
+		}
+	}
+}
+
+// stopStream asks the pool to stop the stream, the stop channel is buffered (1), the second stop is not needed
+func stopStream(stopCh chan struct{}) {
+	select {
+	case stopCh <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,70 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/roadrunner-server/goridge/v3/pkg/frame"
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/temporal"
+)
+
+func streamChunks(chunks ...string) chan *testResult {
+	result := make(chan *testResult, len(chunks))
+	for i, c := range chunks {
+		pld := &payload.Payload{Context: []byte("ctx"), Body: []byte(c)}
+		if i < len(chunks)-1 {
+			pld.Flags = frame.STREAM
+		}
+
+		result <- &testResult{pld: pld}
+	}
+
+	return result
+}
+
+func Test_StreamedResult(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+
+	pld, err := streamedResult(context.Background(), streamChunks("rep", "ort", "-1"), stopCh, 8)
+	require.NoError(t, err)
+	assert.Equal(t, "report-1", string(pld.Body))
+	assert.Equal(t, "ctx", string(pld.Context))
+	assert.Empty(t, stopCh)
+
+	// the non-streamed result is not limited
+	pld, err = streamedResult(context.Background(), streamChunks("a large single frame"), stopCh, 8)
+	require.NoError(t, err)
+	assert.Equal(t, "a large single frame", string(pld.Body))
+}
+
+func Test_StreamedResultOverflow(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+
+	_, err := streamedResult(context.Background(), streamChunks("rep", "ort", "-12"), stopCh, 8)
+	require.Error(t, err)
+
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, streamedResultErrType, appErr.Type())
+	assert.True(t, appErr.NonRetryable())
+	// the stream is stopped
+	assert.Len(t, stopCh, 1)
+}
+
+func Test_StreamedResultInterrupted(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+
+	// the last chunk never arrives
+	result := make(chan *testResult, 1)
+	result <- &testResult{pld: &payload.Payload{Body: []byte("rep"), Flags: frame.STREAM}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := streamedResult(ctx, result, stopCh, 8)
+	require.Error(t, err)
+	assert.Len(t, stopCh, 1)
+}
@@ -0,0 +1,82 @@
+package aggregatedpool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+func Test_LocalActivityRawPayloads(t *testing.T) {
+	dc := converter.GetDefaultDataConverter()
+	blob := []byte{0x00, 0xff, 0x10, '"'}
+
+	// the worker sends the bytes as is, binary/plain
+	pls, err := dc.ToPayloads(blob)
+	require.NoError(t, err)
+	assert.Equal(t, blob, pls.GetPayloads()[0].GetData())
+	require.NoError(t, checkLocalActivityPayloads(internal.RawDataConverter, pls))
+
+	var out []byte
+	require.NoError(t, dc.FromPayloads(pls, &out))
+	assert.Equal(t, blob, out)
+
+	// JSON encoded payload is rejected
+	pls, err = dc.ToPayloads("foo")
+	require.NoError(t, err)
+	assert.Error(t, checkLocalActivityPayloads(internal.RawDataConverter, pls))
+	assert.NoError(t, checkLocalActivityPayloads("", pls))
+
+	assert.NoError(t, checkLocalActivityPayloads(internal.RawDataConverter, nil))
+	assert.Error(t, checkLocalActivityPayloads("foo", nil))
+}
+
+func Test_LocalActivityConcurrencyLimit(t *testing.T) {
+	la := NewLocalActivityFn(nil, nil, zap.NewNop(), nil, 2, 0)
+	mh := newRecordingHandler()
+
+	r1, err := la.acquire(context.Background(), mh)
+	require.NoError(t, err)
+	r2, err := la.acquire(context.Background(), mh)
+	require.NoError(t, err)
+	assert.Equal(t, float64(2), mh.gauges[RrLocalActivitiesInFlightMetricName+":"])
+
+	// the excess local activity waits for the slot
+	acquired := make(chan func(), 1)
+	go func() {
+		r, errA := la.acquire(context.Background(), nil)
+		assert.NoError(t, errA)
+		acquired <- r
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("the local activity should wait for the free slot")
+	case <-time.After(time.Millisecond * 100):
+	}
+
+	r1()
+	select {
+	case r3 := <-acquired:
+		r3()
+	case <-time.After(time.Second):
+		t.Fatal("the local activity should be dispatched after the release")
+	}
+
+	// the wait respects the local activity timeout
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	r4, err := la.acquire(ctx, nil)
+	require.NoError(t, err)
+	_, err = la.acquire(ctx, nil)
+	assert.Error(t, err)
+
+	r2()
+	r4()
+	assert.Zero(t, la.inFlight.Load())
+}
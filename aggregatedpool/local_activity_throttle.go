@@ -0,0 +1,76 @@
+package aggregatedpool
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// laThrottleTimer is the timer used to force a new workflow task, should be > 0, otherwise no timer is created
+const laThrottleTimer = time.Millisecond
+
+// scheduleLocalActivity executes the local activity or, if the per workflow task limit is reached,
+// postpones it to the next workflow task using a timer
+func (wp *Workflow) scheduleLocalActivity(msg *internal.Message, command *internal.ExecuteLocalActivity) {
+	limit := wp.cfg.MaxLocalActivitiesPerTask
+	if limit <= 0 || wp.laCount < limit {
+		wp.laCount++
+		wp.executeLocalActivity(msg, command)
+		return
+	}
+
+	wp.log.Info("local activities limit per workflow task reached, postponing to the next workflow task",
+		zap.Uint64("ID", msg.ID),
+		zap.String("name", command.Name),
+		zap.Int("limit", limit),
+		zap.String("RunID", wp.env.WorkflowInfo().WorkflowExecution.RunID),
+	)
+
+	timerID := wp.env.NewTimer(laThrottleTimer, workflow.TimerOptions{Summary: "local activities throttling"}, func(_ *commonpb.Payloads, err error) {
+		cb := func() {
+			if err != nil {
+				// timer (and so the local activity) was canceled
+				wp.canceller.Discard(msg.ID)
+				wp.mq.PushError(msg.ID, temporal.GetDefaultFailureConverter().ErrorToFailure(err))
+				wp.resolveSelect(msg.ID, "")
+				return
+			}
+
+			wp.scheduleLocalActivity(msg, command)
+		}
+
+		if atomic.LoadUint32(&wp.inLoop) == 1 {
+			cb()
+			return
+		}
+
+		wp.callbacks = append(wp.callbacks, func() error {
+			cb()
+			return nil
+		})
+	})
+
+	wp.canceller.Register(msg.ID, func() error {
+		if timerID != nil {
+			wp.env.RequestCancelTimer(*timerID)
+		}
+		return nil
+	})
+}
+
+func (wp *Workflow) executeLocalActivity(msg *internal.Message, command *internal.ExecuteLocalActivity) {
+	params := command.LocalActivityParams(wp.env, wp.la, msg.Payloads, wp.commandHeader(msg.Header))
+	// the local activity is sent to the task queue the workflow is registered on in the PHP worker
+	if taskQueue := wp.taskQueue(); taskQueue != params.WorkflowInfo.TaskQueueName {
+		info := *params.WorkflowInfo
+		info.TaskQueueName = taskQueue
+		params.WorkflowInfo = &info
+	}
+
+	wp.runLocalActivity(msg.ID, params, command.Options.RetryJitter)
+}
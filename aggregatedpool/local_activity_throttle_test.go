@@ -0,0 +1,41 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func Test_LocalActivityThrottleCancelSelect(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{
+		env:       env,
+		log:       zap.NewNop(),
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+		cfg:       &WorkflowConfig{MaxLocalActivitiesPerTask: 1},
+		laCount:   1,
+		inLoop:    1,
+	}
+
+	// over the limit, postponed with the timer
+	wp.scheduleLocalActivity(&internal.Message{ID: 5}, &internal.ExecuteLocalActivity{Name: "charge"})
+	require.Len(t, env.timerCallbacks, 1)
+	wp.registerSelect(10, &internal.Select{CommandIDs: []uint64{5}})
+
+	// the cancelled local activity resolves the selector, the canceled timer reports the cancellation
+	require.NoError(t, wp.canceller.Cancel(5))
+	require.Len(t, env.canceledTimers, 1)
+	env.timerCallbacks[0](nil, temporal.NewCanceledError())
+	require.Len(t, wp.mq.Messages(), 2)
+	assert.Equal(t, uint64(5), wp.mq.Messages()[0].ID)
+	assert.NotNil(t, wp.mq.Messages()[0].Failure)
+	assert.Equal(t, internal.SelectResult{ID: 5}, selectResult(t, wp.mq.Messages()[1]))
+	assert.Empty(t, wp.selectors)
+}
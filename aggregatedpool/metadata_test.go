@@ -0,0 +1,42 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	sdkpb "go.temporal.io/api/sdk/v1"
+	"go.uber.org/zap"
+)
+
+func Test_WorkflowMetadataQuery(t *testing.T) {
+	env := &fakeEnv{info: orderWorkflow()}
+	wp := &Workflow{
+		env: env,
+		log: zap.NewNop(),
+		workflows: map[string]*internal.WorkflowInfo{
+			"OrderWorkflow": {Name: "OrderWorkflow", Queries: []string{"status"}, Signals: []string{"approve", "cancel"}},
+		},
+	}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.SetCurrentDetails{Details: "waiting for the approval"}}))
+
+	// answered by RR, the worker is not involved
+	res, err := wp.handleQuery(workflowMetadataQuery, nil, nil)
+	require.NoError(t, err)
+
+	var md sdkpb.WorkflowMetadata
+	require.NoError(t, env.GetDataConverter().FromPayloads(res, &md))
+	assert.Equal(t, "waiting for the approval", md.GetCurrentDetails())
+	assert.Equal(t, "OrderWorkflow", md.GetDefinition().GetType())
+	require.Len(t, md.GetDefinition().GetQueryDefinitions(), 1)
+	assert.Equal(t, "status", md.GetDefinition().GetQueryDefinitions()[0].GetName())
+	require.Len(t, md.GetDefinition().GetSignalDefinitions(), 2)
+	assert.Equal(t, "approve", md.GetDefinition().GetSignalDefinitions()[0].GetName())
+	assert.Equal(t, "cancel", md.GetDefinition().GetSignalDefinitions()[1].GetName())
+
+	// the unknown workflow type has no interactions
+	wp.workflows = nil
+	assert.Empty(t, wp.workflowMetadata().GetDefinition().GetSignalDefinitions())
+}
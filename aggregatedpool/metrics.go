@@ -0,0 +1,71 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	bindings "go.temporal.io/sdk/internalbindings"
+)
+
+const (
+	// RrActivityExecutionsMetricName counts the completed activities of the workflows by the activity type
+	RrActivityExecutionsMetricName string = "rr_activity_executions"
+	// RrActivityFailuresMetricName counts the failed (including canceled and timed out) activities by the activity type
+	RrActivityFailuresMetricName string = "rr_activity_failures"
+	// RrActivityLatencyMetricName is the time from the activity scheduling to its result by the activity type
+	RrActivityLatencyMetricName string = "rr_activity_latency"
+)
+
+// emitMetric emits the worker metric using the workflow metrics handler, metrics are not emitted during replay
+func (wp *Workflow) emitMetric(m *internal.EmitMetric) error {
+	if m.Name == "" {
+		return errors.Str("metric name should not be empty")
+	}
+
+	if wp.env.IsReplaying() || wp.mh == nil {
+		return nil
+	}
+
+	mh := wp.mh
+	if len(m.Tags) > 0 {
+		mh = mh.WithTags(m.Tags)
+	}
+
+	switch m.Type {
+	case internal.CounterMetric:
+		mh.Counter(m.Name).Inc(int64(m.Value))
+	case internal.GaugeMetric:
+		mh.Gauge(m.Name).Update(m.Value)
+	case internal.TimerMetric:
+		mh.Timer(m.Name).Record(time.Duration(m.Value * float64(time.Millisecond)))
+	default:
+		return errors.Errorf("unknown metric type: %s, supported: counter, gauge, timer", m.Type)
+	}
+
+	return nil
+}
+
+// activityMetricsCallback records the activity type metrics when the activity result is delivered to the workflow.
+// The latency is measured in the workflow time (the same on replay), the replayed results are not recorded.
+func (wp *Workflow) activityMetricsCallback(activityType string, callback bindings.ResultHandler) bindings.ResultHandler {
+	if wp.mh == nil {
+		return callback
+	}
+
+	scheduled := wp.env.Now()
+
+	return func(result *commonpb.Payloads, err error) {
+		if !wp.env.IsReplaying() {
+			mh := wp.mh.WithTags(map[string]string{"activity_type": activityType})
+			mh.Counter(RrActivityExecutionsMetricName).Inc(1)
+			if err != nil {
+				mh.Counter(RrActivityFailuresMetricName).Inc(1)
+			}
+			mh.Timer(RrActivityLatencyMetricName).Record(wp.env.Now().Sub(scheduled))
+		}
+
+		callback(result, err)
+	}
+}
@@ -0,0 +1,101 @@
+package aggregatedpool
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	commonpb "go.temporal.io/api/common/v1"
+	temporalClient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+// recordingHandler records the counters, gauges and timers by the activity (workflow) type or update name tag
+type recordingHandler struct {
+	temporalClient.MetricsHandler
+	tags     map[string]string
+	counters map[string]int64
+	gauges   map[string]float64
+	timers   map[string]time.Duration
+}
+
+func newRecordingHandler() *recordingHandler {
+	return &recordingHandler{
+		MetricsHandler: temporalClient.MetricsNopHandler,
+		counters:       map[string]int64{},
+		gauges:         map[string]float64{},
+		timers:         map[string]time.Duration{},
+	}
+}
+
+func (h *recordingHandler) key(name string) string {
+	if wt, ok := h.tags["workflow_type"]; ok {
+		return name + ":" + wt
+	}
+	if un, ok := h.tags["update_name"]; ok {
+		return name + ":" + un
+	}
+	return name + ":" + h.tags["activity_type"]
+}
+
+func (h *recordingHandler) WithTags(tags map[string]string) temporalClient.MetricsHandler {
+	return &recordingHandler{MetricsHandler: h.MetricsHandler, tags: tags, counters: h.counters, gauges: h.gauges, timers: h.timers}
+}
+
+func (h *recordingHandler) Gauge(name string) temporalClient.MetricsGauge {
+	return gaugeFunc(func(v float64) { h.gauges[h.key(name)] = v })
+}
+
+func (h *recordingHandler) Counter(name string) temporalClient.MetricsCounter {
+	return counterFunc(func(v int64) { h.counters[h.key(name)] += v })
+}
+
+func (h *recordingHandler) Timer(name string) temporalClient.MetricsTimer {
+	return timerFunc(func(d time.Duration) { h.timers[h.key(name)] = d })
+}
+
+type counterFunc func(int64)
+
+func (f counterFunc) Inc(v int64) { f(v) }
+
+type gaugeFunc func(float64)
+
+func (f gaugeFunc) Update(v float64) { f(v) }
+
+type timerFunc func(time.Duration)
+
+func (f timerFunc) Record(d time.Duration) { f(d) }
+
+func Test_ActivityMetrics(t *testing.T) {
+	start := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	env := &fakeEnv{now: start}
+	mh := newRecordingHandler()
+	wp := &Workflow{env: env, log: zap.NewNop(), mh: mh}
+
+	delivered := 0
+	callback := func(*commonpb.Payloads, error) { delivered++ }
+
+	ok := wp.activityMetricsCallback("Charge", callback)
+	failed := wp.activityMetricsCallback("Charge", callback)
+	replayed := wp.activityMetricsCallback("Refund", callback)
+
+	env.now = start.Add(time.Second * 3)
+	ok(nil, nil)
+	failed(nil, errors.New("declined"))
+
+	env.replaying = true
+	replayed(nil, nil)
+
+	// the results are always delivered, the replayed ones are not recorded
+	assert.Equal(t, 3, delivered)
+	assert.Equal(t, int64(2), mh.counters[RrActivityExecutionsMetricName+":Charge"])
+	assert.Equal(t, int64(1), mh.counters[RrActivityFailuresMetricName+":Charge"])
+	assert.Equal(t, time.Second*3, mh.timers[RrActivityLatencyMetricName+":Charge"])
+	assert.NotContains(t, mh.counters, RrActivityExecutionsMetricName+":Refund")
+
+	// no metrics handler, the callback is used as is
+	wp.mh = nil
+	wp.activityMetricsCallback("Charge", callback)(nil, nil)
+	assert.Equal(t, 4, delivered)
+}
@@ -0,0 +1,56 @@
+package aggregatedpool
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/api"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+)
+
+// SetCommandMiddleware sets the middleware wrapping the commands of the workflows of the definition, ordered by name.
+func (wp *Workflow) SetCommandMiddleware(mw []api.CommandMiddleware) {
+	mw = slices.Clone(mw)
+	slices.SortFunc(mw, func(a, b api.CommandMiddleware) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	wp.middleware = mw
+}
+
+// handleCommand handles the command with the middleware chain: Before in order, the command, After in the reverse order.
+func (wp *Workflow) handleCommand(msg *internal.Message) error {
+	if len(wp.middleware) == 0 {
+		return wp.handleMessage(msg)
+	}
+
+	const op = errors.Op("handle_command")
+
+	name, err := internal.CommandName(msg.Command)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	info := wp.env.WorkflowInfo()
+	cmd := &api.Command{ID: msg.ID, Name: name, Payloads: msg.Payloads, Header: msg.Header}
+
+	i := 0
+	for ; i < len(wp.middleware); i++ {
+		err = wp.middleware[i].Before(info, cmd)
+		if err != nil {
+			err = errors.E(op, errors.Errorf("command '%s' rejected by the '%s' middleware: %v", name, wp.middleware[i].Name(), err))
+			break
+		}
+	}
+
+	if err == nil {
+		err = wp.handleMessage(msg)
+	}
+
+	for i--; i >= 0; i-- {
+		wp.middleware[i].After(info, cmd, err)
+	}
+
+	return err
+}
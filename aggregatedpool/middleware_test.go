@@ -0,0 +1,94 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/api"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+// recordingMiddleware records the calls as "name: stage command", rejects the commands in reject
+type recordingMiddleware struct {
+	name   string
+	calls  *[]string
+	reject map[string]bool
+	seen   []*api.Command
+}
+
+func (m *recordingMiddleware) Before(info *workflow.Info, cmd *api.Command) error {
+	*m.calls = append(*m.calls, m.name+": before "+info.WorkflowType.Name+" "+cmd.Name)
+	m.seen = append(m.seen, cmd)
+	if m.reject[cmd.Name] {
+		return errors.Str("not allowed")
+	}
+
+	return nil
+}
+
+func (m *recordingMiddleware) After(_ *workflow.Info, cmd *api.Command, err error) {
+	stage := "after"
+	if err != nil {
+		stage = "failed"
+	}
+
+	*m.calls = append(*m.calls, m.name+": "+stage+" "+cmd.Name)
+}
+
+func (m *recordingMiddleware) Name() string {
+	return m.name
+}
+
+func Test_CommandMiddleware(t *testing.T) {
+	env := &fakeEnv{
+		info:   &workflow.Info{WorkflowType: bindings.WorkflowType{Name: "Billing"}},
+		logger: &recordingLogger{},
+	}
+	wp := &Workflow{env: env, log: zap.NewNop()}
+
+	var calls []string
+	timing := &recordingMiddleware{name: "timing", calls: &calls}
+	wp.SetCommandMiddleware([]api.CommandMiddleware{
+		timing,
+		&recordingMiddleware{name: "auth", calls: &calls, reject: map[string]bool{"UpsertMemo": true}},
+	})
+
+	// ordered by name, After in the reverse order
+	hdr := &commonpb.Header{Fields: map[string]*commonpb.Payload{"tenant": {Data: []byte("acme")}}}
+	require.NoError(t, wp.handleCommand(&internal.Message{ID: 1, Command: &internal.Log{Message: "started"}, Header: hdr}))
+	assert.Equal(t, []string{
+		"auth: before Billing Log",
+		"timing: before Billing Log",
+		"timing: after Log",
+		"auth: after Log",
+	}, calls)
+	assert.Len(t, env.logger.entries, 1)
+	// the command as seen by the middleware
+	require.Len(t, timing.seen, 1)
+	assert.Equal(t, uint64(1), timing.seen[0].ID)
+	assert.Equal(t, "Log", timing.seen[0].Name)
+	assert.Same(t, hdr, timing.seen[0].Header)
+
+	// rejected by the first middleware, neither the command nor the next middleware are called
+	calls = nil
+	err := wp.handleCommand(&internal.Message{ID: 2, Command: &internal.UpsertMemo{}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "'auth' middleware: not allowed")
+	assert.Equal(t, []string{"auth: before Billing UpsertMemo"}, calls)
+
+	// the handling error is passed to After
+	calls = nil
+	require.Error(t, wp.handleCommand(&internal.Message{ID: 3, Command: &internal.ReportUpdateProgress{}}))
+	assert.Equal(t, []string{
+		"auth: before Billing ReportUpdateProgress",
+		"timing: before Billing ReportUpdateProgress",
+		"timing: failed ReportUpdateProgress",
+		"auth: failed ReportUpdateProgress",
+	}, calls)
+}
@@ -0,0 +1,110 @@
+package aggregatedpool
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.uber.org/zap"
+)
+
+const (
+	// SDK log tags, sync with the sdk-go/internal/internal_logging_tags.go
+	sdkRunIDTag string = "RunID"
+	sdkErrorTag string = "Error"
+	// non-determinism error code of the SDK errors
+	nonDeterminismErrCode string = "TMPRL1100"
+)
+
+// RunRegistry tracks the workflows running in the workflow worker by the run ID, shared by the workflow definitions
+// (the pools might be replaced) to find the workflow of the SDK log entries.
+type RunRegistry struct {
+	runs sync.Map
+}
+
+func NewRunRegistry() *RunRegistry {
+	return &RunRegistry{}
+}
+
+// SetRunRegistry sets the registry the workflows of the definition are tracked in.
+func (wp *Workflow) SetRunRegistry(r *RunRegistry) {
+	wp.runs = r
+}
+
+func (r *RunRegistry) add(runID string, wp *Workflow) {
+	if r == nil {
+		return
+	}
+
+	r.runs.Store(runID, wp)
+}
+
+func (r *RunRegistry) remove(runID string) {
+	if r == nil {
+		return
+	}
+
+	r.runs.Delete(runID)
+}
+
+// ReportNonDeterminism is the SDK logger hook: the non-determinism error logged by the SDK for the workflow task is
+// recorded for the workflow and reported to the worker when the SDK drops the workflow state after the failed task
+// (see reportNonDeterminism). The hook doesn't block the logger and doesn't touch the workflow state, only the first
+// error of the run is kept.
+func (r *RunRegistry) ReportNonDeterminism(_ string, keyvals []any) {
+	var runID, nde string
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		switch keyvals[i] {
+		case sdkRunIDTag:
+			runID = fmt.Sprint(keyvals[i+1])
+		case sdkErrorTag:
+			if keyvals[i+1] != nil {
+				nde = fmt.Sprint(keyvals[i+1])
+			}
+		}
+	}
+
+	if runID == "" || !isNonDeterminismError(nde) {
+		return
+	}
+
+	v, ok := r.runs.Load(runID)
+	if !ok {
+		return
+	}
+
+	// the same error might be logged several times during the task
+	v.(*Workflow).nde.CompareAndSwap(nil, &nde)
+}
+
+// reportNonDeterminism sends the recorded non-determinism error to the worker with the NonDeterminismDetected
+// command, called on the workflow goroutine when the workflow is closed. The worker response (or error) is ignored.
+func (wp *Workflow) reportNonDeterminism() {
+	nde := wp.nde.Swap(nil)
+	if nde == nil {
+		return
+	}
+
+	info := wp.env.WorkflowInfo()
+	wp.log.Error("non-deterministic workflow replay",
+		zap.String("workflow_type", info.WorkflowType.Name),
+		zap.String("workflow_id", info.WorkflowExecution.ID),
+		zap.String("run_id", info.WorkflowExecution.RunID),
+		zap.String("error", *nde),
+	)
+
+	_, err := wp.runCommand(internal.NonDeterminismDetected{
+		RunID:         info.WorkflowExecution.RunID,
+		WorkflowType:  info.WorkflowType.Name,
+		Error:         *nde,
+		HistoryLength: info.GetCurrentHistoryLength(),
+	}, nil, wp.header)
+	if err != nil {
+		wp.log.Debug("failed to report the non-deterministic replay to the worker", zap.String("run_id", info.WorkflowExecution.RunID), zap.Error(err))
+	}
+}
+
+func isNonDeterminismError(msg string) bool {
+	return strings.Contains(msg, nonDeterminismErrCode) || strings.Contains(strings.ToLower(msg), "nondeterministic")
+}
@@ -0,0 +1,96 @@
+package aggregatedpool
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/api"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.uber.org/zap"
+
+	staticPool "github.com/roadrunner-server/pool/pool/static_pool"
+)
+
+// recordingCodec records the messages sent to the worker
+type recordingCodec struct {
+	api.Codec
+	sent     []*internal.Message
+	released int
+}
+
+func (c *recordingCodec) Encode(_ *internal.Context, _ *payload.Payload, msg ...*internal.Message) error {
+	c.sent = append(c.sent, msg...)
+	return nil
+}
+
+func (c *recordingCodec) Release(*payload.Payload) {
+	c.released++
+}
+
+// stoppedPool fails every execution
+type stoppedPool struct {
+	api.Pool
+}
+
+func (p *stoppedPool) Exec(context.Context, *payload.Payload, chan struct{}) (chan *staticPool.PExec, error) {
+	return nil, errors.Str("worker stopped")
+}
+
+func Test_ReportNonDeterminism(t *testing.T) {
+	codec := &recordingCodec{}
+	runs := NewRunRegistry()
+	wp := &Workflow{
+		env:     &fakeEnv{info: orderWorkflow()},
+		log:     zap.NewNop(),
+		mq:      queue.NewMessageQueue(seq),
+		codec:   codec,
+		pool:    &stoppedPool{},
+		pldPool: &sync.Pool{New: func() any { return new(payload.Payload) }},
+		runs:    runs,
+	}
+	runs.add("run-1", wp)
+
+	nde := errors.Str("[TMPRL1100] During replay, a matching ScheduleActivityTask command was expected in history event position 5")
+	// unknown run and the other errors are not recorded
+	runs.ReportNonDeterminism("Failed to process workflow task.", []any{"RunID", "run-2", "Error", nde})
+	runs.ReportNonDeterminism("Failed to process workflow task.", []any{"RunID", "run-1", "Error", errors.Str("boom")})
+	assert.Nil(t, wp.nde.Load())
+
+	// the hook only records the first error, nothing is sent to the worker
+	runs.ReportNonDeterminism("Failed to process workflow task.", []any{"WorkflowType", "OrderWorkflow", "RunID", "run-1", "Error", nde})
+	runs.ReportNonDeterminism("Failed to process workflow task.", []any{"RunID", "run-1", "Error", errors.Str("[TMPRL1100] other")})
+	require.NotNil(t, wp.nde.Load())
+	assert.Equal(t, nde.Error(), *wp.nde.Load())
+	assert.Empty(t, codec.sent)
+
+	// reported before the destroy when the SDK closes the workflow, the worker error doesn't panic
+	wp.Close()
+	require.Len(t, codec.sent, 2)
+
+	cmd, ok := codec.sent[0].Command.(internal.NonDeterminismDetected)
+	require.True(t, ok)
+	assert.Equal(t, "run-1", cmd.RunID)
+	assert.Equal(t, "OrderWorkflow", cmd.WorkflowType)
+	assert.Equal(t, nde.Error(), cmd.Error)
+	assert.IsType(t, internal.DestroyWorkflow{}, codec.sent[1].Command)
+	assert.Nil(t, wp.nde.Load())
+
+	// the closed workflow is not tracked
+	_, ok = runs.runs.Load("run-1")
+	assert.False(t, ok)
+
+	// the workflow closed without the error sends only the destroy
+	codec.sent = nil
+	wp.Close()
+	require.Len(t, codec.sent, 1)
+	assert.IsType(t, internal.DestroyWorkflow{}, codec.sent[0].Command)
+
+	assert.True(t, isNonDeterminismError("nondeterministic workflow definition code"))
+	assert.False(t, isNonDeterminismError(""))
+}
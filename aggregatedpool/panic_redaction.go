@@ -0,0 +1,94 @@
+package aggregatedpool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+
+	"github.com/roadrunner-server/errors"
+	"go.temporal.io/api/failure/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// PanicRedactionReplace replaces the matches with the redactedValue
+	PanicRedactionReplace string = "replace"
+	// PanicRedactionHash replaces the matches with their sha256 prefix, the same values could be correlated
+	PanicRedactionHash string = "hash"
+
+	redactedValue string = "[REDACTED]"
+)
+
+// PanicRedaction configures the redaction of the panic failures before they are recorded in the workflow history.
+type PanicRedaction struct {
+	// Patterns are the regular expressions of the redacted parts of the failure messages and stack traces.
+	Patterns []string `mapstructure:"patterns"`
+	// Mode is replace (default) or hash.
+	Mode string `mapstructure:"mode"`
+
+	// compiled patterns
+	re []*regexp.Regexp
+}
+
+func (r *PanicRedaction) InitDefaults() {
+	if r.Mode == "" {
+		r.Mode = PanicRedactionReplace
+	}
+}
+
+// compile checks the mode and compiles the patterns
+func (r *PanicRedaction) compile() error {
+	switch r.Mode {
+	case "", PanicRedactionReplace, PanicRedactionHash:
+	default:
+		return errors.Errorf("panic_redaction.mode should be one of: replace, hash, got: %s", r.Mode)
+	}
+
+	r.re = make([]*regexp.Regexp, 0, len(r.Patterns))
+	for _, p := range r.Patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return errors.Errorf("panic_redaction: invalid pattern %q: %v", p, err)
+		}
+
+		r.re = append(r.re, re)
+	}
+
+	return nil
+}
+
+// redact returns the copy of the failure with the message and the stack trace redacted, the causes are redacted
+// as well. The failure info (e.g. the application error type and non-retryable flag) is kept intact.
+func (r *PanicRedaction) redact(f *failure.Failure) *failure.Failure {
+	if r == nil || len(r.re) == 0 || f == nil {
+		return f
+	}
+
+	res := proto.Clone(f).(*failure.Failure)
+	for cur := res; cur != nil; cur = cur.GetCause() {
+		cur.Message = r.redactString(cur.GetMessage())
+		cur.StackTrace = r.redactString(cur.GetStackTrace())
+	}
+
+	return res
+}
+
+func (r *PanicRedaction) redactString(s string) string {
+	if r == nil || s == "" {
+		return s
+	}
+
+	for _, re := range r.re {
+		if r.Mode == PanicRedactionHash {
+			s = re.ReplaceAllStringFunc(s, func(m string) string {
+				sum := sha256.Sum256([]byte(m))
+				return "[sha256:" + hex.EncodeToString(sum[:6]) + "]"
+			})
+			continue
+		}
+
+		s = re.ReplaceAllLiteralString(s, redactedValue)
+	}
+
+	return s
+}
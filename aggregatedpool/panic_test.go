@@ -0,0 +1,97 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func panicMessage(policy internal.PanicPolicy) *internal.Message {
+	return &internal.Message{
+		ID:      1,
+		Command: &internal.Panic{Message: "boom", Policy: policy},
+		Failure: temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewApplicationError("boom", "PanicError")),
+	}
+}
+
+func Test_PanicFailsTask(t *testing.T) {
+	for _, policy := range []internal.PanicPolicy{"", internal.PanicFailTask} {
+		env := &fakeEnv{}
+		wp := &Workflow{env: env, log: zap.NewNop()}
+
+		// the error fails the workflow task, the execution is not completed
+		err := wp.handleMessage(panicMessage(policy))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "boom")
+		assert.False(t, env.completed)
+	}
+}
+
+func Test_PanicFailsWorkflow(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop()}
+
+	require.NoError(t, wp.handleMessage(panicMessage(internal.PanicFailWorkflow)))
+	assert.True(t, env.completed)
+
+	// the worker panic failure is converted to the SDK panic error, recorded as the PanicError application failure
+	var panicErr *temporal.PanicError
+	require.ErrorAs(t, env.err, &panicErr)
+	recorded := temporal.GetDefaultFailureConverter().ErrorToFailure(env.err)
+	assert.Equal(t, "PanicError", recorded.GetApplicationFailureInfo().GetType())
+	assert.Equal(t, "boom", recorded.GetMessage())
+
+	// failure is optional, the message is used instead
+	env = &fakeEnv{}
+	wp = &Workflow{env: env, log: zap.NewNop()}
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.Panic{Message: "boom", Policy: internal.PanicFailWorkflow}}))
+	assert.EqualError(t, env.err, "boom")
+}
+
+func Test_PanicUnknownPolicy(t *testing.T) {
+	wp := &Workflow{env: &fakeEnv{}, log: zap.NewNop()}
+	msg := panicMessage("foo")
+	msg.Failure = &failure.Failure{Message: "boom"}
+
+	assert.Error(t, wp.handleMessage(msg))
+}
+
+func Test_PanicRedaction(t *testing.T) {
+	cfg := &WorkflowConfig{PanicRedaction: &PanicRedaction{Patterns: []string{`/var/www/[^\s:]+`, `password=\S+`}}}
+	cfg.InitDefaults()
+	require.NoError(t, cfg.Validate())
+
+	f := temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewNonRetryableApplicationError("connect failed: password=secret", "DbError", nil))
+	f.StackTrace = "#0 /var/www/app/src/Db.php:42"
+	f.Cause = &failure.Failure{Message: "open /var/www/app/.env: denied"}
+
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop(), cfg: cfg}
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.Panic{Policy: internal.PanicFailWorkflow}, Failure: f}))
+
+	// the type and the retry flag are kept
+	var appErr *temporal.ApplicationError
+	require.ErrorAs(t, env.err, &appErr)
+	assert.Equal(t, "DbError", appErr.Type())
+	assert.True(t, appErr.NonRetryable())
+
+	redacted := temporal.GetDefaultFailureConverter().ErrorToFailure(env.err)
+	assert.Equal(t, "connect failed: [REDACTED]", redacted.GetMessage())
+	assert.Equal(t, "#0 [REDACTED]:42", redacted.GetStackTrace())
+	assert.Equal(t, "open [REDACTED]: denied", redacted.GetCause().GetMessage())
+	// the command failure is not modified
+	assert.Equal(t, "#0 /var/www/app/src/Db.php:42", f.GetStackTrace())
+
+	// hashed matches are stable
+	cfg.PanicRedaction.Mode = PanicRedactionHash
+	assert.Equal(t, cfg.PanicRedaction.redactString("password=secret"), cfg.PanicRedaction.redactString("password=secret"))
+	assert.NotContains(t, cfg.PanicRedaction.redactString("password=secret"), "secret")
+
+	assert.Error(t, (&WorkflowConfig{PanicRedaction: &PanicRedaction{Patterns: []string{"("}}}).Validate())
+	assert.Error(t, (&WorkflowConfig{PanicRedaction: &PanicRedaction{Mode: "drop"}}).Validate())
+}
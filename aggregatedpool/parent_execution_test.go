@@ -0,0 +1,68 @@
+package aggregatedpool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+func Test_ChildSignalsParent(t *testing.T) {
+	env := &fakeEnv{info: &workflow.Info{
+		ParentWorkflowNamespace: "billing",
+		ParentWorkflowExecution: &bindings.WorkflowExecution{ID: "order-1", RunID: "run-1"},
+	}}
+	codec := &recordingCodec{}
+	wp := &Workflow{
+		env:       env,
+		log:       zap.NewNop(),
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+		codec:     codec,
+		pool:      &stoppedPool{},
+		pldPool:   &sync.Pool{New: func() any { return new(payload.Payload) }},
+	}
+
+	// the response is flushed to the worker right away, the stopped worker fails the exchange
+	err := wp.handleMessage(&internal.Message{ID: 1, Command: &internal.GetParentWorkflowExecution{}})
+	assert.Error(t, err)
+	require.Len(t, codec.sent, 1)
+	assert.Equal(t, uint64(1), codec.sent[0].ID)
+
+	// as received by the worker
+	var parent *internal.ParentWorkflowExecution
+	require.NoError(t, env.GetDataConverter().FromPayloads(codec.sent[0].Payloads, &parent))
+	require.NotNil(t, parent)
+	assert.Equal(t, internal.ParentWorkflowExecution{Namespace: "billing", WorkflowID: "order-1", RunID: "run-1"}, *parent)
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.SignalExternalWorkflow{
+		Namespace:  parent.Namespace,
+		WorkflowID: parent.WorkflowID,
+		RunID:      parent.RunID,
+		Signal:     "childCompleted",
+	}}))
+	assert.Equal(t, []string{"childCompleted"}, env.signals)
+	assert.Equal(t, []string{"billing/order-1/run-1"}, env.signalTargets)
+}
+
+func Test_RootWorkflowHasNoParent(t *testing.T) {
+	env := &fakeEnv{info: &workflow.Info{}}
+	wp := &Workflow{env: env, log: zap.NewNop()}
+
+	assert.Nil(t, wp.parentWorkflowExecution())
+
+	pls, err := env.GetDataConverter().ToPayloads(wp.parentWorkflowExecution())
+	require.NoError(t, err)
+
+	var parent *internal.ParentWorkflowExecution
+	require.NoError(t, env.GetDataConverter().FromPayloads(pls, &parent))
+	assert.Nil(t, parent)
+}
@@ -0,0 +1,60 @@
+package aggregatedpool
+
+import (
+	"github.com/roadrunner-server/errors"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// default Temporal server limit.blobSize.error
+	defaultBlobSize  int     = 2 * 1024 * 1024
+	defaultWarnRatio float64 = 0.8
+
+	payloadSizeErrType string = "PayloadSizeLimitExceeded"
+)
+
+// PayloadLimits checks the size of the payloads produced by the worker before sending them to the server.
+type PayloadLimits struct {
+	// BlobSize is the server blob size limit in bytes (limit.blobSize.error), default: 2MB.
+	BlobSize int `mapstructure:"blob_size"`
+	// WarnRatio logs a warning when the payloads size reaches BlobSize*WarnRatio, default: 0.8.
+	WarnRatio float64 `mapstructure:"warn_ratio"`
+	// Fail fails the activity, update or workflow task with a clear message instead of sending the oversize payloads.
+	Fail bool `mapstructure:"fail"`
+}
+
+func (l *PayloadLimits) InitDefaults() {
+	if l.BlobSize == 0 {
+		l.BlobSize = defaultBlobSize
+	}
+
+	if l.WarnRatio == 0 {
+		l.WarnRatio = defaultWarnRatio
+	}
+}
+
+// check returns an error if the payloads exceed the blob size limit and Fail is set, source is the command which produced the payloads
+func (l *PayloadLimits) check(log *zap.Logger, source string, name string, pls *commonpb.Payloads) error {
+	if l == nil || pls == nil {
+		return nil
+	}
+
+	size := proto.Size(pls)
+	if float64(size) < float64(l.BlobSize)*l.WarnRatio {
+		return nil
+	}
+
+	if size < l.BlobSize {
+		log.Warn("payloads size is approaching the blob size limit", zap.String("source", source), zap.String("name", name), zap.Int("size", size), zap.Int("limit", l.BlobSize))
+		return nil
+	}
+
+	log.Warn("payloads size exceeds the blob size limit", zap.String("source", source), zap.String("name", name), zap.Int("size", size), zap.Int("limit", l.BlobSize))
+	if !l.Fail {
+		return nil
+	}
+
+	return errors.Errorf("%s '%s' payloads size %d bytes exceeds the blob size limit %d bytes", source, name, size, l.BlobSize)
+}
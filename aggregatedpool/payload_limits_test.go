@@ -0,0 +1,30 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.uber.org/zap"
+)
+
+func Test_PayloadLimits(t *testing.T) {
+	l := &PayloadLimits{BlobSize: 100, Fail: true}
+	l.InitDefaults()
+
+	small := &commonpb.Payloads{Payloads: []*commonpb.Payload{{Data: make([]byte, 10)}}}
+	big := &commonpb.Payloads{Payloads: []*commonpb.Payload{{Data: make([]byte, 200)}}}
+
+	assert.NoError(t, l.check(zap.NewNop(), "activity", "SimpleActivity.echo", small))
+
+	err := l.check(zap.NewNop(), "activity", "SimpleActivity.echo", big)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "SimpleActivity.echo")
+
+	l.Fail = false
+	assert.NoError(t, l.check(zap.NewNop(), "activity", "SimpleActivity.echo", big))
+
+	// not configured
+	var nl *PayloadLimits
+	assert.NoError(t, nl.check(zap.NewNop(), "activity", "SimpleActivity.echo", big))
+}
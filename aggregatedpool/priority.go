@@ -0,0 +1,59 @@
+package aggregatedpool
+
+import (
+	"slices"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+)
+
+const (
+	priorityDefault int = iota
+	priorityHigh
+)
+
+// prioritize stable sorts the batch of messages received from the worker by the command priority.
+// The order depends only on the batch content, so the same batch is reordered in the same way on replay.
+func prioritize(msgs []*internal.Message) {
+	if len(msgs) < 2 {
+		return
+	}
+
+	ids := make(map[uint64]struct{}, len(msgs))
+	// the batch registers the commands under the scopes
+	scoped := false
+	for i := range msgs {
+		ids[msgs[i].ID] = struct{}{}
+		if _, ok := msgs[i].Command.(*internal.CancellationScope); ok {
+			scoped = true
+		}
+	}
+
+	slices.SortStableFunc(msgs, func(a, b *internal.Message) int {
+		return priority(b, ids, scoped) - priority(a, ids, scoped)
+	})
+}
+
+// priority of the command received from the worker.
+// Only Cancel commands are moved ahead: they request cancellation of the already scheduled activities, timers and
+// child workflows, so sending them first avoids wasted work. A Cancel which targets a command from the same batch
+// keeps its place, otherwise it would be a no-op. So does a Cancel of the scopes when the batch registers commands
+// under any scope (CancellationScope): the scope might be nested in the cancelled one, moved ahead the Cancel would
+// miss the commands added to it.
+func priority(msg *internal.Message, batch map[uint64]struct{}, scoped bool) int {
+	cancel, ok := msg.Command.(*internal.Cancel)
+	if !ok {
+		return priorityDefault
+	}
+
+	if scoped && len(cancel.Scopes) > 0 {
+		return priorityDefault
+	}
+
+	for _, id := range cancel.CommandIDs {
+		if _, ok := batch[id]; ok {
+			return priorityDefault
+		}
+	}
+
+	return priorityHigh
+}
@@ -0,0 +1,62 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+)
+
+func Test_PrioritizeCancel(t *testing.T) {
+	msgs := []*internal.Message{
+		{ID: 10, Command: &internal.ExecuteActivity{Name: "a"}},
+		{ID: 11, Command: &internal.NewTimer{Milliseconds: 100}},
+		{ID: 12, Command: &internal.Cancel{CommandIDs: []uint64{1, 2}}},
+	}
+
+	prioritize(msgs)
+	assert.Equal(t, []uint64{12, 10, 11}, ids(msgs))
+}
+
+func Test_PrioritizeCancelSameBatch(t *testing.T) {
+	msgs := []*internal.Message{
+		{ID: 10, Command: &internal.ExecuteActivity{Name: "a"}},
+		{ID: 11, Command: &internal.NewTimer{Milliseconds: 100}},
+		{ID: 12, Command: &internal.Cancel{CommandIDs: []uint64{10}}},
+		{ID: 13, Command: &internal.Cancel{CommandIDs: []uint64{3}}},
+	}
+
+	prioritize(msgs)
+	assert.Equal(t, []uint64{13, 10, 11, 12}, ids(msgs))
+}
+
+func Test_PrioritizeCancelScopes(t *testing.T) {
+	// the scope is cancelled after the batch registers the commands under it
+	msgs := []*internal.Message{
+		{ID: 10, Command: &internal.ExecuteActivity{Name: "a"}},
+		{ID: 11, Command: &internal.CancellationScope{ScopeID: "child", ParentID: "parent", CommandIDs: []uint64{10}}},
+		{ID: 12, Command: &internal.Cancel{Scopes: []string{"parent"}}},
+		{ID: 13, Command: &internal.Cancel{CommandIDs: []uint64{3}}},
+	}
+
+	prioritize(msgs)
+	assert.Equal(t, []uint64{13, 10, 11, 12}, ids(msgs))
+
+	// nothing is registered in the batch
+	msgs = []*internal.Message{
+		{ID: 10, Command: &internal.ExecuteActivity{Name: "a"}},
+		{ID: 11, Command: &internal.Cancel{Scopes: []string{"parent"}}},
+	}
+
+	prioritize(msgs)
+	assert.Equal(t, []uint64{11, 10}, ids(msgs))
+}
+
+func ids(msgs []*internal.Message) []uint64 {
+	res := make([]uint64, 0, len(msgs))
+	for i := range msgs {
+		res = append(res, msgs[i].ID)
+	}
+
+	return res
+}
@@ -0,0 +1,50 @@
+package aggregatedpool
+
+import (
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+)
+
+// publishQueryState stores the query results published by the worker, the results are in the payloads order
+func (wp *Workflow) publishQueryState(cmd *internal.PublishQueryState, payloads *commonpb.Payloads) error {
+	if len(cmd.Queries) != len(payloads.GetPayloads()) {
+		return errors.Errorf("published queries and results mismatch: %d queries, %d results", len(cmd.Queries), len(payloads.GetPayloads()))
+	}
+
+	for _, name := range cmd.Remove {
+		delete(wp.queryState, name)
+	}
+
+	if len(cmd.Queries) == 0 {
+		return nil
+	}
+
+	if wp.queryState == nil {
+		wp.queryState = make(map[string]*commonpb.Payload, len(cmd.Queries))
+	}
+
+	for i, name := range cmd.Queries {
+		if name == "" {
+			return errors.Str("published query name should not be empty")
+		}
+
+		wp.queryState[name] = payloads.GetPayloads()[i]
+	}
+
+	return nil
+}
+
+// publishedQuery returns the published result of the query, the queries with arguments are always sent to the worker
+func (wp *Workflow) publishedQuery(name string, args *commonpb.Payloads) (*commonpb.Payloads, bool) {
+	if len(args.GetPayloads()) > 0 {
+		return nil, false
+	}
+
+	res, ok := wp.queryState[name]
+	if !ok {
+		return nil, false
+	}
+
+	return &commonpb.Payloads{Payloads: []*commonpb.Payload{res}}, true
+}
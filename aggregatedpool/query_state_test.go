@@ -0,0 +1,58 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+func Test_PublishQueryState(t *testing.T) {
+	wp := &Workflow{env: &fakeEnv{}, log: zap.NewNop()}
+	dc := converter.GetDefaultDataConverter()
+
+	results, err := dc.ToPayloads("pending", 3)
+	require.NoError(t, err)
+	require.NoError(t, wp.handleMessage(&internal.Message{
+		ID:       1,
+		Command:  &internal.PublishQueryState{Queries: []string{"status", "items"}},
+		Payloads: results,
+	}))
+
+	// answered without the worker
+	res, err := wp.handleQuery("status", nil, nil)
+	require.NoError(t, err)
+	var status string
+	require.NoError(t, dc.FromPayloads(res, &status))
+	assert.Equal(t, "pending", status)
+
+	// the queries with arguments and the unpublished ones are sent to the worker
+	args, err := dc.ToPayloads("filter")
+	require.NoError(t, err)
+	_, ok := wp.publishedQuery("items", args)
+	assert.False(t, ok)
+	_, ok = wp.publishedQuery("unknown", nil)
+	assert.False(t, ok)
+
+	// republished and removed
+	results, err = dc.ToPayloads("completed")
+	require.NoError(t, err)
+	require.NoError(t, wp.handleMessage(&internal.Message{
+		ID:       2,
+		Command:  &internal.PublishQueryState{Queries: []string{"status"}, Remove: []string{"items"}},
+		Payloads: results,
+	}))
+	res, ok = wp.publishedQuery("status", &commonpb.Payloads{})
+	require.True(t, ok)
+	require.NoError(t, dc.FromPayloads(res, &status))
+	assert.Equal(t, "completed", status)
+	_, ok = wp.publishedQuery("items", nil)
+	assert.False(t, ok)
+
+	// every query should have the result
+	assert.Error(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.PublishQueryState{Queries: []string{"status"}}}))
+}
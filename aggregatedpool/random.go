@@ -0,0 +1,94 @@
+package aggregatedpool
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"math"
+	"math/rand/v2"
+
+	"github.com/roadrunner-server/errors"
+	commonpb "go.temporal.io/api/common/v1"
+)
+
+// maxRandomValues limits the number of values requested by a single GetRandom command
+const maxRandomValues = 1000
+
+// randomValues draws the values from the deterministic random source of the workflow.
+// The source is seeded with a side effect recorded on the first use: on replay the seed is read from the history and
+// the following draws produce the same values.
+func (wp *Workflow) randomValues(count int, maxValue int64) ([]int64, error) {
+	const op = errors.Op("workflow_random_values")
+
+	if count == 0 {
+		count = 1
+	}
+
+	if count < 0 || count > maxRandomValues {
+		return nil, errors.E(op, errors.Errorf("random values count should be between 1 and %d, got: %d", maxRandomValues, count))
+	}
+
+	if maxValue < 0 {
+		return nil, errors.E(op, errors.Errorf("random values max should be positive, got: %d", maxValue))
+	}
+
+	if wp.random == nil {
+		err := wp.seedRandom()
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+	}
+
+	if maxValue == 0 {
+		maxValue = math.MaxInt64
+	}
+
+	values := make([]int64, count)
+	for i := range values {
+		values[i] = wp.random.Int64N(maxValue)
+	}
+
+	return values, nil
+}
+
+// seedRandom records the seed of the random source with a side effect, the side effect callback is called
+// synchronously both for the new and the replayed side effects
+func (wp *Workflow) seedRandom() error {
+	var seed [2]uint64
+	var called bool
+	var err error
+
+	wp.env.SideEffect(func() (*commonpb.Payloads, error) {
+		var b [16]byte
+		_, _ = crand.Read(b[:])
+
+		return wp.env.GetDataConverter().ToPayloads([]uint64{binary.LittleEndian.Uint64(b[:8]), binary.LittleEndian.Uint64(b[8:])})
+	}, func(result *commonpb.Payloads, e error) {
+		called = true
+		if e != nil {
+			err = e
+			return
+		}
+
+		var s []uint64
+		err = wp.env.GetDataConverter().FromPayloads(result, &s)
+		if err == nil && len(s) != 2 {
+			err = errors.Errorf("malformed random seed, expected 2 values, got: %d", len(s))
+		}
+
+		if err == nil {
+			seed = [2]uint64{s[0], s[1]}
+		}
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if !called {
+		return errors.Str("random seed side effect is not resolved")
+	}
+
+	wp.random = rand.New(rand.NewPCG(seed[0], seed[1])) //nolint:gosec
+
+	return nil
+}
@@ -0,0 +1,44 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RandomValuesReplay(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env}
+
+	first, err := wp.randomValues(3, 100)
+	require.NoError(t, err)
+	require.Len(t, first, 3)
+	for _, v := range first {
+		assert.True(t, v >= 0 && v < 100)
+	}
+
+	second, err := wp.randomValues(0, 0)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+
+	// only the seed is recorded
+	require.Len(t, env.sideEffects, 1)
+
+	// replay produces the same values
+	env.replaying = true
+	wp = &Workflow{env: env}
+
+	replayed, err := wp.randomValues(3, 100)
+	require.NoError(t, err)
+	assert.Equal(t, first, replayed)
+
+	replayed, err = wp.randomValues(1, 0)
+	require.NoError(t, err)
+	assert.Equal(t, second, replayed)
+
+	_, err = wp.randomValues(maxRandomValues+1, 0)
+	assert.Error(t, err)
+	_, err = wp.randomValues(1, -1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,123 @@
+package aggregatedpool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/goridge/v3/pkg/frame"
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+
+	staticPool "github.com/roadrunner-server/pool/pool/static_pool"
+)
+
+// resultTimeout bounds the wait for the worker response after Exec returned when the exec_timeout is not set, extended
+// up to the deadlock detection timeout of the worker (workers.<task_queue>.deadlock_detection_timeout)
+const resultTimeout = time.Second * 10
+
+// execResult is the result of the pool execution (*staticPool.PExec)
+type execResult interface {
+	Payload() *payload.Payload
+	Error() error
+}
+
+// execContext returns the context of the pool execution, bounded by the exec_timeout if set.
+// The pool kills the worker which didn't respond in time, the workflow worker is restarted then.
+func (wp *Workflow) execContext() (context.Context, context.CancelFunc) {
+	if wp.cfg == nil || wp.cfg.ExecTimeout == 0 {
+		return context.Background(), func() {}
+	}
+
+	return context.WithTimeout(context.Background(), wp.cfg.ExecTimeout)
+}
+
+// receiveResult reads the worker response to the sent messages, see awaitResult.
+func (wp *Workflow) receiveResult(result chan *staticPool.PExec, stopCh chan struct{}, sent []*internal.Message) (*payload.Payload, error) {
+	const op = errors.Op("workflow_receive_result")
+
+	pld, err := awaitResult(result, stopCh, wp.resultWait(), func(reason string) error {
+		return wp.emptyResponse(sent, reason)
+	})
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	return pld, nil
+}
+
+// resultWait returns the exec_timeout if set, otherwise the resultTimeout or the deadlock detection timeout
+// passed by the SDK with the last workflow task, whichever is longer.
+func (wp *Workflow) resultWait() time.Duration {
+	if wp.cfg != nil && wp.cfg.ExecTimeout > 0 {
+		return wp.cfg.ExecTimeout
+	}
+
+	return max(resultTimeout, wp.deadlockTimeout)
+}
+
+// awaitResult waits for the execution result.
+//
+// The pool pushes the response of a regular execution into the result channel before Exec returns, but the streamed
+// response is pushed from a separate goroutine. The non-blocking receive used before could report an empty response
+// while the (stream) response was on the way, so the receive is blocking, bounded by the timeout.
+func awaitResult[T execResult](result chan T, stopCh chan struct{}, timeout time.Duration, empty func(reason string) error) (*payload.Payload, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case pld, ok := <-result:
+		if !ok {
+			return nil, empty("result channel closed")
+		}
+
+		if pld.Error() != nil {
+			return nil, pld.Error()
+		}
+
+		if pld.Payload() == nil {
+			return nil, empty("no payload")
+		}
+
+		// streaming is not supported
+		if pld.Payload().Flags&frame.STREAM != 0 {
+			stopCh <- struct{}{}
+			return nil, errors.Str("streaming is not supported")
+		}
+
+		return pld.Payload(), nil
+	case <-timer.C:
+		return nil, empty(fmt.Sprintf("no result within %s", timeout))
+	}
+}
+
+// emptyResponse describes the messages which got no response: the sent commands, the number of messages and
+// responses and the workflow worker PID
+func (wp *Workflow) emptyResponse(sent []*internal.Message, reason string) error {
+	pid := "unknown"
+	if wp.pool != nil {
+		if w := wp.pool.Workers(); len(w) > 0 {
+			pid = fmt.Sprint(w[0].Pid())
+		}
+	}
+
+	commands := make([]string, 0, len(sent))
+	responses := 0
+	for _, msg := range sent {
+		if !msg.IsCommand() {
+			responses++
+			continue
+		}
+
+		name, err := internal.CommandName(msg.Command)
+		if err != nil {
+			name = fmt.Sprintf("%T", msg.Command)
+		}
+		commands = append(commands, name)
+	}
+
+	return errors.Errorf("worker empty response (%s): worker pid: %s, messages: %d, commands: [%s], responses: %d",
+		reason, pid, len(sent), strings.Join(commands, ", "), responses)
+}
@@ -0,0 +1,226 @@
+package aggregatedpool
+
+import (
+	"context"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/roadrunner-server/pool/worker"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.uber.org/zap"
+
+	staticPool "github.com/roadrunner-server/pool/pool/static_pool"
+)
+
+// testResult is the execution result pushed by the test pool
+type testResult struct {
+	pld *payload.Payload
+	err error
+}
+
+func (r *testResult) Payload() *payload.Payload {
+	return r.pld
+}
+
+func (r *testResult) Error() error {
+	return r.err
+}
+
+func Test_ReceiveResultEmptyResponse(t *testing.T) {
+	wp := &Workflow{log: zap.NewNop()}
+
+	result := make(chan *staticPool.PExec, 1)
+	close(result)
+
+	sent := []*internal.Message{
+		{ID: 1, Command: internal.StartWorkflow{}},
+		{ID: 2, Command: &internal.InvokeSignal{Name: "approve"}},
+		{ID: 3},
+	}
+
+	_, err := wp.receiveResult(result, make(chan struct{}, 1), sent)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "worker empty response")
+	assert.Contains(t, err.Error(), "messages: 3")
+	assert.Contains(t, err.Error(), "commands: [StartWorkflow, InvokeSignal]")
+	assert.Contains(t, err.Error(), "responses: 1")
+	assert.Contains(t, err.Error(), "worker pid: unknown")
+}
+
+func Test_AwaitResultTimeout(t *testing.T) {
+	_, err := awaitResult(make(chan *testResult, 1), make(chan struct{}, 1), time.Millisecond*10, func(reason string) error {
+		return errors.Str(reason)
+	})
+	assert.EqualError(t, err, "no result within 10ms")
+}
+
+// the results are pushed after Exec returned (as the pool does for the streamed responses), the receive must wait for
+// them instead of reporting an empty response
+func Test_AwaitResultConcurrent(t *testing.T) {
+	const commands = 1000
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, commands)
+
+	for i := range commands {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			result := make(chan *testResult, 1)
+			go func() {
+				time.Sleep(time.Microsecond * time.Duration(rand.IntN(2000))) //nolint:gosec
+				result <- &testResult{pld: &payload.Payload{Body: []byte{byte(i)}}}
+			}()
+
+			pld, err := awaitResult(result, make(chan struct{}, 1), time.Second*5, func(reason string) error {
+				return errors.Str(reason)
+			})
+			if err != nil {
+				errCh <- err
+				return
+			}
+
+			if pld.Body[0] != byte(i) {
+				errCh <- errors.Str("unexpected result")
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		assert.NoError(t, err)
+	}
+}
+
+// latePool pushes the execution result after Exec returned, or never when silent. The result has no payload, the
+// payload of the pool result can't be set outside the pool.
+type latePool struct {
+	stoppedPool
+	silent   bool
+	deadline atomic.Bool
+}
+
+func (p *latePool) Exec(ctx context.Context, _ *payload.Payload, _ chan struct{}) (chan *staticPool.PExec, error) {
+	_, ok := ctx.Deadline()
+	p.deadline.Store(ok)
+
+	result := make(chan *staticPool.PExec, 1)
+	if !p.silent {
+		go func() {
+			time.Sleep(time.Microsecond * time.Duration(rand.IntN(2000))) //nolint:gosec
+			result <- &staticPool.PExec{}
+		}()
+	}
+
+	return result, nil
+}
+
+func (p *latePool) Workers() []*worker.Process {
+	return nil
+}
+
+// concurrent workflows exchange the commands (runCommand) and the frames (flushQueue) with the workers responding
+// after Exec returned: the late response is received, the silent worker is bounded by the exec_timeout
+func Test_ExecTimeoutConcurrent(t *testing.T) {
+	const workflows = 200
+	const execTimeout = time.Millisecond * 200
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, workflows)
+
+	for i := range workflows {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pool := &latePool{silent: i%4 == 0}
+			wp := &Workflow{
+				env:     &fakeEnv{},
+				log:     zap.NewNop(),
+				mq:      queue.NewMessageQueue(seq),
+				codec:   &recordingCodec{},
+				pool:    pool,
+				pldPool: &sync.Pool{New: func() any { return new(payload.Payload) }},
+				cfg:     &WorkflowConfig{ExecTimeout: execTimeout},
+			}
+
+			var err error
+			if i%2 == 0 {
+				_, err = wp.runCommand(internal.InvokeQuery{RunID: "run", Name: "status"}, nil, nil)
+			} else {
+				wp.mq.PushCommand(internal.InvokeSignal{RunID: "run", Name: "approve"}, nil, nil)
+				err = wp.flushQueue()
+			}
+
+			switch {
+			case !pool.deadline.Load():
+				errCh <- errors.Str("the execution is not bounded by the exec_timeout")
+			case err == nil:
+				errCh <- errors.Str("the empty response is not reported")
+			case pool.silent && !strings.Contains(err.Error(), "no result within 200ms"):
+				errCh <- errors.Errorf("silent worker: %v", err)
+			case !pool.silent && !strings.Contains(err.Error(), "no payload"):
+				errCh <- errors.Errorf("late response is not received: %v", err)
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		assert.NoError(t, err)
+	}
+}
+
+func Test_ResultWait(t *testing.T) {
+	wp := &Workflow{}
+	assert.Equal(t, resultTimeout, wp.resultWait())
+
+	// the SDK default deadlock detection timeout doesn't shorten the wait, the configured one extends it
+	wp.deadlockTimeout = time.Second
+	assert.Equal(t, resultTimeout, wp.resultWait())
+	wp.deadlockTimeout = time.Minute
+	assert.Equal(t, time.Minute, wp.resultWait())
+
+	// exec_timeout takes precedence
+	wp.cfg = &WorkflowConfig{ExecTimeout: time.Second * 5}
+	assert.Equal(t, time.Second*5, wp.resultWait())
+}
+
+// the frame is not released while the pool might still write it: the exec failed or the worker didn't respond
+func Test_ExecTimeoutFrameNotReleased(t *testing.T) {
+	codec := &recordingCodec{}
+	wp := &Workflow{
+		env:     &fakeEnv{},
+		log:     zap.NewNop(),
+		mq:      queue.NewMessageQueue(seq),
+		codec:   codec,
+		pool:    &latePool{silent: true},
+		pldPool: &sync.Pool{New: func() any { return new(payload.Payload) }},
+		cfg:     &WorkflowConfig{ExecTimeout: time.Millisecond * 50},
+	}
+
+	_, err := wp.runCommand(internal.InvokeQuery{RunID: "run", Name: "status"}, nil, nil)
+	require.Error(t, err)
+	wp.mq.PushCommand(internal.InvokeSignal{RunID: "run", Name: "approve"}, nil, nil)
+	require.Error(t, wp.flushQueue())
+
+	wp.pool = &stoppedPool{}
+	_, err = wp.runCommand(internal.InvokeQuery{RunID: "run", Name: "status"}, nil, nil)
+	require.Error(t, err)
+
+	assert.Zero(t, codec.released)
+}
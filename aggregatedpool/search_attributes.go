@@ -0,0 +1,179 @@
+package aggregatedpool
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/sdk/temporal"
+)
+
+// typedSearchAttributes converts the typed search attributes received from the worker to the search attribute updates.
+// All attributes are validated first: if any of them can't be converted, the error lists the offending keys and
+// none of the attributes should be applied.
+func typedSearchAttributes(attrs map[string]*internal.TypedSearchAttribute) ([]temporal.SearchAttributeUpdate, error) {
+	const op = errors.Op("typed_search_attributes")
+
+	sau := make([]temporal.SearchAttributeUpdate, 0, len(attrs))
+	var failed []string
+
+	// sorted, the error message should be stable
+	for _, k := range slices.Sorted(maps.Keys(attrs)) {
+		u, err := typedSearchAttribute(k, attrs[k])
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", k, err))
+			continue
+		}
+
+		sau = append(sau, u)
+	}
+
+	if len(failed) > 0 {
+		return nil, errors.E(op, errors.Errorf("invalid search attributes, none applied: %s", strings.Join(failed, "; ")))
+	}
+
+	return sau, nil
+}
+
+// typedSearchAttribute converts a single typed search attribute to the update
+func typedSearchAttribute(k string, v *internal.TypedSearchAttribute) (temporal.SearchAttributeUpdate, error) {
+	if v == nil {
+		return nil, errors.Str("attribute is not set")
+	}
+
+	unset := v.Operation == internal.TypedSearchAttributeOperationUnset
+	if !unset && v.Value == nil {
+		return nil, errors.Str("field value is not set")
+	}
+
+	switch v.Type {
+	case internal.BoolType:
+		key := temporal.NewSearchAttributeKeyBool(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		if tt, ok := v.Value.(bool); ok {
+			return key.ValueSet(tt), nil
+		}
+
+		return nil, errors.Errorf("field value is not a bool type: %v", v.Value)
+
+	case internal.FloatType:
+		key := temporal.NewSearchAttributeKeyFloat64(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		if tt, ok := v.Value.(float64); ok {
+			return key.ValueSet(tt), nil
+		}
+
+		return nil, errors.Errorf("field value is not a float64 type: %v", v.Value)
+
+	case internal.IntType:
+		key := temporal.NewSearchAttributeKeyInt64(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		switch ti := v.Value.(type) {
+		case float64:
+			return key.ValueSet(int64(ti)), nil
+		case int:
+			return key.ValueSet(int64(ti)), nil
+		case int64:
+			return key.ValueSet(ti), nil
+		case int32:
+			return key.ValueSet(int64(ti)), nil
+		case int16:
+			return key.ValueSet(int64(ti)), nil
+		case int8:
+			return key.ValueSet(int64(ti)), nil
+		case string:
+			i, err := strconv.ParseInt(ti, 10, 64)
+			if err != nil {
+				return nil, errors.Errorf("failed to parse int: %v", err)
+			}
+
+			return key.ValueSet(i), nil
+		default:
+			return nil, errors.Errorf("field value is not an int type: %v", v.Value)
+		}
+
+	case internal.KeywordType:
+		key := temporal.NewSearchAttributeKeyKeyword(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		if tt, ok := v.Value.(string); ok {
+			return key.ValueSet(tt), nil
+		}
+
+		return nil, errors.Errorf("field value is not a string type: %v", v.Value)
+
+	case internal.KeywordListType:
+		key := temporal.NewSearchAttributeKeyKeywordList(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		switch tt := v.Value.(type) {
+		case []string:
+			return key.ValueSet(tt), nil
+		case []any:
+			res := make([]string, 0, len(tt))
+			for _, v := range tt {
+				s, ok := v.(string)
+				if !ok {
+					return nil, errors.Errorf("keyword list item is not a string: %v", v)
+				}
+
+				res = append(res, s)
+			}
+
+			return key.ValueSet(res), nil
+		default:
+			return nil, errors.Errorf("field value is not a []string (strings array) type: %v", v.Value)
+		}
+
+	case internal.StringType:
+		key := temporal.NewSearchAttributeKeyString(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		if tt, ok := v.Value.(string); ok {
+			return key.ValueSet(tt), nil
+		}
+
+		return nil, errors.Errorf("field value is not a string type: %v", v.Value)
+
+	case internal.DatetimeType:
+		key := temporal.NewSearchAttributeKeyTime(k)
+		if unset {
+			return key.ValueUnset(), nil
+		}
+
+		tt, ok := v.Value.(string)
+		if !ok {
+			return nil, errors.Errorf("field value is not a RFC3339 datetime string: %v", v.Value)
+		}
+
+		tm, err := time.Parse(time.RFC3339, tt)
+		if err != nil {
+			return nil, errors.Errorf("failed to parse time into RFC3339: %v", err)
+		}
+
+		return key.ValueSet(tm), nil
+
+	default:
+		return nil, errors.Errorf("unknown search attribute type: %s", v.Type)
+	}
+}
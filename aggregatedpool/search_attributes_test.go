@@ -0,0 +1,65 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/sdk/temporal"
+)
+
+func Test_TypedSearchAttributes(t *testing.T) {
+	sau, err := typedSearchAttributes(map[string]*internal.TypedSearchAttribute{
+		"tenant":   {Type: internal.KeywordType, Value: "acme"},
+		"priority": {Type: internal.IntType, Value: float64(10)},
+		"started":  {Type: internal.DatetimeType, Value: "2024-01-02T15:04:05Z"},
+		"enabled":  {Type: internal.BoolType, Operation: internal.TypedSearchAttributeOperationUnset},
+	})
+	require.NoError(t, err)
+	require.Len(t, sau, 4)
+
+	sa := temporal.NewSearchAttributes(sau...)
+
+	tenant, ok := sa.GetKeyword(temporal.NewSearchAttributeKeyKeyword("tenant"))
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+
+	priority, ok := sa.GetInt64(temporal.NewSearchAttributeKeyInt64("priority"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(10), priority)
+}
+
+func Test_TypedSearchAttributesWrongDatetime(t *testing.T) {
+	_, err := typedSearchAttributes(map[string]*internal.TypedSearchAttribute{
+		"started": {Type: internal.DatetimeType, Value: "yesterday"},
+	})
+	assert.Error(t, err)
+}
+
+func Test_TypedSearchAttributesAllOrNothing(t *testing.T) {
+	sau, err := typedSearchAttributes(map[string]*internal.TypedSearchAttribute{
+		"tenant":  {Type: internal.KeywordType, Value: "acme"},
+		"enabled": {Type: internal.BoolType, Value: "true"},
+		"tags":    {Type: internal.KeywordListType, Value: []any{"a", float64(1)}},
+		"foo":     {Type: "uuid", Value: "bar"},
+	})
+	require.Error(t, err)
+	assert.Nil(t, sau)
+
+	// every offending key is listed, the valid ones are not applied either
+	assert.Contains(t, err.Error(), "enabled:")
+	assert.Contains(t, err.Error(), "tags:")
+	assert.Contains(t, err.Error(), "foo:")
+	assert.NotContains(t, err.Error(), "tenant")
+}
+
+func Test_ValidateTypedSearchAttributes(t *testing.T) {
+	require.Error(t, ValidateTypedSearchAttributes(nil))
+	require.Error(t, ValidateTypedSearchAttributes(map[string]*internal.TypedSearchAttribute{
+		"tenant": {Type: internal.KeywordType, Value: 10},
+	}))
+	require.NoError(t, ValidateTypedSearchAttributes(map[string]*internal.TypedSearchAttribute{
+		"tenant": {Type: internal.KeywordType, Value: "acme"},
+	}))
+}
@@ -0,0 +1,92 @@
+package aggregatedpool
+
+import (
+	"slices"
+	"sync/atomic"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+// selector is the pending Select command
+type selector struct {
+	id       uint64
+	commands []uint64
+	signals  []string
+}
+
+// registerSelect adds the Select command, it is resolved by the first completed command or received signal
+func (wp *Workflow) registerSelect(id uint64, cmd *internal.Select) {
+	wp.selectors = append(wp.selectors, &selector{
+		id:       id,
+		commands: cmd.CommandIDs,
+		signals:  cmd.Signals,
+	})
+
+	wp.canceller.Register(id, func() error {
+		if wp.removeSelect(id) {
+			wp.mq.PushError(id, temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewCanceledError()))
+		}
+
+		return nil
+	})
+}
+
+// resolveSelect resolves the selectors awaiting the completed command (or the signal), the selectors are resolved
+// in the registration order right after the command response
+func (wp *Workflow) resolveSelect(commandID uint64, signal string) {
+	if len(wp.selectors) == 0 {
+		return
+	}
+
+	wp.selectors = slices.DeleteFunc(wp.selectors, func(s *selector) bool {
+		var res internal.SelectResult
+		switch {
+		case signal == "" && slices.Contains(s.commands, commandID):
+			res.ID = commandID
+		case signal != "" && slices.Contains(s.signals, signal):
+			res.Signal = signal
+		default:
+			return false
+		}
+
+		result, err := wp.env.GetDataConverter().ToPayloads(res)
+		if err != nil {
+			wp.mq.PushError(s.id, temporal.GetDefaultFailureConverter().ErrorToFailure(err))
+		} else {
+			wp.log.Debug("select resolved", zap.Uint64("ID", s.id), zap.Uint64("command_id", res.ID), zap.String("signal", res.Signal))
+			wp.mq.PushResponse(s.id, result)
+		}
+
+		wp.canceller.Discard(s.id)
+
+		return true
+	})
+}
+
+// selectSignal resolves the selectors awaiting the signal. The signals are handled when the history events are
+// applied, the selectors are resolved with the callbacks to keep the history order with the completed commands.
+func (wp *Workflow) selectSignal(name string) {
+	if !slices.ContainsFunc(wp.selectors, func(s *selector) bool { return slices.Contains(s.signals, name) }) {
+		return
+	}
+
+	if atomic.LoadUint32(&wp.inLoop) == 1 {
+		wp.resolveSelect(0, name)
+		return
+	}
+
+	wp.callbacks = append(wp.callbacks, func() error {
+		wp.resolveSelect(0, name)
+		return nil
+	})
+}
+
+// removeSelect removes the pending selector, returns false if it is already resolved
+func (wp *Workflow) removeSelect(id uint64) bool {
+	n := len(wp.selectors)
+	wp.selectors = slices.DeleteFunc(wp.selectors, func(s *selector) bool { return s.id == id })
+
+	return len(wp.selectors) != n
+}
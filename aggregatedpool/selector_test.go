@@ -0,0 +1,99 @@
+package aggregatedpool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+func selectResult(t *testing.T, msg *internal.Message) internal.SelectResult {
+	var res internal.SelectResult
+	require.NoError(t, converter.GetDefaultDataConverter().FromPayloads(msg.Payloads, &res))
+	return res
+}
+
+func Test_Select(t *testing.T) {
+	wp := &Workflow{
+		env:       &fakeEnv{},
+		log:       zap.NewNop(),
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+	}
+
+	wp.registerSelect(10, &internal.Select{CommandIDs: []uint64{5, 7}, Signals: []string{"approve"}})
+	wp.registerSelect(11, &internal.Select{CommandIDs: []uint64{7}})
+	wp.registerSelect(12, &internal.Select{Signals: []string{"approve"}})
+
+	// not awaited
+	wp.resolveSelect(6, "")
+	assert.Empty(t, wp.mq.Messages())
+
+	// both selectors awaiting the command are resolved in the registration order
+	wp.resolveSelect(7, "")
+	require.Len(t, wp.mq.Messages(), 2)
+	assert.Equal(t, uint64(10), wp.mq.Messages()[0].ID)
+	assert.Equal(t, internal.SelectResult{ID: 7}, selectResult(t, wp.mq.Messages()[0]))
+	assert.Equal(t, uint64(11), wp.mq.Messages()[1].ID)
+	wp.mq.Flush()
+
+	// signals are resolved with the callbacks outside the workflow task loop
+	wp.selectSignal("approve")
+	assert.Empty(t, wp.mq.Messages())
+	require.Len(t, wp.callbacks, 1)
+	require.NoError(t, wp.callbacks[0]())
+	require.Len(t, wp.mq.Messages(), 1)
+	assert.Equal(t, uint64(12), wp.mq.Messages()[0].ID)
+	assert.Equal(t, internal.SelectResult{Signal: "approve"}, selectResult(t, wp.mq.Messages()[0]))
+	wp.mq.Flush()
+
+	// cancelled select
+	wp.registerSelect(13, &internal.Select{CommandIDs: []uint64{8}})
+	require.NoError(t, wp.canceller.Cancel(13))
+	require.Len(t, wp.mq.Messages(), 1)
+	assert.NotNil(t, wp.mq.Messages()[0].Failure)
+
+	wp.resolveSelect(8, "")
+	assert.Len(t, wp.mq.Messages(), 1)
+	assert.Empty(t, wp.selectors)
+}
+
+func Test_SelectContinuableCallback(t *testing.T) {
+	codec := &recordingCodec{}
+	wp := &Workflow{
+		env:       &fakeEnv{},
+		log:       zap.NewNop(),
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+		codec:     codec,
+		pool:      &stoppedPool{},
+		pldPool:   &sync.Pool{New: func() any { return new(payload.Payload) }},
+	}
+
+	wp.registerSelect(10, &internal.Select{CommandIDs: []uint64{5}})
+	wp.registerSelect(11, &internal.Select{CommandIDs: []uint64{6}})
+
+	// the failed side effect resolves the selector
+	wp.createContinuableCallback(5, "SideEffect")(nil, errors.Str("boom"))
+	require.Len(t, wp.mq.Messages(), 2)
+	assert.NotNil(t, wp.mq.Messages()[0].Failure)
+	assert.Equal(t, internal.SelectResult{ID: 5}, selectResult(t, wp.mq.Messages()[1]))
+	wp.mq.Flush()
+
+	// the selector is resolved in the same frame as the side effect result, the stopped worker fails the flush
+	result, err := converter.GetDefaultDataConverter().ToPayloads("value")
+	require.NoError(t, err)
+	assert.Panics(t, func() { wp.createContinuableCallback(6, "SideEffect")(result, nil) })
+	require.Len(t, codec.sent, 2)
+	assert.Equal(t, uint64(6), codec.sent[0].ID)
+	assert.Equal(t, uint64(11), codec.sent[1].ID)
+	assert.Empty(t, wp.selectors)
+}
@@ -0,0 +1,105 @@
+package aggregatedpool
+
+import (
+	"slices"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+// semaphore is the workflow-scoped semaphore, see internal.AcquireSemaphore
+type semaphore struct {
+	capacity int64
+	used     int64
+	// pending AcquireSemaphore commands in the order they were received
+	waiters []*semaphoreWaiter
+}
+
+type semaphoreWaiter struct {
+	id      uint64
+	permits int64
+}
+
+// acquireSemaphore queues the AcquireSemaphore command, it's resolved right away when the permits are available.
+// The waiters are served in the FIFO order: the first waiter blocks the following ones until its permits are released.
+func (wp *Workflow) acquireSemaphore(id uint64, cmd *internal.AcquireSemaphore) error {
+	if cmd.Name == "" {
+		return errors.Str("semaphore name should not be empty")
+	}
+
+	if cmd.Capacity < 0 || cmd.Permits < 0 {
+		return errors.Errorf("semaphore '%s': capacity and permits should be positive", cmd.Name)
+	}
+
+	permits := max(cmd.Permits, 1)
+
+	sem, ok := wp.semaphores[cmd.Name]
+	switch {
+	case !ok:
+		sem = &semaphore{capacity: max(cmd.Capacity, 1)}
+		if wp.semaphores == nil {
+			wp.semaphores = make(map[string]*semaphore)
+		}
+		wp.semaphores[cmd.Name] = sem
+	case cmd.Capacity != 0 && cmd.Capacity != sem.capacity:
+		return errors.Errorf("semaphore '%s': capacity %d doesn't match the capacity %d set by the first command", cmd.Name, cmd.Capacity, sem.capacity)
+	}
+
+	if permits > sem.capacity {
+		return errors.Errorf("semaphore '%s': %d permits requested, the capacity is %d", cmd.Name, permits, sem.capacity)
+	}
+
+	sem.waiters = append(sem.waiters, &semaphoreWaiter{id: id, permits: permits})
+	wp.canceller.Register(id, func() error {
+		n := len(sem.waiters)
+		sem.waiters = slices.DeleteFunc(sem.waiters, func(w *semaphoreWaiter) bool { return w.id == id })
+		if len(sem.waiters) != n {
+			wp.mq.PushError(id, temporal.GetDefaultFailureConverter().ErrorToFailure(temporal.NewCanceledError()))
+			wp.resolveSelect(id, "")
+			// the cancelled waiter might have blocked the following ones
+			wp.grantSemaphore(cmd.Name, sem)
+		}
+
+		return nil
+	})
+
+	wp.grantSemaphore(cmd.Name, sem)
+
+	return nil
+}
+
+// releaseSemaphore returns the permits to the semaphore and resolves the waiters which fit into the capacity
+func (wp *Workflow) releaseSemaphore(cmd *internal.ReleaseSemaphore) error {
+	sem, ok := wp.semaphores[cmd.Name]
+	if !ok {
+		return errors.Errorf("semaphore '%s' was not acquired", cmd.Name)
+	}
+
+	permits := max(cmd.Permits, 1)
+	if permits > sem.used {
+		return errors.Errorf("semaphore '%s': %d permits released, %d acquired", cmd.Name, permits, sem.used)
+	}
+
+	sem.used -= permits
+	wp.grantSemaphore(cmd.Name, sem)
+
+	return nil
+}
+
+// grantSemaphore resolves the first waiters (and the selectors awaiting them) while the permits are available
+func (wp *Workflow) grantSemaphore(name string, sem *semaphore) {
+	for len(sem.waiters) > 0 && sem.used+sem.waiters[0].permits <= sem.capacity {
+		w := sem.waiters[0]
+		sem.waiters = sem.waiters[1:]
+		sem.used += w.permits
+
+		wp.log.Debug("semaphore acquired", zap.Uint64("ID", w.id), zap.String("name", name), zap.Int64("permits", w.permits), zap.Int64("used", sem.used))
+		wp.canceller.Discard(w.id)
+
+		result, _ := wp.env.GetDataConverter().ToPayloads(completed)
+		wp.mq.PushResponse(w.id, result)
+		wp.resolveSelect(w.id, "")
+	}
+}
@@ -0,0 +1,105 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.uber.org/zap"
+)
+
+func semaphoreWorkflow() *Workflow {
+	return &Workflow{env: &fakeEnv{}, log: zap.NewNop(), mq: queue.NewMessageQueue(seq), canceller: new(canceller.Canceller)}
+}
+
+// resolved returns the IDs of the resolved commands and flushes the queue
+func resolved(wp *Workflow) []uint64 {
+	ids := make([]uint64, 0, len(wp.mq.Messages()))
+	for _, msg := range wp.mq.Messages() {
+		ids = append(ids, msg.ID)
+	}
+	wp.mq.Flush()
+
+	return ids
+}
+
+func Test_SemaphoreContention(t *testing.T) {
+	wp := semaphoreWorkflow()
+
+	require.NoError(t, wp.acquireSemaphore(1, &internal.AcquireSemaphore{Name: "db", Capacity: 2}))
+	require.NoError(t, wp.acquireSemaphore(2, &internal.AcquireSemaphore{Name: "db"}))
+	assert.Equal(t, []uint64{1, 2}, resolved(wp))
+
+	// the capacity is exhausted, the waiters are served in the FIFO order
+	require.NoError(t, wp.acquireSemaphore(3, &internal.AcquireSemaphore{Name: "db", Permits: 2}))
+	require.NoError(t, wp.acquireSemaphore(4, &internal.AcquireSemaphore{Name: "db"}))
+	assert.Empty(t, resolved(wp))
+
+	// the first waiter needs both permits, the second one waits behind it
+	require.NoError(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "db"}))
+	assert.Empty(t, resolved(wp))
+
+	require.NoError(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "db"}))
+	assert.Equal(t, []uint64{3}, resolved(wp))
+
+	require.NoError(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "db", Permits: 2}))
+	assert.Equal(t, []uint64{4}, resolved(wp))
+
+	// more permits than acquired or the capacity, the capacity mismatch
+	assert.Error(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "db", Permits: 2}))
+	assert.Error(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "unknown"}))
+	assert.Error(t, wp.acquireSemaphore(5, &internal.AcquireSemaphore{Name: "db", Permits: 3}))
+	assert.Error(t, wp.acquireSemaphore(6, &internal.AcquireSemaphore{Name: "db", Capacity: 3}))
+	assert.Error(t, wp.acquireSemaphore(7, &internal.AcquireSemaphore{}))
+}
+
+func Test_SemaphoreCancelWhileWaiting(t *testing.T) {
+	wp := semaphoreWorkflow()
+
+	// mutex by default
+	require.NoError(t, wp.acquireSemaphore(1, &internal.AcquireSemaphore{Name: "lock"}))
+	require.NoError(t, wp.acquireSemaphore(2, &internal.AcquireSemaphore{Name: "lock"}))
+	require.NoError(t, wp.acquireSemaphore(3, &internal.AcquireSemaphore{Name: "lock"}))
+	assert.Equal(t, []uint64{1}, resolved(wp))
+
+	// the cancelled waiter gets the canceled failure and doesn't take the permit
+	require.NoError(t, wp.canceller.Cancel(2))
+	require.Len(t, wp.mq.Messages(), 1)
+	assert.Equal(t, uint64(2), wp.mq.Messages()[0].ID)
+	assert.NotNil(t, wp.mq.Messages()[0].Failure)
+	wp.mq.Flush()
+
+	require.NoError(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "lock"}))
+	assert.Equal(t, []uint64{3}, resolved(wp))
+
+	// the acquired semaphore is not released by the cancellation
+	require.NoError(t, wp.canceller.Cancel(3))
+	assert.Empty(t, resolved(wp))
+	assert.Equal(t, int64(1), wp.semaphores["lock"].used)
+}
+
+func Test_SemaphoreSelect(t *testing.T) {
+	wp := semaphoreWorkflow()
+
+	require.NoError(t, wp.acquireSemaphore(1, &internal.AcquireSemaphore{Name: "lock"}))
+	require.NoError(t, wp.acquireSemaphore(2, &internal.AcquireSemaphore{Name: "lock"}))
+	require.NoError(t, wp.acquireSemaphore(3, &internal.AcquireSemaphore{Name: "lock"}))
+	assert.Equal(t, []uint64{1}, resolved(wp))
+
+	wp.registerSelect(10, &internal.Select{CommandIDs: []uint64{2}})
+	wp.registerSelect(11, &internal.Select{CommandIDs: []uint64{3}})
+
+	// the granted waiter resolves the selector right after its response
+	require.NoError(t, wp.releaseSemaphore(&internal.ReleaseSemaphore{Name: "lock"}))
+	require.Len(t, wp.mq.Messages(), 2)
+	assert.Equal(t, internal.SelectResult{ID: 2}, selectResult(t, wp.mq.Messages()[1]))
+	assert.Equal(t, []uint64{2, 10}, resolved(wp))
+
+	// and so does the cancelled one
+	require.NoError(t, wp.canceller.Cancel(3))
+	assert.Equal(t, []uint64{3, 11}, resolved(wp))
+	assert.Empty(t, wp.selectors)
+}
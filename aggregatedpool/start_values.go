@@ -0,0 +1,34 @@
+package aggregatedpool
+
+import (
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+// decodeFields decodes the memo or search attributes fields supplied by the workflow starter, nil if there are no fields.
+// Fields that can't be decoded by the data converter (e.g. encoded with a custom PHP codec) are skipped,
+// the raw payloads are still available in the workflow info.
+func (wp *Workflow) decodeFields(kind string, dc converter.DataConverter, fields map[string]*commonpb.Payload) map[string]any {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	res := make(map[string]any, len(fields))
+	for k, v := range fields {
+		var val any
+		err := dc.FromPayload(v, &val)
+		if err != nil {
+			wp.log.Warn("failed to decode the start value, skipping", zap.String("kind", kind), zap.String("key", k), zap.Error(err))
+			continue
+		}
+
+		res[k] = val
+	}
+
+	if len(res) == 0 {
+		return nil
+	}
+
+	return res
+}
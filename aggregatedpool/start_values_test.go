@@ -0,0 +1,35 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+func Test_DecodeStartFields(t *testing.T) {
+	wp := &Workflow{log: zap.NewNop()}
+	dc := converter.GetDefaultDataConverter()
+
+	priority, err := dc.ToPayload("high")
+	require.NoError(t, err)
+	attempts, err := dc.ToPayload(3)
+	require.NoError(t, err)
+
+	fields := map[string]*commonpb.Payload{
+		"priority": priority,
+		"attempts": attempts,
+		// encoded by a codec unknown to the data converter
+		"secret": {Metadata: map[string][]byte{converter.MetadataEncoding: []byte("binary/encrypted")}, Data: []byte("...")},
+	}
+
+	res := wp.decodeFields("memo", dc, fields)
+	assert.Equal(t, map[string]any{"priority": "high", "attempts": float64(3)}, res)
+
+	// empty case
+	assert.Nil(t, wp.decodeFields("memo", dc, nil))
+	assert.Nil(t, wp.decodeFields("memo", dc, map[string]*commonpb.Payload{"secret": fields["secret"]}))
+}
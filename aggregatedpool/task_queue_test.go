@@ -0,0 +1,34 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/workflow"
+)
+
+func Test_RoutedWorkflowTaskQueue(t *testing.T) {
+	workflows := map[string]*internal.WorkflowInfo{
+		"Payment": {Name: "Payment", TaskQueue: "high-priority", RegisteredTaskQueue: "default"},
+		"Fraud":   {Name: "Fraud", TaskQueue: "high-priority", RegisteredTaskQueue: "default"},
+		"Report":  {Name: "Report", TaskQueue: "default"},
+	}
+
+	wp := &Workflow{env: &fakeEnv{info: &workflow.Info{TaskQueueName: "high-priority", WorkflowType: bindings.WorkflowType{Name: "Payment"}}}, workflows: workflows}
+
+	// the PHP worker serves the workflow on the task queue it was registered on
+	assert.Equal(t, "default", wp.taskQueue())
+
+	// routed children are started on their task queue, the others on the registered one
+	assert.Equal(t, "high-priority", wp.workflowTaskQueue("Fraud"))
+	assert.Equal(t, "default", wp.workflowTaskQueue("Report"))
+	assert.Equal(t, "default", wp.workflowTaskQueue("Unknown"))
+
+	// not routed workflows keep the polled task queue
+	wp = &Workflow{env: &fakeEnv{}, workflows: workflows}
+	assert.Equal(t, "default", wp.taskQueue())
+	assert.Equal(t, "default", wp.workflowTaskQueue("Report"))
+	assert.Equal(t, "high-priority", wp.workflowTaskQueue("Payment"))
+}
@@ -0,0 +1,20 @@
+package aggregatedpool
+
+import (
+	"time"
+	// fallback when the system zone database is not available
+	_ "time/tzdata"
+)
+
+// currentTime converts the workflow time to the IANA timezone and returns it in RFC3339 format with the zone offset.
+// The workflow time is deterministic, DST transitions are handled by the time package. Zone rules are loaded
+// from the system database (or ZONEINFO), the embedded one is used if it is missing, so all workers replaying
+// the workflow should use the same zone database version.
+func currentTime(now time.Time, timezone string) (string, error) {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return "", err
+	}
+
+	return now.In(loc).Format(time.RFC3339Nano), nil
+}
@@ -0,0 +1,31 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CurrentTimeDST(t *testing.T) {
+	// 2024-03-31 01:00 UTC is the DST switch in Europe/Berlin
+	before := time.Date(2024, 3, 31, 0, 30, 0, 0, time.UTC)
+
+	now, err := currentTime(before, "Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-31T01:30:00+01:00", now)
+
+	now, err = currentTime(before.Add(time.Hour), "Europe/Berlin")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-03-31T03:30:00+02:00", now)
+}
+
+func Test_CurrentTimeUTC(t *testing.T) {
+	now, err := currentTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), "")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-01-01T00:00:00Z", now)
+
+	_, err = currentTime(time.Now(), "Mars/Olympus")
+	require.Error(t, err)
+}
@@ -0,0 +1,68 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.uber.org/zap"
+)
+
+func Test_UpdatesLimitReached(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{
+		env:              env,
+		cfg:              &WorkflowConfig{MaxInFlightUpdates: 2},
+		updateCompleteCb: map[string]func(res *internal.Message){},
+	}
+
+	assert.False(t, wp.updatesLimitReached())
+
+	wp.updateCompleteCb["1"] = func(*internal.Message) {}
+	wp.updateCompleteCb["2"] = func(*internal.Message) {}
+	assert.True(t, wp.updatesLimitReached())
+
+	// accepted updates are in the history, not rejected on replay
+	env.replaying = true
+	assert.False(t, wp.updatesLimitReached())
+
+	// completed update frees the slot
+	env.replaying = false
+	delete(wp.updateCompleteCb, "1")
+	assert.False(t, wp.updatesLimitReached())
+
+	// no limit
+	wp.cfg = &WorkflowConfig{}
+	wp.updateCompleteCb["1"] = func(*internal.Message) {}
+	assert.False(t, wp.updatesLimitReached())
+
+	assert.Error(t, (&WorkflowConfig{MaxInFlightUpdates: -1}).Validate())
+}
+
+func Test_StrictUpdateCallbacks(t *testing.T) {
+	wp := &Workflow{
+		env:              &fakeEnv{},
+		log:              zap.NewNop(),
+		cfg:              &WorkflowConfig{},
+		updateCompleteCb: map[string]func(res *internal.Message){"b": func(*internal.Message) {}, "a": func(*internal.Message) {}},
+		updateValidateCb: map[string]func(res *internal.Message){},
+	}
+
+	// lenient by default
+	assert.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.UpdateCompleted{ID: "unknown"}}))
+	assert.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.UpdateValidated{ID: "unknown"}}))
+
+	wp.cfg.StrictUpdateCallbacks = true
+	err := wp.handleMessage(&internal.Message{ID: 3, Command: &internal.UpdateCompleted{ID: "unknown"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't complete update: unknown, known IDs: [a, b]")
+
+	err = wp.handleMessage(&internal.Message{ID: 4, Command: &internal.UpdateValidated{ID: "unknown"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "can't validate update: unknown, known IDs: []")
+
+	// the known update is completed
+	assert.NoError(t, wp.handleMessage(&internal.Message{ID: 5, Command: &internal.UpdateCompleted{ID: "a"}}))
+	assert.NotContains(t, wp.updateCompleteCb, "a")
+}
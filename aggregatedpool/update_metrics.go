@@ -0,0 +1,70 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	temporalClient "go.temporal.io/sdk/client"
+)
+
+const (
+	// RrWorkflowUpdateValidateLatencyMetricName is the time from the validate command to the worker validation result by the update name
+	RrWorkflowUpdateValidateLatencyMetricName string = "rr_workflow_update_validate_latency"
+	// RrWorkflowUpdateExecuteLatencyMetricName is the time from the update acceptance to its completion by the update name
+	RrWorkflowUpdateExecuteLatencyMetricName string = "rr_workflow_update_execute_latency"
+	// RrWorkflowUpdatesAcceptedMetricName counts the accepted updates by the update name
+	RrWorkflowUpdatesAcceptedMetricName string = "rr_workflow_updates_accepted"
+	// RrWorkflowUpdatesRejectedMetricName counts the rejected (by the validator or the in-flight limit) updates by the update name
+	RrWorkflowUpdatesRejectedMetricName string = "rr_workflow_updates_rejected"
+)
+
+// updateMetrics returns the metrics handler tagged by the update name, nil without the metrics or during replay:
+// the replayed updates were already recorded by the worker which executed them first.
+// Latencies are measured in the wall time, the workflow time doesn't move within the workflow task.
+func (wp *Workflow) updateMetrics(name string) temporalClient.MetricsHandler {
+	if wp.mh == nil || wp.env.IsReplaying() {
+		return nil
+	}
+
+	return wp.mh.WithTags(map[string]string{"update_name": name})
+}
+
+// recordUpdateValidated records the validation result and latency, returns the acceptance time
+func (wp *Workflow) recordUpdateValidated(name string, started time.Time, accepted bool) time.Time {
+	now := time.Now()
+	mh := wp.updateMetrics(name)
+	if mh == nil {
+		return now
+	}
+
+	mh.Timer(RrWorkflowUpdateValidateLatencyMetricName).Record(now.Sub(started))
+	if accepted {
+		mh.Counter(RrWorkflowUpdatesAcceptedMetricName).Inc(1)
+	} else {
+		mh.Counter(RrWorkflowUpdatesRejectedMetricName).Inc(1)
+	}
+
+	return now
+}
+
+// recordUpdateAccepted records the update accepted without validation, returns the acceptance time
+func (wp *Workflow) recordUpdateAccepted(name string) time.Time {
+	if mh := wp.updateMetrics(name); mh != nil {
+		mh.Counter(RrWorkflowUpdatesAcceptedMetricName).Inc(1)
+	}
+
+	return time.Now()
+}
+
+// recordUpdateRejected records the update rejected before validation (in-flight limit)
+func (wp *Workflow) recordUpdateRejected(name string) {
+	if mh := wp.updateMetrics(name); mh != nil {
+		mh.Counter(RrWorkflowUpdatesRejectedMetricName).Inc(1)
+	}
+}
+
+// recordUpdateCompleted records the update execution latency from its acceptance
+func (wp *Workflow) recordUpdateCompleted(name string, accepted time.Time) {
+	if mh := wp.updateMetrics(name); mh != nil {
+		mh.Timer(RrWorkflowUpdateExecuteLatencyMetricName).Record(time.Since(accepted))
+	}
+}
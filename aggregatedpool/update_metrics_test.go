@@ -0,0 +1,84 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/api/failure/v1"
+	"go.uber.org/zap"
+)
+
+// updateResult records the update callbacks calls
+type updateResult struct {
+	accepted  bool
+	rejected  error
+	completed bool
+}
+
+func (u *updateResult) Accept()             { u.accepted = true }
+func (u *updateResult) Reject(err error)    { u.rejected = err }
+func (u *updateResult) Complete(any, error) { u.completed = true }
+
+func Test_UpdateMetrics(t *testing.T) {
+	env := &fakeEnv{}
+	mh := newRecordingHandler()
+	wp := &Workflow{
+		env:              env,
+		log:              zap.NewNop(),
+		mh:               mh,
+		cfg:              &WorkflowConfig{MaxInFlightUpdates: 2},
+		mq:               queue.NewMessageQueue(seq),
+		workflows:        map[string]*internal.WorkflowInfo{"": {ExecOnlyUpdates: []string{"addItem"}}},
+		updatesQueue:     map[string]struct{}{},
+		updateCompleteCb: map[string]func(res *internal.Message){},
+		updateValidateCb: map[string]func(res *internal.Message){},
+	}
+
+	// validated and completed
+	approve := &updateResult{}
+	wp.handleUpdate("approve", "1", nil, nil, approve)
+	wp.updateValidateCb["1"](&internal.Message{})
+	wp.updateCompleteCb["1"](&internal.Message{})
+	delete(wp.updateCompleteCb, "1")
+	assert.True(t, approve.accepted)
+	assert.True(t, approve.completed)
+
+	// rejected by the validator
+	wp.handleUpdate("approve", "2", nil, nil, &updateResult{})
+	wp.updateValidateCb["2"](&internal.Message{Failure: &failure.Failure{Message: "not allowed"}})
+	delete(wp.updateCompleteCb, "2")
+
+	// accepted without validation
+	wp.handleUpdate("addItem", "3", nil, nil, &updateResult{})
+	require.NotContains(t, wp.updateValidateCb, "3")
+	wp.updateCompleteCb["3"](&internal.Message{})
+	delete(wp.updateCompleteCb, "3")
+
+	assert.Equal(t, int64(1), mh.counters[RrWorkflowUpdatesAcceptedMetricName+":approve"])
+	assert.Equal(t, int64(1), mh.counters[RrWorkflowUpdatesRejectedMetricName+":approve"])
+	assert.Equal(t, int64(1), mh.counters[RrWorkflowUpdatesAcceptedMetricName+":addItem"])
+	assert.Contains(t, mh.timers, RrWorkflowUpdateValidateLatencyMetricName+":approve")
+	assert.Contains(t, mh.timers, RrWorkflowUpdateExecuteLatencyMetricName+":approve")
+	assert.Contains(t, mh.timers, RrWorkflowUpdateExecuteLatencyMetricName+":addItem")
+	assert.NotContains(t, mh.timers, RrWorkflowUpdateValidateLatencyMetricName+":addItem")
+
+	// rejected by the in-flight limit
+	wp.updateCompleteCb["a"] = func(*internal.Message) {}
+	wp.updateCompleteCb["b"] = func(*internal.Message) {}
+	limited := &updateResult{}
+	wp.handleUpdate("addItem", "4", nil, nil, limited)
+	assert.Error(t, limited.rejected)
+	assert.Equal(t, int64(1), mh.counters[RrWorkflowUpdatesRejectedMetricName+":addItem"])
+
+	// replayed updates are not recorded
+	delete(wp.updateCompleteCb, "a")
+	delete(wp.updateCompleteCb, "b")
+	env.replaying = true
+	wp.handleUpdate("approve", "5", nil, nil, &updateResult{})
+	wp.updateValidateCb["5"](&internal.Message{})
+	wp.updateCompleteCb["5"](&internal.Message{})
+	assert.Equal(t, int64(1), mh.counters[RrWorkflowUpdatesAcceptedMetricName+":approve"])
+}
@@ -0,0 +1,59 @@
+package aggregatedpool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.uber.org/zap"
+)
+
+func Test_ReportUpdateProgress(t *testing.T) {
+	cmd := &internal.ReportUpdateProgress{}
+	require.NoError(t, json.Unmarshal([]byte(`{"updateId":"upd-1","progress":{"done":3,"total":10}}`), cmd))
+
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop()}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: cmd}))
+	require.Contains(t, env.memo, "update_progress:upd-1")
+	assert.Equal(t, map[string]any{"done": float64(3), "total": float64(10)}, env.memo["update_progress:upd-1"])
+
+	// null progress removes the key
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.ReportUpdateProgress{UpdateID: "upd-1"}}))
+	require.Contains(t, env.memo, "update_progress:upd-1")
+	assert.Nil(t, env.memo["update_progress:upd-1"])
+
+	assert.Error(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.ReportUpdateProgress{}}))
+}
+
+func Test_ReportProgress(t *testing.T) {
+	cmd := &internal.ReportProgress{}
+	require.NoError(t, json.Unmarshal([]byte(`{"percent":40,"stage":"download","message":"4 of 10 files"}`), cmd))
+
+	env := &fakeEnv{now: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	wp := &Workflow{env: env, log: zap.NewNop()}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: cmd}))
+	require.Contains(t, env.memo, internal.ProgressMemoKey)
+	assert.Equal(t, &internal.WorkflowProgress{
+		Percent:   40,
+		Stage:     "download",
+		Message:   "4 of 10 files",
+		UpdatedAt: env.Now(),
+	}, env.memo[internal.ProgressMemoKey])
+
+	// the unchanged progress is not upserted
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.ReportProgress{Percent: 40, Stage: "download", Message: "4 of 10 files"}}))
+	assert.Equal(t, 1, env.upserts)
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.ReportProgress{Percent: 50, Stage: "download"}}))
+	assert.Equal(t, 2, env.upserts)
+
+	assert.Error(t, wp.handleMessage(&internal.Message{ID: 4, Command: &internal.ReportProgress{Percent: 101}}))
+	assert.Error(t, wp.handleMessage(&internal.Message{ID: 5, Command: &internal.ReportProgress{Percent: -1}}))
+	assert.Equal(t, 2, env.upserts)
+}
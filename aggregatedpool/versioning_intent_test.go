@@ -0,0 +1,37 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/temporal"
+	"go.uber.org/zap"
+)
+
+func Test_ActivityVersioningIntent(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{
+		env:       env,
+		log:       zap.NewNop(),
+		cfg:       &WorkflowConfig{},
+		mq:        queue.NewMessageQueue(seq),
+		canceller: new(canceller.Canceller),
+	}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.ExecuteActivity{Name: "Charge", VersioningIntent: internal.VersioningIntentCompatible}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.ExecuteActivity{Name: "Notify", VersioningIntent: internal.VersioningIntentDefault}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 3, Command: &internal.ExecuteActivity{Name: "Audit"}}))
+	require.Len(t, env.params, 3)
+
+	assert.Equal(t, temporal.VersioningIntentCompatible, env.params[0].VersioningIntent)
+	assert.Equal(t, temporal.VersioningIntentDefault, env.params[1].VersioningIntent)
+	assert.Equal(t, temporal.VersioningIntentUnspecified, env.params[2].VersioningIntent)
+
+	// unknown intent is a protocol error
+	assert.Error(t, wp.handleMessage(&internal.Message{ID: 4, Command: &internal.ExecuteActivity{Name: "Charge", VersioningIntent: "latest"}}))
+	assert.Len(t, env.params, 3)
+}
@@ -0,0 +1,44 @@
+package aggregatedpool
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.uber.org/zap"
+)
+
+// unknownLogLevel warns about the unknown worker log level once per process, not for every entry
+var unknownLogLevel sync.Once
+
+// workerLog writes the worker log entry with the workflow logger, the logger adds the workflow tags and skips the
+// entries during replay. The fields are sorted by the key, the unknown level is written as info.
+func (wp *Workflow) workerLog(cmd *internal.Log) {
+	keys := make([]string, 0, len(cmd.Fields))
+	for k := range cmd.Fields {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	keyvals := make([]any, 0, len(keys)*2)
+	for _, k := range keys {
+		keyvals = append(keyvals, k, cmd.Fields[k])
+	}
+
+	logger := wp.env.GetLogger()
+	switch cmd.Level {
+	case internal.DebugLevel:
+		logger.Debug(cmd.Message, keyvals...)
+	case internal.InfoLevel, "":
+		logger.Info(cmd.Message, keyvals...)
+	case internal.WarnLevel:
+		logger.Warn(cmd.Message, keyvals...)
+	case internal.ErrorLevel:
+		logger.Error(cmd.Message, keyvals...)
+	default:
+		unknownLogLevel.Do(func() {
+			wp.log.Warn("unknown worker log level, written as info", zap.String("level", string(cmd.Level)), zap.Strings("supported", []string{"debug", "info", "warn", "error"}))
+		})
+		logger.Info(cmd.Message, keyvals...)
+	}
+}
@@ -0,0 +1,43 @@
+package aggregatedpool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func Test_WorkerLog(t *testing.T) {
+	env := &fakeEnv{logger: &recordingLogger{}}
+	wp := &Workflow{env: env, log: zap.NewNop()}
+
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.Log{
+		Level:   internal.WarnLevel,
+		Message: "payment retried",
+		Fields:  map[string]any{"order": "42", "attempt": 2},
+	}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.Log{Message: "started"}}))
+
+	// the fields are sorted by the key, the default level is info
+	assert.Equal(t, []string{"warn: payment retried [attempt 2 order 42]", "info: started []"}, env.logger.entries)
+}
+
+func Test_WorkerLogUnknownLevel(t *testing.T) {
+	unknownLogLevel = sync.Once{}
+
+	core, logs := observer.New(zap.WarnLevel)
+	env := &fakeEnv{logger: &recordingLogger{}}
+	wp := &Workflow{env: env, log: zap.New(core)}
+
+	// written as info, the unknown level is reported once
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.Log{Level: "trace", Message: "foo"}}))
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 2, Command: &internal.Log{Level: "notice", Message: "bar"}}))
+
+	assert.Equal(t, []string{"info: foo []", "info: bar []"}, env.logger.entries)
+	require.Equal(t, 1, logs.Len())
+	assert.Equal(t, "trace", logs.All()[0].ContextMap()["level"])
+}
@@ -15,25 +15,21 @@ import (
 
 const tq = "taskqueue"
 
-func TemporalWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo, log *zap.Logger, tc temporalClient.Client, interceptors map[string]api.Interceptor) ([]worker.Worker, error) {
+// TemporalWorkers creates the workers of the task queues of the worker info. The workers without the identity set by the
+// worker options use the configured identity suffixed by the task queue, a random one when it's empty.
+func TemporalWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo, log *zap.Logger, tc temporalClient.Client, interceptors map[string]api.Interceptor, identity string) ([]worker.Worker, error) {
 	workers := make([]worker.Worker, 0, 1)
+	workflows := make(map[string]*internal.WorkflowInfo)
 
 	for i := range wi {
 		log.Debug("worker info", zap.Any("worker_info", wi[i]))
 
-		// just to be sure
-		wi[i].Options.WorkerStopTimeout = 0
-
 		if wi[i].TaskQueue == "" {
 			wi[i].TaskQueue = temporalClient.DefaultNamespace
 		}
 
 		if wi[i].Options.Identity == "" {
-			wi[i].Options.Identity = fmt.Sprintf(
-				"roadrunner:%s:%s",
-				wi[i].TaskQueue,
-				uuid.NewString(),
-			)
+			wi[i].Options.Identity = workerIdentity(identity, wi[i].TaskQueue)
 		}
 
 		// interceptor used here to  the headers
@@ -51,6 +47,8 @@ func TemporalWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo
 				DisableAlreadyRegisteredCheck: false,
 			})
 
+			wi[i].Workflows[j].TaskQueue = wi[i].TaskQueue
+			workflows[wi[i].Workflows[j].Name] = &wi[i].Workflows[j]
 			log.Debug("workflow registered", zap.String(tq, wi[i].TaskQueue), zap.Any("workflow name", wi[i].Workflows[j].Name), zap.Int("versioning_behavior", int(wi[i].Workflows[j].VersioningBehavior)))
 		}
 
@@ -74,7 +72,19 @@ func TemporalWorkers(wDef *Workflow, actDef *Activity, wi []*internal.WorkerInfo
 		workers = append(workers, wrk)
 	}
 
+	// workers are not started yet
+	wDef.workflows = workflows
 	log.Debug("workers initialized", zap.Int("num_workers", len(workers)))
 
 	return workers, nil
 }
+
+// workerIdentity returns the identity of the task queue worker, the workers of the same process poll different task
+// queues, so the identity is suffixed by the task queue.
+func workerIdentity(identity, taskQueue string) string {
+	if identity == "" {
+		return fmt.Sprintf("roadrunner:%s:%s", taskQueue, uuid.NewString())
+	}
+
+	return fmt.Sprintf("%s:%s", identity, taskQueue)
+}
@@ -0,0 +1,19 @@
+package aggregatedpool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WorkerIdentity(t *testing.T) {
+	// the configured identity is used by the workers of every task queue
+	assert.Equal(t, "billing-42:default", workerIdentity("billing-42", "default"))
+	assert.Equal(t, "billing-42:high-priority", workerIdentity("billing-42", "high-priority"))
+
+	// random when not configured
+	id := workerIdentity("", "default")
+	assert.True(t, strings.HasPrefix(id, "roadrunner:default:"))
+	assert.NotEqual(t, id, workerIdentity("", "default"))
+}
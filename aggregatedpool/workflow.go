@@ -3,7 +3,10 @@ package aggregatedpool
 import (
 	"context"
 	"fmt"
+	"math/rand/v2"
+	"slices"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -18,6 +21,7 @@ import (
 	commonpb "go.temporal.io/api/common/v1"
 	enumspb "go.temporal.io/api/enums/v1"
 	temporalClient "go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	bindings "go.temporal.io/sdk/internalbindings"
 	"go.uber.org/zap"
 )
@@ -35,7 +39,7 @@ import (
 */
 
 type Callback func() error
-type LaFn func(ctx context.Context, hdr *commonpb.Header, args *commonpb.Payloads) (*commonpb.Payloads, error)
+type LaFn func(ctx context.Context, hdr *commonpb.Header, args *commonpb.Payloads, dataConverter string) (*commonpb.Payloads, error)
 
 // seqID is global sequence ID
 var seqID uint64 //nolint:gochecknoglobals
@@ -47,7 +51,16 @@ func seq() uint64 {
 type Workflow struct {
 	codec api.Codec
 	pool  api.Pool
+	cfg   *WorkflowConfig
 	rrID  string
+	// shared between all workflows, nil if not limited
+	limiter *execLimiter
+	// sorted by name
+	decorators []api.ContextDecorator
+	// sorted by name, wraps every command handled
+	middleware []api.CommandMiddleware
+	// nil - no checks
+	limits *PayloadLimits
 
 	// LocalActivityFn
 	la LaFn
@@ -62,10 +75,49 @@ type Workflow struct {
 	callbacks    []Callback
 	canceller    *canceller.Canceller
 	inLoop       uint32
+	// local activities scheduled in the current workflow task
+	laCount int
+	// commands handled in the current workflow task
+	taskCommands int
+
+	// registered workflows, used to answer the metadata query
+	workflows map[string]*internal.WorkflowInfo
+	// human-readable details set by the worker
+	currentDetails string
+	// query results published by the worker, answered without the worker round-trip
+	queryState map[string]*commonpb.Payload
+	// deterministic random source, seeded on the first GetRandom command
+	random *rand.Rand
+	// pending Select commands in the registration order
+	selectors []*selector
+	// workflow-scoped semaphores by name
+	semaphores map[string]*semaphore
+	// default activity options set by the SetActivityOptions command, nil - not set
+	activityOptions *bindings.ExecuteActivityOptions
+	// pending delayed signals (command IDs) in the order they were sent
+	delayedSignals []uint64
+	// the last progress reported with the ReportProgress command
+	progress *internal.ReportProgress
+	// the workflow state is rebuilt from the history (replayed from the start), see reportCacheEviction
+	rebuilt bool
+	// cache evictions by the workflow type, shared by the runs
+	evictions *cacheEvictions
+	// optional context fields sent to the worker (ContextFields), shared by the runs, nil - all
+	ctxFields *atomic.Uint32
+	// running workflows, shared by the definitions, nil - not tracked
+	runs *RunRegistry
+	// the non-determinism error logged by the SDK for the run, reported to the worker on close
+	nde atomic.Pointer[string]
+	// the continue-as-new signal was sent to the worker, see WorkflowConfig.ContinueAsNewSignal
+	continueAsNewSignaled bool
+	// the deadlock detection timeout of the worker passed with the workflow task, see resultWait
+	deadlockTimeout time.Duration
 
 	// updates
 	updateCompleteCb map[string]func(res *internal.Message)
 	updateValidateCb map[string]func(res *internal.Message)
+	// workflow worker PIDs which handled the recent updates, recorded with the debug commands enabled
+	updateWorkers []internal.UpdateWorker
 
 	log *zap.Logger
 	mh  temporalClient.MetricsHandler
@@ -75,13 +127,37 @@ type Workflow struct {
 }
 
 // NewWorkflowDefinition ... WorkflowDefinition Constructor
-func NewWorkflowDefinition(codec api.Codec, la LaFn, pool api.Pool, log *zap.Logger) *Workflow {
+func NewWorkflowDefinition(codec api.Codec, la LaFn, pool api.Pool, log *zap.Logger, cfg *WorkflowConfig, decorators []api.ContextDecorator, limits *PayloadLimits) *Workflow {
+	if cfg == nil {
+		cfg = &WorkflowConfig{}
+	}
+
+	var limiter *execLimiter
+	if cfg.MaxInFlight > 0 {
+		limiter = newExecLimiter(cfg.MaxInFlight, cfg.InFlightWaitTimeout)
+	}
+
+	contextFields := new(atomic.Uint32)
+	contextFields.Store(uint32(AllContextFields))
+
+	// stable order, decorators might override each other's keys
+	decorators = slices.Clone(decorators)
+	slices.SortFunc(decorators, func(a, b api.ContextDecorator) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
 	return &Workflow{
-		rrID:  uuid.NewString(),
-		log:   log,
-		la:    la,
-		codec: codec,
-		pool:  pool,
+		rrID:       uuid.NewString(),
+		log:        log,
+		la:         la,
+		codec:      codec,
+		pool:       pool,
+		cfg:        cfg,
+		limiter:    limiter,
+		decorators: decorators,
+		limits:     limits,
+		evictions:  newCacheEvictions(),
+		ctxFields:  contextFields,
 		pldPool: &sync.Pool{
 			New: func() any {
 				return new(payload.Payload)
@@ -103,9 +179,18 @@ func (wp *Workflow) NewWorkflowDefinition() bindings.WorkflowDefinition {
 		updateValidateCb: make(map[string]func(res *internal.Message)),
 		updatesQueue:     map[string]struct{}{},
 		// -- updates
-		pool:  wp.pool,
-		codec: wp.codec,
-		log:   wp.log,
+		workflows:  wp.workflows,
+		cfg:        wp.cfg,
+		limiter:    wp.limiter,
+		decorators: wp.decorators,
+		middleware: wp.middleware,
+		limits:     wp.limits,
+		evictions:  wp.evictions,
+		ctxFields:  wp.ctxFields,
+		runs:       wp.runs,
+		pool:       wp.pool,
+		codec:      wp.codec,
+		log:        wp.log,
 		pldPool: &sync.Pool{
 			New: func() any {
 				return new(payload.Payload)
@@ -126,7 +211,11 @@ func (wp *Workflow) Execute(env bindings.WorkflowEnvironment, header *commonpb.H
 
 	// sequenceID shared for all pool workflows
 	wp.mq = queue.NewMessageQueue(seq)
+	wp.mq.IsReplaying = env.IsReplaying
 	wp.ids = new(registry.IDRegistry)
+	// the first workflow task of a new workflow is not a replay
+	wp.rebuilt = env.IsReplaying()
+	wp.runs.add(env.WorkflowInfo().WorkflowExecution.RunID, wp)
 
 	env.RegisterCancelHandler(wp.handleCancel)
 	env.RegisterSignalHandler(wp.handleSignal)
@@ -254,6 +343,10 @@ func (wp *Workflow) Execute(env bindings.WorkflowEnvironment, header *commonpb.H
 		stwfcmd.SearchAttributes = tsaParsed
 	}
 
+	// the values supplied by the starter, the search attributes are always encoded with the default data converter
+	stwfcmd.UntypedSearchAttributes = wp.decodeFields("search_attributes", converter.GetDefaultDataConverter(), env.WorkflowInfo().SearchAttributes.GetIndexedFields())
+	stwfcmd.Memo = wp.decodeFields("memo", env.GetDataConverter(), env.WorkflowInfo().Memo.GetFields())
+
 	var lastCompletion = bindings.GetLastCompletionResult(env)
 	if lastCompletion != nil && len(lastCompletion.Payloads) != 0 {
 		if input == nil {
@@ -283,7 +376,11 @@ func (wp *Workflow) OnWorkflowTaskStarted(t time.Duration) {
 		atomic.StoreUint32(&wp.inLoop, 0)
 	}()
 
-	wp.log.Debug("workflow task started", zap.Duration("time", t))
+	wp.log.Debug("workflow task started", zap.Duration("deadlock_detection_timeout", t))
+	wp.deadlockTimeout = t
+	wp.laCount = 0
+	wp.taskCommands = 0
+	wp.reportCacheEviction()
 
 	var err error
 	// do not copy
@@ -296,6 +393,8 @@ func (wp *Workflow) OnWorkflowTaskStarted(t time.Duration) {
 
 	wp.callbacks = nil
 
+	wp.signalContinueAsNew()
+
 	// handle updates
 	if len(wp.updatesQueue) > 0 {
 		for k := range wp.updatesQueue {
@@ -322,7 +421,8 @@ func (wp *Workflow) OnWorkflowTaskStarted(t time.Duration) {
 				panic(fmt.Sprintf("undefined response: %s", msg.Command.(*internal.UndefinedResponse).Message))
 			}
 
-			err = wp.handleMessage(msg)
+			wp.taskCommands++
+			err = wp.handleCommand(msg)
 		}
 
 		if err != nil {
@@ -375,6 +475,10 @@ func (wp *Workflow) Close() {
 		delete(wp.updateCompleteCb, k)
 	}
 
+	wp.runs.remove(wp.env.WorkflowInfo().WorkflowExecution.RunID)
+	// the failed task drops the workflow state, the worker is told why before the destroy
+	wp.reportNonDeterminism()
+
 	// send destroy command
 	_, _ = wp.runCommand(internal.DestroyWorkflow{RunID: wp.env.WorkflowInfo().WorkflowExecution.RunID}, nil, wp.header)
 	// flush queue
@@ -0,0 +1,58 @@
+package aggregatedpool
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/workflow"
+	"go.uber.org/zap"
+)
+
+func Test_GetWorkflowInfo(t *testing.T) {
+	info := &workflow.Info{
+		TaskQueueName: "default",
+		Attempt:       3,
+		CronSchedule:  "@hourly",
+		Namespace:     "billing",
+	}
+	info.WorkflowType.Name = "OrderWorkflow"
+	info.WorkflowExecution.ID = "order-1"
+	info.WorkflowExecution.RunID = "run-1"
+
+	env := &fakeEnv{info: info}
+	codec := &recordingCodec{}
+	wp := &Workflow{
+		env:     env,
+		log:     zap.NewNop(),
+		mq:      queue.NewMessageQueue(seq),
+		codec:   codec,
+		pool:    &stoppedPool{},
+		pldPool: &sync.Pool{New: func() any { return new(payload.Payload) }},
+	}
+
+	// the response is flushed to the worker right away, the stopped worker fails the exchange
+	assert.Error(t, wp.handleMessage(&internal.Message{ID: 1, Command: &internal.GetWorkflowInfo{}}))
+	require.Len(t, codec.sent, 1)
+	assert.Equal(t, uint64(1), codec.sent[0].ID)
+
+	// as received by the worker
+	var got workflow.Info
+	require.NoError(t, env.GetDataConverter().FromPayloads(codec.sent[0].Payloads, &got))
+	assert.Equal(t, "OrderWorkflow", got.WorkflowType.Name)
+	assert.Equal(t, "order-1", got.WorkflowExecution.ID)
+	assert.Equal(t, "run-1", got.WorkflowExecution.RunID)
+	assert.Equal(t, int32(3), got.Attempt)
+	assert.Equal(t, "@hourly", got.CronSchedule)
+	assert.Equal(t, "billing", got.Namespace)
+
+	name, err := internal.CommandName(internal.GetWorkflowInfo{})
+	require.NoError(t, err)
+	cmd, err := internal.InitCommand(name)
+	require.NoError(t, err)
+	assert.IsType(t, &internal.GetWorkflowInfo{}, cmd)
+}
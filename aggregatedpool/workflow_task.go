@@ -0,0 +1,50 @@
+package aggregatedpool
+
+import (
+	"time"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/workflow"
+)
+
+// forceNewWorkflowTaskDelay is the shortest timer, the zero timer is resolved by the SDK without a command
+const forceNewWorkflowTaskDelay = time.Millisecond
+
+// forceNewWorkflowTask resolves the command with true in the next workflow task. The pending responses are flushed
+// first, the timer completes the current workflow task with the commands issued so far. When the command is the only
+// one in the current workflow task, there is nothing to checkpoint: it's resolved immediately with false.
+func (wp *Workflow) forceNewWorkflowTask(id uint64) error {
+	if wp.taskCommands <= 1 && len(wp.mq.Messages()) == 0 {
+		result, err := wp.env.GetDataConverter().ToPayloads(false)
+		if err != nil {
+			return err
+		}
+
+		wp.mq.PushResponse(id, result)
+		return wp.flushQueue()
+	}
+
+	err := wp.flushQueue()
+	if err != nil {
+		return err
+	}
+
+	callback := wp.createCallback(id, "ForceNewWorkflowTask")
+	timerID := wp.env.NewTimer(forceNewWorkflowTaskDelay, workflow.TimerOptions{Summary: "force new workflow task"}, func(_ *commonpb.Payloads, err error) {
+		if err != nil {
+			callback(nil, err)
+			return
+		}
+
+		callback(wp.env.GetDataConverter().ToPayloads(true))
+	})
+
+	wp.canceller.Register(id, func() error {
+		if timerID != nil {
+			wp.env.RequestCancelTimer(*timerID)
+		}
+		return nil
+	})
+
+	return nil
+}
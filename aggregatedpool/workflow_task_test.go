@@ -0,0 +1,38 @@
+package aggregatedpool
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/canceller"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/queue"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+)
+
+func Test_ForceNewWorkflowTask(t *testing.T) {
+	env := &fakeEnv{}
+	wp := &Workflow{env: env, log: zap.NewNop(), mq: queue.NewMessageQueue(seq), canceller: new(canceller.Canceller)}
+
+	// an activity was scheduled in the same workflow task
+	wp.taskCommands = 2
+	require.NoError(t, wp.handleMessage(&internal.Message{ID: 7, Command: &internal.ForceNewWorkflowTask{}}))
+
+	// the timer completes the workflow task, the command is resolved in the next one
+	require.Len(t, env.timers, 1)
+	assert.Equal(t, forceNewWorkflowTaskDelay, env.timers[0])
+	assert.Empty(t, wp.mq.Messages())
+
+	env.timerCallbacks[0](nil, nil)
+	require.Len(t, wp.callbacks, 1)
+	require.NoError(t, wp.callbacks[0]())
+
+	require.Len(t, wp.mq.Messages(), 1)
+	assert.Equal(t, uint64(7), wp.mq.Messages()[0].ID)
+
+	var forced bool
+	require.NoError(t, converter.GetDefaultDataConverter().FromPayloads(wp.mq.Messages()[0].Payloads, &forced))
+	assert.True(t, forced)
+}
@@ -9,7 +9,11 @@ import (
 	"github.com/roadrunner-server/pool/state/process"
 	"github.com/roadrunner-server/pool/worker"
 	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/interceptor"
+	"go.temporal.io/sdk/workflow"
 	"go.uber.org/zap"
 
 	staticPool "github.com/roadrunner-server/pool/pool/static_pool"
@@ -20,6 +24,52 @@ type Interceptor interface {
 	Name() string
 }
 
+// ContextDecorator enriches the context sent to the workflow worker with every batch of commands (context meta).
+// Decorate is called for the new and replayed workflow tasks, so it must be deterministic:
+// use only the workflow info (memo, search attributes) and the workflow header.
+type ContextDecorator interface {
+	Decorate(info *workflow.Info, header *commonpb.Header, meta map[string]string)
+	Name() string
+}
+
+// Command is the command received from the workflow worker as seen by the CommandMiddleware.
+type Command struct {
+	// ID of the command, the result is sent to the worker with the same ID.
+	ID uint64
+	// Name of the command, e.g. ExecuteActivity.
+	Name string
+	// Payloads are the command arguments.
+	Payloads *commonpb.Payloads
+	// Header of the command.
+	Header *commonpb.Header
+}
+
+// CommandMiddleware wraps the handling of every command received from the workflow worker (timing, auth checks,
+// feature flags). The middleware are ordered by name: Before is called in that order, After in the reverse one.
+// Before returning an error stops the chain, the command is not handled and the workflow task fails with the error.
+// The commands are handled for the new and replayed workflow tasks, so the middleware must be deterministic:
+// the decision should depend only on the workflow info and the command.
+type CommandMiddleware interface {
+	Before(info *workflow.Info, cmd *Command) error
+	// After receives the handling error, nil on success. Called only for the middleware whose Before succeeded.
+	After(info *workflow.Info, cmd *Command, err error)
+	Name() string
+}
+
+// TemporalClient is the Temporal client of the plugin with the data converter and namespace it was created with.
+type TemporalClient struct {
+	Client        client.Client
+	DataConverter converter.DataConverter
+	Namespace     string
+}
+
+// ClientProvider is the extension point for the custom RPCs of the sibling plugins calling the Temporal APIs not
+// wrapped by the plugin: collect it (implemented by the temporal plugin) to reuse the configured client.
+type ClientProvider interface {
+	// TemporalClient returns an error until the plugin is connected to the Temporal server (Serve).
+	TemporalClient() (*TemporalClient, error)
+}
+
 type Pool interface {
 	// Workers return a worker list associated with the pool.
 	Workers() (workers []*worker.Process)
@@ -42,6 +92,9 @@ type Codec interface {
 	Decode(pld *payload.Payload, msg *[]*internal.Message) error
 	// DecodeWorkerInfo decode a call to get a worker info ID=0 (initial)
 	DecodeWorkerInfo(p *payload.Payload, wi *[]*internal.WorkerInfo) error
+	// Release returns the buffers of the payload encoded by Encode for reuse, the payload body must not be used after that.
+	// Called only once the worker responded to the payload, the pool might still write it after the exec timeout.
+	Release(p *payload.Payload)
 }
 
 // Informer used to get workers from a particular plugin or set of plugins
@@ -1,13 +1,33 @@
 package canceller
 
 import (
+	"slices"
 	"sync"
 )
 
 type Cancellable func() error
 
+// scope groups commands and nested scopes cancelled together
+type scope struct {
+	parent   string
+	ids      map[uint64]struct{}
+	children map[string]struct{}
+	// disconnected scope is not cancelled together with the parent
+	disconnected bool
+}
+
 type Canceller struct {
 	ids sync.Map
+
+	mu sync.Mutex
+	// scope id -> scope
+	scopes map[string]*scope
+	// command id -> scope id
+	idScope map[uint64]string
+	// name -> command ids in the registration order
+	names map[string][]uint64
+	// command id -> name
+	idName map[uint64]string
 }
 
 func (c *Canceller) Register(id uint64, cancel Cancellable) {
@@ -16,6 +36,7 @@ func (c *Canceller) Register(id uint64, cancel Cancellable) {
 
 func (c *Canceller) Discard(id uint64) {
 	c.ids.Delete(id)
+	c.forget(id)
 }
 
 func (c *Canceller) Cancel(ids ...uint64) error {
@@ -26,6 +47,8 @@ func (c *Canceller) Cancel(ids ...uint64) error {
 			continue
 		}
 
+		c.forget(id)
+
 		err = cancel.(Cancellable)()
 		if err != nil {
 			return err
@@ -34,3 +57,170 @@ func (c *Canceller) Cancel(ids ...uint64) error {
 
 	return nil
 }
+
+// Scope adds the commands to the scope, the scope is created if not exists.
+// Non-empty parent makes the scope nested, it is cancelled together with the parent.
+func (c *Canceller) Scope(id string, parent string, ids ...uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.scopes == nil {
+		c.scopes = make(map[string]*scope)
+		c.idScope = make(map[uint64]string)
+	}
+
+	s := c.scope(id)
+	if parent != "" && parent != id && s.parent == "" && !s.disconnected {
+		s.parent = parent
+		c.scope(parent).children[id] = struct{}{}
+	}
+
+	for _, cid := range ids {
+		// command belongs to a single scope
+		if prev, ok := c.idScope[cid]; ok {
+			delete(c.scopes[prev].ids, cid)
+		}
+
+		s.ids[cid] = struct{}{}
+		c.idScope[cid] = id
+	}
+}
+
+// Disconnect detaches the scope from its parent, the scope (created if not exists) is not cancelled together with
+// the parent anymore, e.g. to run the cleanup after the cancellation. It's cancelled with CancelScope using its own id,
+// the nested scopes are cancelled together with it.
+func (c *Canceller) Disconnect(id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.scopes == nil {
+		c.scopes = make(map[string]*scope)
+		c.idScope = make(map[uint64]string)
+	}
+
+	s := c.scope(id)
+	s.disconnected = true
+	if p, ok := c.scopes[s.parent]; ok {
+		delete(p.children, id)
+	}
+	s.parent = ""
+}
+
+// Name associates the command with the logical name, several commands might share the same name.
+func (c *Canceller) Name(name string, id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.names == nil {
+		c.names = make(map[string][]uint64)
+		c.idName = make(map[uint64]string)
+	}
+
+	c.names[name] = append(c.names[name], id)
+	c.idName[id] = name
+}
+
+// CancelName cancels all the pending commands registered with the name in the registration order.
+func (c *Canceller) CancelName(name string) error {
+	c.mu.Lock()
+	ids := c.names[name]
+	c.mu.Unlock()
+
+	// the names are forgotten by Cancel
+	return c.Cancel(slices.Clone(ids)...)
+}
+
+// CancelScope cancels all commands registered under the scope and its nested scopes in the ascending order of their
+// IDs, the same order on replay.
+func (c *Canceller) CancelScope(id string) error {
+	c.mu.Lock()
+	s, ok := c.scopes[id]
+	if !ok {
+		c.mu.Unlock()
+		return nil
+	}
+
+	if p, ok := c.scopes[s.parent]; ok {
+		delete(p.children, id)
+	}
+
+	ids := c.collect(id, nil)
+	c.mu.Unlock()
+
+	slices.Sort(ids)
+
+	return c.Cancel(ids...)
+}
+
+// CancelAll cancels all the registered commands in the ascending order of their IDs, the order doesn't depend on
+// the registration timing, so it's the same on replay.
+func (c *Canceller) CancelAll() error {
+	var ids []uint64
+	c.ids.Range(func(key, _ any) bool {
+		ids = append(ids, key.(uint64))
+		return true
+	})
+
+	slices.Sort(ids)
+
+	return c.Cancel(ids...)
+}
+
+// scope returns existing or creates a new scope, should be called under the lock
+func (c *Canceller) scope(id string) *scope {
+	s, ok := c.scopes[id]
+	if !ok {
+		s = &scope{
+			ids:      make(map[uint64]struct{}),
+			children: make(map[string]struct{}),
+		}
+		c.scopes[id] = s
+	}
+
+	return s
+}
+
+// collect removes the scope with the nested scopes and returns their commands (unordered), should be called under
+// the lock
+func (c *Canceller) collect(id string, ids []uint64) []uint64 {
+	s, ok := c.scopes[id]
+	if !ok {
+		return ids
+	}
+
+	delete(c.scopes, id)
+
+	for cid := range s.ids {
+		ids = append(ids, cid)
+	}
+
+	for child := range s.children {
+		ids = c.collect(child, ids)
+	}
+
+	return ids
+}
+
+// forget removes the command from its scope and name
+func (c *Canceller) forget(id uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if name, ok := c.idName[id]; ok {
+		delete(c.idName, id)
+		c.names[name] = slices.DeleteFunc(c.names[name], func(cid uint64) bool { return cid == id })
+		if len(c.names[name]) == 0 {
+			delete(c.names, name)
+		}
+	}
+
+	sid, ok := c.idScope[id]
+	if !ok {
+		return
+	}
+
+	delete(c.idScope, id)
+	if s, ok := c.scopes[sid]; ok {
+		delete(s.ids, id)
+	}
+}
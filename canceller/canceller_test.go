@@ -31,3 +31,136 @@ func Test_CancellerListenerDiscarded(t *testing.T) {
 	c.Discard(1)
 	assert.NoError(t, c.Cancel(1))
 }
+
+func Test_CancellerScope(t *testing.T) {
+	c := &Canceller{}
+
+	var cancelled []uint64
+	for i := uint64(1); i <= 5; i++ {
+		c.Register(i, func() error {
+			cancelled = append(cancelled, i)
+			return nil
+		})
+	}
+
+	c.Scope("parent", "", 4, 1)
+	c.Scope("child", "parent", 3, 2)
+
+	// completed command is removed from the scope
+	c.Discard(3)
+
+	// in the IDs order, the same on replay
+	assert.NoError(t, c.CancelScope("parent"))
+	assert.Equal(t, []uint64{1, 2, 4}, cancelled)
+
+	// already cancelled
+	assert.NoError(t, c.CancelScope("child"))
+	assert.NoError(t, c.Cancel(5))
+	assert.Equal(t, []uint64{1, 2, 4, 5}, cancelled)
+}
+
+func Test_CancellerNestedScope(t *testing.T) {
+	c := &Canceller{}
+
+	var cancelled []uint64
+	for i := uint64(1); i <= 2; i++ {
+		c.Register(i, func() error {
+			cancelled = append(cancelled, i)
+			return nil
+		})
+	}
+
+	c.Scope("parent", "", 1)
+	c.Scope("child", "parent", 2)
+
+	// nested scope doesn't cancel the parent
+	assert.NoError(t, c.CancelScope("child"))
+	assert.Equal(t, []uint64{2}, cancelled)
+
+	assert.NoError(t, c.CancelScope("parent"))
+	assert.Equal(t, []uint64{2, 1}, cancelled)
+}
+
+func Test_CancellerName(t *testing.T) {
+	c := &Canceller{}
+
+	var cancelled []uint64
+	for i := uint64(1); i <= 4; i++ {
+		c.Register(i, func() error {
+			cancelled = append(cancelled, i)
+			return nil
+		})
+	}
+
+	// duplicate names are cancelled together in the registration order
+	c.Name("timeout", 3)
+	c.Name("timeout", 1)
+	c.Name("timeout", 2)
+	c.Name("reminder", 4)
+
+	// fired timer is removed from the name
+	c.Discard(1)
+
+	assert.NoError(t, c.CancelName("timeout"))
+	assert.Equal(t, []uint64{3, 2}, cancelled)
+
+	// already cancelled or unknown
+	assert.NoError(t, c.CancelName("timeout"))
+	assert.NoError(t, c.CancelName("foo"))
+
+	assert.NoError(t, c.Cancel(4))
+	assert.NoError(t, c.CancelName("reminder"))
+	assert.Equal(t, []uint64{3, 2, 4}, cancelled)
+}
+
+func Test_CancellerDisconnectedScope(t *testing.T) {
+	c := &Canceller{}
+
+	var cancelled []uint64
+	for i := uint64(1); i <= 4; i++ {
+		c.Register(i, func() error {
+			cancelled = append(cancelled, i)
+			return nil
+		})
+	}
+
+	c.Scope("root", "", 1)
+	c.Scope("child", "root", 2)
+	// detached from the parent, the later parent is ignored as well
+	c.Scope("cleanup", "root", 3)
+	c.Disconnect("cleanup")
+	c.Scope("cleanup", "root", 4)
+
+	assert.NoError(t, c.CancelScope("root"))
+	assert.Equal(t, []uint64{1, 2}, cancelled)
+
+	assert.NoError(t, c.CancelScope("cleanup"))
+	assert.Equal(t, []uint64{1, 2, 3, 4}, cancelled)
+}
+
+func Test_CancellerCancelAll(t *testing.T) {
+	c := &Canceller{}
+
+	var cancelled []uint64
+	for _, id := range []uint64{7, 2, 9, 4} {
+		c.Register(id, func() error {
+			cancelled = append(cancelled, id)
+			return nil
+		})
+	}
+	c.Scope("main", "", 9)
+	c.Name("timeout", 4)
+
+	// completed command
+	c.Discard(2)
+
+	// the same order regardless of the registration one
+	assert.NoError(t, c.CancelAll())
+	assert.Equal(t, []uint64{4, 7, 9}, cancelled)
+
+	// nothing left
+	assert.NoError(t, c.CancelAll())
+	assert.NoError(t, c.CancelScope("main"))
+	assert.NoError(t, c.CancelName("timeout"))
+	assert.Equal(t, []uint64{4, 7, 9}, cancelled)
+}
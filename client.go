@@ -0,0 +1,25 @@
+package rrtemporal
+
+import (
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/api"
+)
+
+// TemporalClient returns the Temporal client with the active data converter and namespace (api.ClientProvider),
+// the extension point for the custom RPCs of the sibling plugins. The client is shared, it must not be closed.
+func (p *Plugin) TemporalClient() (*api.TemporalClient, error) {
+	const op = errors.Op("temporal_client")
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.temporal.client == nil {
+		return nil, errors.E(op, errors.Str("the plugin is not connected to the Temporal server yet"))
+	}
+
+	return &api.TemporalClient{
+		Client:        p.temporal.client,
+		DataConverter: p.temporal.dc,
+		Namespace:     p.config.Namespace,
+	}, nil
+}
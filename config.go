@@ -7,14 +7,72 @@ import (
 
 	"github.com/roadrunner-server/errors"
 	"github.com/roadrunner-server/pool/pool"
+	"github.com/temporalio/roadrunner-temporal/v5/aggregatedpool"
+	"github.com/temporalio/roadrunner-temporal/v5/dataconverter"
+	"go.uber.org/zap/zapcore"
 )
 
 // Config of the temporal client and dependent services.
 type Config struct {
+	// Metrics configures the SDK metrics handler (client, workers and RR pool gauges), disabled when not set
 	Metrics                *Metrics     `mapstructure:"metrics"`
 	Activities             *pool.Config `mapstructure:"activities"`
 	TLS                    *TLS         `mapstructure:"tls, omitempty"`
 	DisableActivityWorkers bool         `mapstructure:"disable_activity_workers"`
+	// SearchAttributes declares search attributes expected to be registered in the namespace
+	SearchAttributes *SearchAttributes `mapstructure:"search_attributes"`
+	// Workers overrides worker options sent by the PHP worker, key is the task queue name
+	Workers map[string]*WorkerOptions `mapstructure:"workers"`
+	// WorkflowTaskQueues routes the workflow types to the dedicated task queues, key is the task queue name.
+	// The workers for the task queues not registered by the PHP worker are created with the options of the original one.
+	WorkflowTaskQueues map[string][]string `mapstructure:"workflow_task_queues"`
+	// Connection tunes the gRPC connection to the Temporal server
+	Connection *Connection `mapstructure:"connection"`
+	// Headers are the gRPC headers sent with every request to the Temporal server, values support ${ENV} substitution
+	Headers map[string]string `mapstructure:"headers"`
+	// Workflows tunes the workflow commands processing
+	Workflows *aggregatedpool.WorkflowConfig `mapstructure:"workflows"`
+	// Logs configures the logger of the workflow and activity handlers
+	Logs *Logs `mapstructure:"logs"`
+	// PayloadLimits checks the payloads size produced by the worker before sending them to the server, disabled when not set
+	PayloadLimits *aggregatedpool.PayloadLimits `mapstructure:"payload_limits"`
+	// DataConverter configures the encoding of the values converted on the RR side
+	DataConverter *DataConverter `mapstructure:"data_converter"`
+
+	// CodecBuffers reuses the buffers of the frames sent to the workers, disabled when not set
+	CodecBuffers *CodecBuffers `mapstructure:"codec_buffers"`
+
+	// OptionsCompression compresses the large command options sent to the workers supporting it, disabled when not set
+	OptionsCompression *OptionsCompression `mapstructure:"options_compression"`
+
+	// HeaderDeduplication sends the large headers once per frame to the workers supporting it, disabled when not set
+	HeaderDeduplication *HeaderDeduplication `mapstructure:"header_deduplication"`
+
+	// Readiness makes the plugin ready only when the Temporal workers are polling their task queues
+	Readiness *Readiness `mapstructure:"readiness"`
+
+	// ResetFailure configures the behavior when the workers can't be restarted after a worker stopped
+	ResetFailure *ResetFailure `mapstructure:"reset_failure"`
+
+	// StartRetry retries the initial start of the workers (e.g. the Temporal server is not ready yet), disabled when not set
+	StartRetry *StartRetry `mapstructure:"start_retry"`
+
+	// HeartbeatMonitor reports the age of the last heartbeat of the running activities, disabled when not set
+	HeartbeatMonitor *HeartbeatMonitor `mapstructure:"heartbeat_monitor"`
+
+	// WorkerInfoCache reuses the worker info between the pools restarts while the worker code is not changed,
+	// disabled when not set
+	WorkerInfoCache *WorkerInfoCache `mapstructure:"worker_info_cache"`
+
+	// ResetOverlap enables the rolling pools replacement (ReplacePools RPC): the new pools start polling and both
+	// old and new workers process the tasks during the overlap, then the old pools are drained and destroyed.
+	// Zero replaces the pools in place.
+	ResetOverlap time.Duration `mapstructure:"reset_overlap"`
+
+	// Identity of the client and workers shown in the Temporal UI, supports {hostname}, {pid} and ${ENV} substitution.
+	// The workers use it suffixed by the task queue ({identity}:{task queue}) unless the worker options set their own.
+	// Default: {hostname}-{pid}
+	Identity string `mapstructure:"identity"`
 
 	Address   string `mapstructure:"address"`
 	Namespace string `mapstructure:"namespace"`
@@ -24,6 +82,10 @@ type Config struct {
 const (
 	MetricsTypeSummary string = "summary"
 
+	// minKeepAliveTime is the default Temporal frontend keep-alive enforcement policy (frontend.keepAliveMinTime),
+	// pings sent more often are answered with GOAWAY
+	minKeepAliveTime = time.Second * 10
+
 	// metrics types
 
 	driverPrometheus string = "prometheus"
@@ -80,6 +142,118 @@ type Metrics struct {
 	Statsd     *Statsd     `mapstructure:"statsd"`
 }
 
+// SearchAttributes describes search attributes the workflows rely on.
+type SearchAttributes struct {
+	// Expected maps the attribute name to its type: bool, float64, int64, keyword, keyword_list, string, datetime
+	Expected map[string]string `mapstructure:"expected"`
+	// FailOnMismatch stops the plugin if an attribute is missing or registered with another type.
+	// By default, mismatches are only logged.
+	FailOnMismatch bool `mapstructure:"fail_on_mismatch"`
+}
+
+// Connection tunes the gRPC connection to the Temporal server.
+type Connection struct {
+	// KeepAliveTime is the interval after which the client pings the server if there is no activity.
+	// Enables keep-alive check when set, should not be lower than 10s.
+	KeepAliveTime time.Duration `mapstructure:"keep_alive_time"`
+	// KeepAliveTimeout is the time to wait for the ping ack before the connection is closed.
+	KeepAliveTimeout time.Duration `mapstructure:"keep_alive_timeout"`
+	// KeepAlivePermitWithoutStream allows pings even if there are no active streams.
+	KeepAlivePermitWithoutStream bool `mapstructure:"keep_alive_permit_without_stream"`
+	// MaxSendMessageSize is the maximum message size in bytes the client can send.
+	MaxSendMessageSize int `mapstructure:"max_send_message_size"`
+	// MaxReceiveMessageSize is the maximum message size in bytes the client can receive.
+	MaxReceiveMessageSize int `mapstructure:"max_receive_message_size"`
+	// DialTimeout is the time to wait for the connection to the server, default: 1m.
+	DialTimeout time.Duration `mapstructure:"dial_timeout"`
+}
+
+// DataConverter configures the data converter.
+type DataConverter struct {
+	// Encoding is the preferred payload encoding: json/protobuf (default), binary/protobuf, json/plain or binary/msgpack.
+	// Payloads with other encodings are still decoded using the payload metadata.
+	Encoding string `mapstructure:"encoding"`
+	// ProtoJSON controls the JSON encoding of the protobuf messages, should match the PHP side settings.
+	ProtoJSON *dataconverter.ProtoJSONOptions `mapstructure:"proto_json"`
+}
+
+// CodecBuffers configures the pool of the encoded frame buffers.
+type CodecBuffers struct {
+	// Size is the initial capacity of a buffer in bytes, default: 4KB.
+	Size int `mapstructure:"size"`
+	// MaxSize is the capacity of the buffers kept in the pool, larger buffers (huge payloads) are left to GC, default: 1MB.
+	MaxSize int `mapstructure:"max_size"`
+}
+
+// OptionsCompression configures the gzip compression of the command options (JSON) sent to the workers.
+// Negotiated on the handshake: the options are compressed only if the worker advertised the OptionsCompression flag.
+type OptionsCompression struct {
+	// Threshold is the size of the options in bytes after which they are compressed, default: 4KB.
+	Threshold int `mapstructure:"threshold"`
+}
+
+// HeaderDeduplication configures the references to the repeated headers of the messages sent to the workers: the header
+// is sent with the first message of the frame, the next messages with the same header reference it.
+// Negotiated on the handshake: the headers are referenced only if the worker advertised the HeaderDeduplication flag.
+type HeaderDeduplication struct {
+	// Threshold is the size of the header in bytes after which it's referenced, default: 1KB.
+	Threshold int `mapstructure:"threshold"`
+}
+
+// WorkerInfoCache skips the worker info round-trip (the registered workflows, activities, queries, signals and updates)
+// when the pools are restarted or replaced and the worker code hash is not changed.
+type WorkerInfoCache struct {
+	// Files are hashed together with the worker command to detect the code changes, e.g. composer.lock or the build
+	// version file. Required.
+	Files []string `mapstructure:"files"`
+}
+
+// HeartbeatMonitor checks the heartbeats of the activities running in the workers.
+type HeartbeatMonitor struct {
+	// Interval of the checks, default: 10s.
+	Interval time.Duration `mapstructure:"interval"`
+	// Threshold is the part of the activity heartbeat timeout after which the activity is reported as not heartbeating,
+	// default: 0.5.
+	Threshold float64 `mapstructure:"threshold"`
+}
+
+// Logs configures the logger of the workflow and activity handlers.
+type Logs struct {
+	// Level of the handlers logger, can't be lower than the plugin log level.
+	Level string `mapstructure:"level"`
+	// SampleDebug logs only 1 of N debug entries, 0 or 1 - log all entries.
+	SampleDebug int `mapstructure:"sample_debug"`
+	// Fields are added to every handler log line (e.g. deployment, region).
+	Fields map[string]string `mapstructure:"fields"`
+}
+
+// WorkerOptions overrides options of the Temporal worker for a particular task queue.
+type WorkerOptions struct {
+	// DisableEagerActivities forces all activities scheduled by the workflows on this task queue to go through the
+	// task queue instead of being executed eagerly on the same worker. Eager execution saves a round-trip to the server
+	// (lower latency), but it can overload a worker which is processing workflows on a busy task queue.
+	// Disabling it spreads activities evenly across all pollers for the price of higher schedule-to-start latency.
+	DisableEagerActivities bool `mapstructure:"disable_eager_activities"`
+	// DeadlockDetectionTimeout is the time to wait for the PHP worker response during the workflow task, when longer
+	// than the default 10s wait. Increase it when the PHP worker legitimately needs more time to process a workflow
+	// task (e.g. GC pauses). Ignored when workflows.exec_timeout is set.
+	DeadlockDetectionTimeout time.Duration `mapstructure:"deadlock_detection_timeout"`
+	// WorkerStopTimeout is the time to wait for the in-flight activities to complete on the worker stop (pools reset
+	// or replacement) before the pools are destroyed, default is 10s.
+	WorkerStopTimeout time.Duration `mapstructure:"worker_stop_timeout"`
+	// MaxConcurrentWorkflowTaskPollers is the number of workflow task pollers, the SDK default is 2.
+	// Every poller is a long-poll request counted against the namespace RPS limit on the server.
+	MaxConcurrentWorkflowTaskPollers int `mapstructure:"max_concurrent_workflow_task_pollers"`
+	// MaxConcurrentActivityTaskPollers is the number of activity task pollers, the SDK default is 2.
+	MaxConcurrentActivityTaskPollers int `mapstructure:"max_concurrent_activity_task_pollers"`
+	// WorkerActivitiesPerSecond limits the activities started per second by this worker, 0 - unlimited.
+	WorkerActivitiesPerSecond float64 `mapstructure:"worker_activities_per_second"`
+	// TaskQueueActivitiesPerSecond limits the activities started per second on the task queue by all the workers,
+	// enforced by the server. The last started worker wins if the workers are configured differently, 0 - unlimited.
+	// Use it to keep the whole fleet under a downstream limit, the per-worker limit multiplies with the number of workers.
+	TaskQueueActivitiesPerSecond float64 `mapstructure:"task_queue_activities_per_second"`
+}
+
 type TLS struct {
 	Key        string         `mapstructure:"key"`
 	Cert       string         `mapstructure:"cert"`
@@ -100,6 +274,20 @@ func (c *Config) InitDefault() error {
 
 	c.Activities.InitDefaults()
 
+	if c.Workflows == nil {
+		c.Workflows = &aggregatedpool.WorkflowConfig{}
+	}
+
+	c.Workflows.InitDefaults()
+	err := c.Workflows.Validate()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if c.PayloadLimits != nil {
+		c.PayloadLimits.InitDefaults()
+	}
+
 	if c.CacheSize == 0 {
 		c.CacheSize = 10000
 	}
@@ -108,6 +296,8 @@ func (c *Config) InitDefault() error {
 		c.Namespace = "default"
 	}
 
+	c.Identity = resolveIdentity(c.Identity)
+
 	if c.Metrics != nil {
 		if c.Metrics.Driver == "" {
 			c.Metrics.Driver = driverPrometheus
@@ -139,6 +329,181 @@ func (c *Config) InitDefault() error {
 		}
 	}
 
+	if c.SearchAttributes != nil {
+		for name, tp := range c.SearchAttributes.Expected {
+			if _, ok := indexedValueType(tp); !ok {
+				return errors.E(op, errors.Errorf("search attribute '%s' has unknown type '%s'", name, tp))
+			}
+		}
+	}
+
+	if c.Connection != nil {
+		if c.Connection.KeepAliveTime > 0 && c.Connection.KeepAliveTime < minKeepAliveTime {
+			return errors.E(op, errors.Errorf("keep_alive_time should not be lower than %s, got: %s", minKeepAliveTime, c.Connection.KeepAliveTime))
+		}
+
+		if c.Connection.KeepAliveTimeout < 0 || c.Connection.DialTimeout < 0 {
+			return errors.E(op, errors.Str("keep_alive_timeout and dial_timeout should be positive"))
+		}
+
+		if c.Connection.MaxSendMessageSize < 0 || c.Connection.MaxReceiveMessageSize < 0 {
+			return errors.E(op, errors.Str("max_send_message_size and max_receive_message_size should be positive"))
+		}
+
+		if c.Connection.DialTimeout == 0 {
+			c.Connection.DialTimeout = time.Minute
+		}
+	}
+
+	if c.Readiness != nil && c.Readiness.Timeout == 0 {
+		c.Readiness.Timeout = time.Minute
+	}
+
+	if c.ResetFailure == nil {
+		c.ResetFailure = &ResetFailure{}
+	}
+
+	switch c.ResetFailure.Policy {
+	case "":
+		c.ResetFailure.Policy = ResetFailureStop
+	case ResetFailureStop, ResetFailureDegraded:
+	default:
+		return errors.E(op, errors.Errorf("unknown reset_failure.policy: %s, supported: stop, degraded", c.ResetFailure.Policy))
+	}
+
+	if c.ResetFailure.InitialInterval == 0 {
+		c.ResetFailure.InitialInterval = time.Second
+	}
+
+	if c.ResetFailure.MaxInterval == 0 {
+		c.ResetFailure.MaxInterval = time.Minute
+	}
+
+	if c.ResetFailure.InitialInterval < 0 || c.ResetFailure.MaxInterval < c.ResetFailure.InitialInterval {
+		return errors.E(op, errors.Str("reset_failure.initial_interval should be positive and not greater than reset_failure.max_interval"))
+	}
+
+	if c.StartRetry != nil {
+		c.StartRetry.InitDefaults()
+		err := c.StartRetry.validate()
+		if err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	if c.HeartbeatMonitor != nil {
+		if c.HeartbeatMonitor.Interval == 0 {
+			c.HeartbeatMonitor.Interval = time.Second * 10
+		}
+
+		if c.HeartbeatMonitor.Threshold == 0 {
+			c.HeartbeatMonitor.Threshold = 0.5
+		}
+
+		if c.HeartbeatMonitor.Interval < 0 {
+			return errors.E(op, errors.Str("heartbeat_monitor.interval should be positive"))
+		}
+
+		if c.HeartbeatMonitor.Threshold < 0 || c.HeartbeatMonitor.Threshold > 1 {
+			return errors.E(op, errors.Str("heartbeat_monitor.threshold should be between 0 and 1"))
+		}
+	}
+
+	if c.WorkerInfoCache != nil && len(c.WorkerInfoCache.Files) == 0 {
+		return errors.E(op, errors.Str("worker_info_cache.files should contain at least 1 file"))
+	}
+
+	if c.OptionsCompression != nil {
+		if c.OptionsCompression.Threshold == 0 {
+			c.OptionsCompression.Threshold = 4 * 1024
+		}
+
+		if c.OptionsCompression.Threshold < 0 {
+			return errors.E(op, errors.Str("options_compression.threshold should be positive"))
+		}
+	}
+
+	if c.HeaderDeduplication != nil {
+		if c.HeaderDeduplication.Threshold == 0 {
+			c.HeaderDeduplication.Threshold = 1024
+		}
+
+		if c.HeaderDeduplication.Threshold < 0 {
+			return errors.E(op, errors.Str("header_deduplication.threshold should be positive"))
+		}
+	}
+
+	if c.CodecBuffers != nil {
+		if c.CodecBuffers.Size == 0 {
+			c.CodecBuffers.Size = 4 * 1024
+		}
+
+		if c.CodecBuffers.MaxSize == 0 {
+			c.CodecBuffers.MaxSize = 1024 * 1024
+		}
+
+		if c.CodecBuffers.Size < 0 || c.CodecBuffers.MaxSize < c.CodecBuffers.Size {
+			return errors.E(op, errors.Str("codec_buffers.size should be positive and not greater than codec_buffers.max_size"))
+		}
+	}
+
+	if c.ResetOverlap < 0 {
+		return errors.E(op, errors.Str("reset_overlap should be positive"))
+	}
+
+	if c.DataConverter != nil && c.DataConverter.Encoding != "" && !dataconverter.ValidEncoding(c.DataConverter.Encoding) {
+		return errors.E(op, errors.Errorf("data_converter: unknown payload encoding '%s'", c.DataConverter.Encoding))
+	}
+
+	if c.Logs != nil {
+		if c.Logs.Level != "" {
+			if _, err := zapcore.ParseLevel(c.Logs.Level); err != nil {
+				return errors.E(op, err)
+			}
+		}
+
+		if c.Logs.SampleDebug < 0 {
+			return errors.E(op, errors.Str("logs.sample_debug should be positive"))
+		}
+	}
+
+	for tq, wo := range c.Workers {
+		if wo == nil {
+			continue
+		}
+
+		if wo.DeadlockDetectionTimeout < 0 {
+			return errors.E(op, errors.Errorf("task queue '%s': deadlock_detection_timeout should be positive", tq))
+		}
+
+		if wo.WorkerStopTimeout < 0 {
+			return errors.E(op, errors.Errorf("task queue '%s': worker_stop_timeout should be positive", tq))
+		}
+
+		if wo.MaxConcurrentWorkflowTaskPollers < 0 || wo.MaxConcurrentActivityTaskPollers < 0 {
+			return errors.E(op, errors.Errorf("task queue '%s': max_concurrent_workflow_task_pollers and max_concurrent_activity_task_pollers should be positive", tq))
+		}
+
+		if wo.WorkerActivitiesPerSecond < 0 || wo.TaskQueueActivitiesPerSecond < 0 {
+			return errors.E(op, errors.Errorf("task queue '%s': worker_activities_per_second and task_queue_activities_per_second should be positive", tq))
+		}
+	}
+
+	routed := make(map[string]string)
+	for tq, workflows := range c.WorkflowTaskQueues {
+		if tq == "" {
+			return errors.E(op, errors.Str("workflow_task_queues: task queue name should not be empty"))
+		}
+
+		for _, name := range workflows {
+			if prev, ok := routed[name]; ok && prev != tq {
+				return errors.E(op, errors.Errorf("workflow '%s' is mapped to two task queues: '%s' and '%s'", name, prev, tq))
+			}
+
+			routed[name] = tq
+		}
+	}
+
 	if c.TLS != nil {
 		if c.TLS.Key != "" {
 			if _, err := os.Stat(c.TLS.Key); err != nil {
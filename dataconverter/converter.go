@@ -3,17 +3,25 @@ package dataconverter
 import (
 	commonpb "go.temporal.io/api/common/v1"
 	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/proto"
 )
 
 // DataConverter wraps Temporal data converter to enable direct access to the payloads.
 type DataConverter struct {
 	// dc is the RR data converter
 	dc converter.DataConverter
+	// pj encodes proto messages as JSON, nil - use the fallback
+	pj *protoJSONConverter
 }
 
 // NewDataConverter creates new data converter.
-func NewDataConverter(fallback converter.DataConverter) converter.DataConverter {
-	return &DataConverter{dc: fallback}
+func NewDataConverter(fallback converter.DataConverter, opts ...Option) converter.DataConverter {
+	r := &DataConverter{dc: fallback}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 // ToPayloads converts a list of values.
@@ -25,11 +33,29 @@ func (r *DataConverter) ToPayloads(values ...any) (*commonpb.Payloads, error) {
 		}
 	}
 
-	return r.dc.ToPayloads(values...)
+	if r.pj == nil {
+		return r.dc.ToPayloads(values...)
+	}
+
+	result := &commonpb.Payloads{}
+	for _, v := range values {
+		pl, err := r.ToPayload(v)
+		if err != nil {
+			return nil, err
+		}
+
+		result.Payloads = append(result.Payloads, pl)
+	}
+
+	return result, nil
 }
 
 // ToPayload converts single value to payload.
 func (r *DataConverter) ToPayload(value any) (*commonpb.Payload, error) {
+	if msg, ok := value.(proto.Message); ok && r.pj != nil {
+		return r.pj.toPayload(msg)
+	}
+
 	return r.dc.ToPayload(value)
 }
 
@@ -61,6 +87,13 @@ func (r *DataConverter) FromPayloads(payloads *commonpb.Payloads, valuePtrs ...a
 
 // FromPayload converts single value from payload.
 func (r *DataConverter) FromPayload(payload *commonpb.Payload, valuePtr any) error {
+	if r.pj != nil && string(payload.GetMetadata()[converter.MetadataEncoding]) == converter.MetadataEncodingProtoJSON {
+		ok, err := r.pj.fromPayload(payload, valuePtr)
+		if ok {
+			return err
+		}
+	}
+
 	return r.dc.FromPayload(payload, valuePtr)
 }
 
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.temporal.io/api/common/v1"
 	"go.temporal.io/sdk/converter"
 )
@@ -21,3 +22,23 @@ func Test_Passthough(t *testing.T) {
 
 	assert.Len(t, out.Payloads, 1)
 }
+
+func Test_ProtoJSONOptions(t *testing.T) {
+	codec := NewDataConverter(converter.GetDefaultDataConverter(), WithProtoJSON(ProtoJSONOptions{
+		UseProtoNames:   true,
+		EmitUnpopulated: true,
+	}))
+
+	pl, err := codec.ToPayload(&common.WorkflowExecution{WorkflowId: "foo"})
+	require.NoError(t, err)
+	assert.Equal(t, converter.MetadataEncodingProtoJSON, string(pl.GetMetadata()[converter.MetadataEncoding]))
+	assert.JSONEq(t, `{"workflow_id":"foo","run_id":""}`, string(pl.GetData()))
+
+	var out *common.WorkflowExecution
+	require.NoError(t, codec.FromPayload(pl, &out))
+	assert.Equal(t, "foo", out.GetWorkflowId())
+
+	out2 := &common.WorkflowExecution{}
+	require.NoError(t, codec.FromPayload(pl, out2))
+	assert.Equal(t, "foo", out2.GetWorkflowId())
+}
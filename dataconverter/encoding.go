@@ -0,0 +1,61 @@
+package dataconverter
+
+import (
+	"fmt"
+	"slices"
+
+	"go.temporal.io/sdk/converter"
+)
+
+// encodings in the default order, used when no preferred encoding is configured
+var encodings = []string{
+	converter.MetadataEncodingProtoJSON,
+	converter.MetadataEncodingProto,
+	converter.MetadataEncodingJSON,
+	MetadataEncodingMsgpack,
+}
+
+// ValidEncoding returns true if the encoding can be used as the preferred one.
+func ValidEncoding(encoding string) bool {
+	return slices.Contains(encodings, encoding)
+}
+
+// NewCompositeDataConverter creates the data converter with the preferred encoding tried first.
+// Nil and []byte values always use the binary/null and binary/plain encodings, the rest of the converters follow
+// the default order (json/protobuf, binary/protobuf, json/plain, binary/msgpack), so all the encodings
+// can still be decoded via the payload metadata.
+func NewCompositeDataConverter(preferred string) (converter.DataConverter, error) {
+	if preferred != "" && !ValidEncoding(preferred) {
+		return nil, fmt.Errorf("unknown payload encoding: %s, supported: %v", preferred, encodings)
+	}
+
+	pcs := []converter.PayloadConverter{
+		converter.NewNilPayloadConverter(),
+		converter.NewByteSlicePayloadConverter(),
+	}
+
+	if preferred != "" {
+		pcs = append(pcs, payloadConverter(preferred))
+	}
+
+	for _, enc := range encodings {
+		if enc != preferred {
+			pcs = append(pcs, payloadConverter(enc))
+		}
+	}
+
+	return converter.NewCompositeDataConverter(pcs...), nil
+}
+
+func payloadConverter(encoding string) converter.PayloadConverter {
+	switch encoding {
+	case converter.MetadataEncodingProtoJSON:
+		return converter.NewProtoJSONPayloadConverter()
+	case converter.MetadataEncodingProto:
+		return converter.NewProtoPayloadConverter()
+	case converter.MetadataEncodingJSON:
+		return converter.NewJSONPayloadConverter()
+	default:
+		return &msgpackPayloadConverter{}
+	}
+}
@@ -0,0 +1,77 @@
+package dataconverter
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/goccy/go-json"
+	"github.com/vmihailenco/msgpack/v5"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+)
+
+// MetadataEncodingMsgpack is the payload encoding of the msgpack converter, should match the PHP side.
+const MetadataEncodingMsgpack = "binary/msgpack"
+
+// msgpackPayloadConverter encodes values as msgpack. Structs use the json tags, so the field names are the same as
+// with the JSON converter. Untyped values are decoded as map[string]any, []any, int64, uint64, float64, string,
+// bool or nil.
+type msgpackPayloadConverter struct{}
+
+func (m *msgpackPayloadConverter) ToPayload(value any) (*commonpb.Payload, error) {
+	enc := msgpack.GetEncoder()
+	defer msgpack.PutEncoder(enc)
+
+	var buf bytes.Buffer
+	// Reset clears the encoder options
+	enc.Reset(&buf)
+	enc.SetCustomStructTag("json")
+
+	err := enc.Encode(value)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", converter.ErrUnableToEncode, err)
+	}
+
+	return &commonpb.Payload{
+		Metadata: map[string][]byte{
+			converter.MetadataEncoding: []byte(MetadataEncodingMsgpack),
+		},
+		Data: buf.Bytes(),
+	}, nil
+}
+
+func (m *msgpackPayloadConverter) FromPayload(payload *commonpb.Payload, valuePtr any) error {
+	dec := msgpack.GetDecoder()
+	defer msgpack.PutDecoder(dec)
+
+	// Reset clears the decoder options
+	dec.Reset(bytes.NewReader(payload.GetData()))
+	dec.SetCustomStructTag("json")
+	dec.UseLooseInterfaceDecoding(true)
+
+	err := dec.Decode(valuePtr)
+	if err != nil {
+		return fmt.Errorf("%w: %v", converter.ErrUnableToDecode, err)
+	}
+
+	return nil
+}
+
+func (m *msgpackPayloadConverter) ToString(payload *commonpb.Payload) string {
+	var value any
+	err := m.FromPayload(payload, &value)
+	if err != nil {
+		return err.Error()
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(data)
+}
+
+func (m *msgpackPayloadConverter) Encoding() string {
+	return MetadataEncodingMsgpack
+}
@@ -0,0 +1,172 @@
+package dataconverter
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/sdk/converter"
+)
+
+func Test_MsgpackRoundTrip(t *testing.T) {
+	dc, err := NewCompositeDataConverter(MetadataEncodingMsgpack)
+	require.NoError(t, err)
+
+	in := map[string]any{
+		"str":   strings.Repeat("a", 300),
+		"int":   int64(-70000),
+		"uint":  uint64(1 << 63),
+		"float": 1.5,
+		"bool":  true,
+		"nil":   nil,
+		"list":  []any{int64(1), "two"},
+	}
+
+	pl, err := dc.ToPayload(in)
+	require.NoError(t, err)
+	assert.Equal(t, MetadataEncodingMsgpack, string(pl.GetMetadata()[converter.MetadataEncoding]))
+
+	var out any
+	require.NoError(t, dc.FromPayload(pl, &out))
+	assert.Equal(t, in, out)
+}
+
+func Test_MsgpackTyped(t *testing.T) {
+	type item struct {
+		Name  string   `json:"name"`
+		Count int      `json:"count"`
+		Tags  []string `json:"tags"`
+	}
+
+	dc, err := NewCompositeDataConverter(MetadataEncodingMsgpack)
+	require.NoError(t, err)
+
+	pl, err := dc.ToPayload(item{Name: "foo", Count: 42, Tags: []string{"a", "b"}})
+	require.NoError(t, err)
+
+	var out item
+	require.NoError(t, dc.FromPayload(pl, &out))
+	assert.Equal(t, item{Name: "foo", Count: 42, Tags: []string{"a", "b"}}, out)
+
+	// bytes keep the binary/plain encoding
+	pl, err = dc.ToPayload([]byte("raw"))
+	require.NoError(t, err)
+	assert.Equal(t, converter.MetadataEncodingBinary, string(pl.GetMetadata()[converter.MetadataEncoding]))
+}
+
+func Test_CompositeDecodesAllEncodings(t *testing.T) {
+	jsonDC, err := NewCompositeDataConverter(converter.MetadataEncodingJSON)
+	require.NoError(t, err)
+	msgpackDC, err := NewCompositeDataConverter(MetadataEncodingMsgpack)
+	require.NoError(t, err)
+
+	pl, err := msgpackDC.ToPayload("foo")
+	require.NoError(t, err)
+
+	// the encoding is negotiated via the payload metadata
+	var out string
+	require.NoError(t, jsonDC.FromPayload(pl, &out))
+	assert.Equal(t, "foo", out)
+}
+
+func Test_UnknownEncoding(t *testing.T) {
+	_, err := NewCompositeDataConverter("binary/foo")
+	assert.Error(t, err)
+	assert.False(t, ValidEncoding("binary/foo"))
+	assert.True(t, ValidEncoding(converter.MetadataEncodingJSON))
+}
+
+func Test_MsgpackMalformed(t *testing.T) {
+	dc, err := NewCompositeDataConverter(MetadataEncodingMsgpack)
+	require.NoError(t, err)
+
+	pl, err := dc.ToPayload([]any{"foo", "bar"})
+	require.NoError(t, err)
+	pl.Data = pl.Data[:len(pl.Data)-1]
+
+	var out any
+	assert.Error(t, dc.FromPayload(pl, &out))
+}
+
+type benchItem struct {
+	ID     int64    `json:"id"`
+	Name   string   `json:"name"`
+	Price  float64  `json:"price"`
+	Active bool     `json:"active"`
+	Tags   []string `json:"tags"`
+}
+
+type benchOrder struct {
+	Items []benchItem `json:"items"`
+}
+
+func largeOrder() benchOrder {
+	items := make([]benchItem, 0, 1000)
+	for i := range 1000 {
+		items = append(items, benchItem{
+			ID:     int64(i),
+			Name:   fmt.Sprintf("item-%d", i),
+			Price:  float64(i) * 1.25,
+			Active: i%2 == 0,
+			Tags:   []string{"foo", "bar", "baz"},
+		})
+	}
+
+	return benchOrder{Items: items}
+}
+
+func largePayload() map[string]any {
+	order := largeOrder()
+	items := make([]any, 0, len(order.Items))
+	for _, it := range order.Items {
+		items = append(items, map[string]any{
+			"id":     it.ID,
+			"name":   it.Name,
+			"price":  it.Price,
+			"active": it.Active,
+			"tags":   []any{"foo", "bar", "baz"},
+		})
+	}
+
+	return map[string]any{"items": items}
+}
+
+func benchmarkEncoding[T any](b *testing.B, encoding string, value T) {
+	dc, err := NewCompositeDataConverter(encoding)
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+
+	for b.Loop() {
+		pl, err := dc.ToPayload(value)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var out T
+		err = dc.FromPayload(pl, &out)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.ReportMetric(float64(len(pl.GetData())), "payload_bytes")
+	}
+}
+
+func BenchmarkLargePayloadJSON(b *testing.B) {
+	benchmarkEncoding[any](b, converter.MetadataEncodingJSON, largePayload())
+}
+
+func BenchmarkLargePayloadMsgpack(b *testing.B) {
+	benchmarkEncoding[any](b, MetadataEncodingMsgpack, largePayload())
+}
+
+func BenchmarkLargeStructJSON(b *testing.B) {
+	benchmarkEncoding(b, converter.MetadataEncodingJSON, largeOrder())
+}
+
+func BenchmarkLargeStructMsgpack(b *testing.B) {
+	benchmarkEncoding(b, MetadataEncodingMsgpack, largeOrder())
+}
@@ -0,0 +1,85 @@
+package dataconverter
+
+import (
+	"reflect"
+
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoJSONOptions control the JSON encoding of the protobuf messages.
+// Decoding accepts both the original and JSON field names and ignores unpopulated fields, so the same options
+// can be used for both directions.
+type ProtoJSONOptions struct {
+	// UseProtoNames uses the original (snake_case) field names instead of the lowerCamelCase JSON names.
+	UseProtoNames bool `mapstructure:"use_proto_names"`
+	// EmitUnpopulated emits fields with default values.
+	EmitUnpopulated bool `mapstructure:"emit_unpopulated"`
+	// DiscardUnknown ignores unknown fields on decoding instead of failing.
+	DiscardUnknown bool `mapstructure:"discard_unknown"`
+}
+
+// Option configures the data converter.
+type Option func(*DataConverter)
+
+// WithProtoJSON sets the options used to encode and decode protobuf messages as JSON.
+func WithProtoJSON(opts ProtoJSONOptions) Option {
+	return func(r *DataConverter) {
+		r.pj = &protoJSONConverter{
+			marshal: protojson.MarshalOptions{
+				UseProtoNames:   opts.UseProtoNames,
+				EmitUnpopulated: opts.EmitUnpopulated,
+			},
+			unmarshal: protojson.UnmarshalOptions{
+				DiscardUnknown: opts.DiscardUnknown,
+			},
+		}
+	}
+}
+
+// protoJSONConverter is the json/protobuf payload converter with custom protojson options
+type protoJSONConverter struct {
+	marshal   protojson.MarshalOptions
+	unmarshal protojson.UnmarshalOptions
+}
+
+func (p *protoJSONConverter) toPayload(msg proto.Message) (*commonpb.Payload, error) {
+	data, err := p.marshal.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &commonpb.Payload{
+		Metadata: map[string][]byte{
+			converter.MetadataEncoding:    []byte(converter.MetadataEncodingProtoJSON),
+			converter.MetadataMessageType: []byte(msg.ProtoReflect().Descriptor().FullName()),
+		},
+		Data: data,
+	}, nil
+}
+
+// fromPayload decodes the payload into the proto message, valuePtr might be a message or a pointer to a message.
+// Returns false if the value is not a proto message.
+func (p *protoJSONConverter) fromPayload(payload *commonpb.Payload, valuePtr any) (bool, error) {
+	if msg, ok := valuePtr.(proto.Message); ok {
+		return true, p.unmarshal.Unmarshal(payload.GetData(), msg)
+	}
+
+	value := reflect.ValueOf(valuePtr)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Ptr {
+		return false, nil
+	}
+
+	elem := value.Elem()
+	if _, ok := elem.Interface().(proto.Message); !ok {
+		return false, nil
+	}
+
+	if elem.IsNil() {
+		elem.Set(reflect.New(elem.Type().Elem()))
+	}
+
+	return true, p.unmarshal.Unmarshal(payload.GetData(), elem.Interface().(proto.Message))
+}
@@ -0,0 +1,56 @@
+package rrtemporal
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ResetFailureStop stops the plugin (and RR) if the workers can't be restarted
+	ResetFailureStop string = "stop"
+	// ResetFailureDegraded keeps retrying the reset, the plugin is reported as not healthy meanwhile
+	ResetFailureDegraded string = "degraded"
+)
+
+// ResetFailure configures the behavior when the workers can't be restarted after a worker stopped.
+type ResetFailure struct {
+	// Policy is stop (default) or degraded.
+	Policy string `mapstructure:"policy"`
+	// InitialInterval of the degraded mode retries, doubled after each attempt. Default: 1s.
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval of the degraded mode retries. Default: 1m.
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+}
+
+// retryReset retries the reset with the exponential backoff until it succeeds, the plugin is degraded meanwhile.
+// Returns true if the plugin was stopped while retrying.
+func (p *Plugin) retryReset(reset func() error) bool {
+	p.degraded.Store(true)
+	defer p.degraded.Store(false)
+
+	interval := p.config.ResetFailure.InitialInterval
+	for attempt := 1; ; attempt++ {
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-p.stopCh:
+			timer.Stop()
+			return true
+		}
+
+		err := reset()
+		if err == nil {
+			p.log.Info("reset succeeded, leaving the degraded mode", zap.Int("attempts", attempt))
+			return false
+		}
+
+		interval = min(interval*2, p.config.ResetFailure.MaxInterval)
+		p.log.Error("reset failed", zap.Int("attempt", attempt), zap.Duration("next_attempt", interval), zap.Error(err))
+	}
+}
+
+// Degraded returns true while the failed reset is retried (reset_failure.policy: degraded).
+func (p *Plugin) Degraded() bool {
+	return p.degraded.Load()
+}
@@ -0,0 +1,103 @@
+package rrtemporal
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/roadrunner-server/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newWatchedPlugin(policy string) *Plugin {
+	return &Plugin{
+		log: zap.NewNop(),
+		config: &Config{ResetFailure: &ResetFailure{
+			Policy:          policy,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond * 5,
+		}},
+		wwPID:  42,
+		events: make(chan events.Event, 1),
+		stopCh: make(chan struct{}, 1),
+	}
+}
+
+func Test_ResetFailureDegraded(t *testing.T) {
+	p := newWatchedPlugin(ResetFailureDegraded)
+
+	var attempts atomic.Int32
+	resetAP := func() error {
+		if attempts.Add(1) < 3 {
+			return errors.New("pool is not started")
+		}
+		return nil
+	}
+	resetWW := func() error {
+		t.Error("the workflow worker was not stopped")
+		return nil
+	}
+
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		p.watchWorkers(errCh, resetAP, resetWW)
+		close(done)
+	}()
+
+	p.events <- events.NewEvent(events.EventWorkerStopped, pluginName, "process exited, pid: 7")
+
+	// retried until succeeded, the plugin is not stopped
+	require.Eventually(t, func() bool { return attempts.Load() == 3 && !p.Degraded() }, time.Second, time.Millisecond)
+	assert.Empty(t, errCh)
+
+	p.stopCh <- struct{}{}
+	<-done
+}
+
+func Test_ResetFailureDegradedStopped(t *testing.T) {
+	p := newWatchedPlugin(ResetFailureDegraded)
+
+	var attempts atomic.Int32
+	reset := func() error {
+		attempts.Add(1)
+		return errors.New("temporal is not available")
+	}
+
+	errCh := make(chan error, 1)
+	done := make(chan struct{})
+	go func() {
+		p.watchWorkers(errCh, reset, reset)
+		close(done)
+	}()
+
+	// the workflow worker
+	p.events <- events.NewEvent(events.EventWorkerStopped, pluginName, "process exited, pid: 42")
+	require.Eventually(t, func() bool { return attempts.Load() > 1 && p.Degraded() }, time.Second, time.Millisecond)
+
+	// the plugin is stopped while retrying
+	p.stopCh <- struct{}{}
+	<-done
+	assert.False(t, p.Degraded())
+	assert.Empty(t, errCh)
+}
+
+func Test_ResetFailureStop(t *testing.T) {
+	p := newWatchedPlugin(ResetFailureStop)
+
+	errCh := make(chan error, 1)
+	go p.watchWorkers(errCh, func() error { return errors.New("pool is not started") }, nil)
+
+	p.events <- events.NewEvent(events.EventWorkerStopped, pluginName, "process exited, pid: 7")
+
+	select {
+	case err := <-errCh:
+		assert.Contains(t, err.Error(), "pool is not started")
+	case <-time.After(time.Second):
+		t.Fatal("the reset error is not reported")
+	}
+	assert.False(t, p.Degraded())
+}
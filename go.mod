@@ -12,6 +12,7 @@ require (
 	github.com/roadrunner-server/pool v1.1.3
 	github.com/stretchr/testify v1.11.1
 	github.com/uber-go/tally/v4 v4.1.17
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	go.temporal.io/api v1.57.0
 	go.temporal.io/sdk v1.37.0
 	go.temporal.io/sdk/contrib/tally v0.2.0
@@ -24,6 +25,7 @@ require (
 	github.com/grpc-ecosystem/go-grpc-middleware/v2 v2.3.2 // indirect
 	go.opentelemetry.io/otel v1.38.0 // indirect
 	go.opentelemetry.io/otel/sdk/metric v1.38.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	go.yaml.in/yaml/v2 v2.4.3 // indirect
 )
 
@@ -0,0 +1,28 @@
+package rrtemporal
+
+import (
+	"context"
+	"os"
+)
+
+// headersProvider adds static gRPC headers to every request, including the workers polling requests
+type headersProvider struct {
+	headers map[string]string
+}
+
+func newHeadersProvider(headers map[string]string) *headersProvider {
+	hp := &headersProvider{
+		headers: make(map[string]string, len(headers)),
+	}
+
+	for k, v := range headers {
+		// secrets might be passed via the environment
+		hp.headers[k] = os.ExpandEnv(v)
+	}
+
+	return hp
+}
+
+func (h *headersProvider) GetHeaders(context.Context) (map[string]string, error) {
+	return h.headers, nil
+}
@@ -0,0 +1,103 @@
+package rrtemporal
+
+import (
+	"context"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	tclient "go.temporal.io/sdk/client"
+)
+
+// healthCheckTimeout bounds the Temporal server health check made by the status and health requests
+const healthCheckTimeout = time.Second * 2
+
+// HealthResponse describes the plugin health: the worker pools and the Temporal connection.
+type HealthResponse struct {
+	// Serving is true when the worker pools are started and have an active worker
+	Serving bool `json:"serving"`
+	// Connected is true when the Temporal server responds to the health check
+	Connected bool `json:"connected"`
+	// Error is the reason of the failed connection check
+	Error string `json:"error,omitempty"`
+	// Workflows is the number of the registered workflow types
+	Workflows int `json:"workflows"`
+	// PoolsStartedAt is the time of the last pools start, replacement or reset, zero if the pools are not started
+	PoolsStartedAt time.Time `json:"pools_started_at"`
+}
+
+// serving returns true if the pools are started and have an active worker, the workflow worker is enough when the
+// activity workers are disabled
+func (p *Plugin) serving() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.wfP == nil || p.actP == nil {
+		return false
+	}
+
+	// the workers are being restarted after the failed reset
+	if p.Degraded() {
+		return false
+	}
+
+	if p.config.DisableActivityWorkers && len(p.wfP.Workers()) > 0 && p.wfP.Workers()[0].State().IsActive() {
+		return true
+	}
+
+	workers := p.actP.Workers()
+	for i := range workers {
+		if workers[i].State().IsActive() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkConnection checks the Temporal server health through the plugin client
+func (p *Plugin) checkConnection() error {
+	const op = errors.Op("temporal_check_connection")
+
+	p.mu.RLock()
+	var client tclient.Client
+	if p.temporal != nil {
+		client = p.temporal.client
+	}
+	p.mu.RUnlock()
+
+	if client == nil {
+		return errors.E(op, errors.Str("temporal client is not initialized"))
+	}
+
+	// the lock is not held while waiting for the server
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+
+	_, err := client.CheckHealth(ctx, &tclient.CheckHealthRequest{})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// Health returns the state of the worker pools and the Temporal connection, the same conditions are reported by the
+// status plugin (/health) with the status code only.
+func (r *rpc) Health(_ bool, out *HealthResponse) error {
+	out.Serving = r.plugin.serving()
+
+	err := r.plugin.checkConnection()
+	out.Connected = err == nil
+	if err != nil {
+		out.Error = err.Error()
+	}
+
+	r.plugin.mu.RLock()
+	if r.plugin.temporal != nil {
+		out.Workflows = len(r.plugin.temporal.workflows)
+	}
+	out.PoolsStartedAt = r.plugin.poolsStartedAt
+	r.plugin.mu.RUnlock()
+
+	return nil
+}
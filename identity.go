@@ -0,0 +1,28 @@
+package rrtemporal
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultIdentity string = "{hostname}-{pid}"
+
+// resolveIdentity replaces {hostname} and {pid} placeholders and ${ENV} variables in the identity template
+func resolveIdentity(tpl string) string {
+	if tpl == "" {
+		tpl = defaultIdentity
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+
+	r := strings.NewReplacer(
+		"{hostname}", hostname,
+		"{pid}", strconv.Itoa(os.Getpid()),
+	)
+
+	return os.ExpandEnv(r.Replace(tpl))
+}
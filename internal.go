@@ -2,13 +2,16 @@ package rrtemporal
 
 import (
 	"context"
+	"maps"
 	"os"
+	"slices"
 	"time"
 
 	"github.com/roadrunner-server/errors"
 	"github.com/roadrunner-server/pool/pool"
 	"github.com/temporalio/roadrunner-temporal/v5/aggregatedpool"
 	"github.com/temporalio/roadrunner-temporal/v5/dataconverter"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
 	"github.com/temporalio/roadrunner-temporal/v5/internal/codec/proto"
 	"github.com/temporalio/roadrunner-temporal/v5/internal/logger"
 	tclient "go.temporal.io/sdk/client"
@@ -25,30 +28,132 @@ const (
 	APIKey string = "ApiKey"
 )
 
+// poolSet is a pair of the activity and workflow pools with the definitions bound to them
+type poolSet struct {
+	actP   *staticPool.Pool
+	wfP    *staticPool.Pool
+	actDef *aggregatedpool.Activity
+	wfDef  *aggregatedpool.Workflow
+	codec  *proto.Codec
+	dc     converter.DataConverter
+	wwPID  int
+	wi     []*internal.WorkerInfo
+}
+
 func (p *Plugin) initPool() error {
-	var err error
+	ps, err := p.newPoolSet(context.Background())
+	if err != nil {
+		return err
+	}
+
+	workers, err := p.startWorkers(ps)
+	if err != nil {
+		// the initial start might be retried (start_retry), release everything allocated by this attempt
+		if p.temporal.client != nil {
+			p.temporal.client.Close()
+			p.temporal.client = nil
+		}
+
+		destroyPools(ps.wfP, ps.actP)
+		return err
+	}
+
+	p.usePoolSet(ps, workers)
+	p.watchPollers(ps.wi)
+
+	return nil
+}
+
+// startWorkers connects the client and starts the Temporal workers of the pool set, the started workers are stopped on error
+func (p *Plugin) startWorkers(ps *poolSet) ([]worker.Worker, error) {
+	err := p.initTemporalClient(ps.wi[0].PhpSdkVersion, ps.wi[0].Flags, ps.dc)
+	if err != nil {
+		return nil, err
+	}
+
+	workers, err := aggregatedpool.TemporalWorkers(ps.wfDef, ps.actDef, ps.wi, p.log, p.temporal.client, p.temporal.interceptors, p.config.Identity)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range workers {
+		err = workers[i].Start()
+		if err != nil {
+			for j := range i {
+				workers[j].Stop()
+			}
+
+			return nil, err
+		}
+	}
+
+	return workers, nil
+}
+
+// newPoolSet allocates the activity and workflow pools and reads the worker info from the workflow worker,
+// the allocated pools are destroyed on error
+func (p *Plugin) newPoolSet(ctx context.Context) (_ *poolSet, err error) {
 	var options []staticPool.Options
 
 	if p.config.DisableActivityWorkers {
 		options = append(options, staticPool.WithNumWorkers(0))
 	}
 
-	ap, err := p.server.NewPoolWithOptions(context.Background(), p.config.Activities, map[string]string{RrMode: pluginName, RrCodec: RrCodecVal}, p.log, options...)
+	ap, err := p.server.NewPoolWithOptions(ctx, p.config.Activities, map[string]string{RrMode: pluginName, RrCodec: RrCodecVal}, p.log, options...)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var wp *staticPool.Pool
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if wp != nil {
+			destroyPools(ap, wp)
+			return
+		}
+
+		destroyPools(ap)
+	}()
+
+	hlog, err := handlerLogger(p.log, p.config.Logs)
+	if err != nil {
+		return nil, err
+	}
+
+	fallback := converter.GetDefaultDataConverter()
+	var dcOpts []dataconverter.Option
+	if p.config.DataConverter != nil {
+		if p.config.DataConverter.Encoding != "" {
+			fallback, err = dataconverter.NewCompositeDataConverter(p.config.DataConverter.Encoding)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if p.config.DataConverter.ProtoJSON != nil {
+			dcOpts = append(dcOpts, dataconverter.WithProtoJSON(*p.config.DataConverter.ProtoJSON))
+		}
 	}
 
-	dc := dataconverter.NewDataConverter(converter.GetDefaultDataConverter())
-	codec := proto.NewCodec(p.log, dc)
+	dc := dataconverter.NewDataConverter(fallback, dcOpts...)
+	var codecOpts []proto.Option
+	if p.config.CodecBuffers != nil {
+		codecOpts = append(codecOpts, proto.WithBufferPool(p.config.CodecBuffers.Size, p.config.CodecBuffers.MaxSize))
+	}
+
+	codec := proto.NewCodec(p.log, dc, codecOpts...)
 
 	// LA + A definitions
-	actDef := aggregatedpool.NewActivityDefinition(codec, ap, p.log, p.config.DisableActivityWorkers)
-	laDef := aggregatedpool.NewLocalActivityFn(codec, ap, p.log)
+	actDef := aggregatedpool.NewActivityDefinition(codec, ap, hlog, p.config.DisableActivityWorkers, p.config.PayloadLimits)
+	laDef := aggregatedpool.NewLocalActivityFn(codec, ap, hlog, p.config.PayloadLimits, p.config.Workflows.MaxConcurrentLocalActivities, p.config.Workflows.MaxStreamedLocalActivityResult)
 	// ------------------
 
 	// ---------- WORKFLOW POOL -------------
-	wp, err := p.server.NewPool(
-		context.Background(),
+	wp, err = p.server.NewPool(
+		ctx,
 		&pool.Config{
 			NumWorkers:      1,
 			Command:         p.config.Activities.Command,
@@ -62,57 +167,62 @@ func (p *Plugin) initPool() error {
 		nil,
 	)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if len(wp.Workers()) < 1 {
-		return errors.E(errors.Str("failed to allocate a workflow worker"))
+		return nil, errors.E(errors.Str("failed to allocate a workflow worker"))
 	}
 
-	// set all fields
 	// we have only 1 worker for the workflow pool
-	p.wwPID = int(wp.Workers()[0].Pid())
+	wwPID := int(wp.Workers()[0].Pid())
 
-	wfDef := aggregatedpool.NewWorkflowDefinition(codec, laDef.ExecuteLA, wp, p.log)
+	wfDef := aggregatedpool.NewWorkflowDefinition(codec, laDef.ExecuteLA, wp, hlog, p.config.Workflows, slices.Collect(maps.Values(p.temporal.decorators)), p.config.PayloadLimits)
 
 	// get worker information
-	wi, err := WorkerInfo(codec, wp, p.rrVersion, p.wwPID)
+	wi, err := p.workerInfo(codec, wp, wwPID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	wi = p.routeWorkflows(wi)
 	if len(wi) == 0 {
-		return errors.Str("worker info should contain at least 1 worker")
-	}
-
-	err = p.initTemporalClient(wi[0].PhpSdkVersion, wi[0].Flags, dc)
-	if err != nil {
-		return err
-	}
-
-	workers, err := aggregatedpool.TemporalWorkers(wfDef, actDef, wi, p.log, p.temporal.client, p.temporal.interceptors)
-	if err != nil {
-		return err
+		return nil, errors.Str("worker info should contain at least 1 worker")
 	}
 
-	for i := range workers {
-		err = workers[i].Start()
-		if err != nil {
-			return err
-		}
-	}
+	p.negotiateOptionsCompression(codec, wi[0].Flags)
+	p.negotiateHeaderDeduplication(codec, wi[0].Flags)
+	p.negotiateContextFields(wfDef, wi[0].Flags)
+	wfDef.SetRunRegistry(p.runs)
+	wfDef.SetCommandMiddleware(slices.Collect(maps.Values(p.temporal.middleware)))
+
+	p.applyWorkerOptions(wi)
+
+	return &poolSet{
+		actP:   ap,
+		wfP:    wp,
+		actDef: actDef,
+		wfDef:  wfDef,
+		codec:  codec,
+		dc:     dc,
+		wwPID:  wwPID,
+		wi:     wi,
+	}, nil
+}
 
-	p.temporal.rrWorkflowDef = wfDef
-	p.temporal.rrActivityDef = actDef
+// usePoolSet sets the pools and started Temporal workers as the current ones
+func (p *Plugin) usePoolSet(ps *poolSet, workers []worker.Worker) {
+	p.temporal.rrWorkflowDef = ps.wfDef
+	p.temporal.rrActivityDef = ps.actDef
 	p.temporal.workers = workers
-	p.codec = codec
-
-	p.temporal.activities = ActivitiesInfo(wi)
-	p.temporal.workflows = WorkflowsInfo(wi)
-	p.actP = ap
-	p.wfP = wp
-
-	return nil
+	p.codec = ps.codec
+
+	p.temporal.activities = ActivitiesInfo(ps.wi)
+	p.temporal.workflows = WorkflowsInfo(ps.wi)
+	p.actP = ps.actP
+	p.wfP = ps.wfP
+	p.wwPID = ps.wwPID
+	p.poolsStartedAt = time.Now()
 }
 
 func (p *Plugin) getWfDef() *aggregatedpool.Workflow {
@@ -127,6 +237,51 @@ func (p *Plugin) getActDef() *aggregatedpool.Activity {
 	return p.temporal.rrActivityDef
 }
 
+// negotiateOptionsCompression enables the command options compression if it's configured and the worker supports it.
+// The activity workers run the same worker code, so the workflow worker flags are used for both pools.
+func (p *Plugin) negotiateOptionsCompression(codec *proto.Codec, flags map[string]string) {
+	if p.config.OptionsCompression == nil {
+		return
+	}
+
+	if flags[proto.OptionsCompressionFlag] != "gzip" {
+		codec.SetOptionsCompression(0)
+		p.log.Warn("options compression is configured, but not supported by the worker, the options are sent uncompressed")
+		return
+	}
+
+	codec.SetOptionsCompression(p.config.OptionsCompression.Threshold)
+	p.log.Debug("options compression enabled", zap.Int("threshold", p.config.OptionsCompression.Threshold))
+}
+
+// negotiateHeaderDeduplication enables the header references if it's configured and the worker supports it.
+func (p *Plugin) negotiateHeaderDeduplication(codec *proto.Codec, flags map[string]string) {
+	if p.config.HeaderDeduplication == nil {
+		return
+	}
+
+	if flags[proto.HeaderDeduplicationFlag] != "index" {
+		codec.SetHeaderDeduplication(0)
+		p.log.Warn("header deduplication is configured, but not supported by the worker, the headers are sent with every message")
+		return
+	}
+
+	codec.SetHeaderDeduplication(p.config.HeaderDeduplication.Threshold)
+	p.log.Debug("header deduplication enabled", zap.Int("threshold", p.config.HeaderDeduplication.Threshold))
+}
+
+// negotiateContextFields sets the configured context fields used by the worker, negotiated again after the workers
+// are restarted.
+func (p *Plugin) negotiateContextFields(wfDef *aggregatedpool.Workflow, flags map[string]string) {
+	// validated with the configuration
+	fields, _ := aggregatedpool.NegotiateContextFields(p.config.Workflows.ContextFields, flags)
+	wfDef.SetContextFields(fields)
+
+	if fields != aggregatedpool.AllContextFields {
+		p.log.Debug("reduced workflow context", zap.Uint32("fields", uint32(fields)), zap.String("worker_fields", flags[aggregatedpool.ContextFieldsFlag]))
+	}
+}
+
 func (p *Plugin) initTemporalClient(phpSdkVersion string, flags map[string]string, dc converter.DataConverter) error {
 	if phpSdkVersion == "" {
 		phpSdkVersion = clientBaselineVersion
@@ -151,8 +306,10 @@ func (p *Plugin) initTemporalClient(phpSdkVersion string, flags map[string]strin
 		HostPort:       p.config.Address,
 		MetricsHandler: p.temporal.mh,
 		Namespace:      p.config.Namespace,
-		Logger:         logger.NewZapAdapter(p.log),
-		DataConverter:  dc,
+		// the workers use it suffixed by the task queue unless it is set in the worker options
+		Identity:      p.config.Identity,
+		Logger:        logger.NewZapAdapter(p.log, p.runs.ReportNonDeterminism),
+		DataConverter: dc,
 		ConnectionOptions: tclient.ConnectionOptions{
 			TLS:         p.temporal.tlsCfg,
 			DialOptions: dialOpts,
@@ -167,17 +324,48 @@ func (p *Plugin) initTemporalClient(phpSdkVersion string, flags map[string]strin
 		}),
 	}
 
+	if len(p.config.Headers) > 0 {
+		opts.HeadersProvider = newHeadersProvider(p.config.Headers)
+	}
+
+	dialTimeout := time.Minute
+	if p.config.Connection != nil {
+		applyConnectionOptions(p.config.Connection, &opts.ConnectionOptions)
+		dialTimeout = p.config.Connection.DialTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	defer cancel()
+
 	var err error
-	p.temporal.client, err = tclient.Dial(opts)
+	p.temporal.client, err = tclient.DialContext(ctx, opts)
 	if err != nil {
 		return err
 	}
 
+	p.temporal.dc = dc
 	p.log.Info("connected to temporal server", zap.String("address", p.config.Address))
 
 	return nil
 }
 
+func applyConnectionOptions(cfg *Connection, co *tclient.ConnectionOptions) {
+	if cfg.KeepAliveTime > 0 {
+		co.DisableKeepAliveCheck = false
+		co.KeepAliveTime = cfg.KeepAliveTime
+		co.KeepAliveTimeout = cfg.KeepAliveTimeout
+		co.DisableKeepAlivePermitWithoutStream = !cfg.KeepAlivePermitWithoutStream
+	}
+
+	if cfg.MaxReceiveMessageSize > 0 {
+		co.MaxPayloadSize = cfg.MaxReceiveMessageSize
+	}
+
+	if cfg.MaxSendMessageSize > 0 {
+		co.DialOptions = append(co.DialOptions, grpc.WithDefaultCallOptions(grpc.MaxCallSendMsgSize(cfg.MaxSendMessageSize)))
+	}
+}
+
 func rewriteNameAndVersion(phpSdkVersion string) grpc.UnaryClientInterceptor {
 	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
 		md, ok := metadata.FromOutgoingContext(ctx)
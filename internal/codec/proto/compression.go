@@ -0,0 +1,71 @@
+package proto
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// OptionsCompressionFlag is the worker info flag advertising that the worker reads the gzip compressed command options.
+// Value: gzip.
+const OptionsCompressionFlag string = "OptionsCompression"
+
+// the gzip magic number is the marker of the compressed options, JSON never starts with it
+var gzipMagic = []byte{0x1f, 0x8b} //nolint:gochecknoglobals
+
+// SetOptionsCompression compresses the command options larger than threshold bytes sent to the workers, 0 disables it.
+// Should be enabled only when the worker advertised the OptionsCompressionFlag in the worker info (handshake),
+// negotiated again after the workers are restarted.
+func (c *Codec) SetOptionsCompression(threshold int) {
+	c.compressThreshold.Store(int64(threshold))
+}
+
+// compressOptions compresses the options over the threshold, the options are returned as is if compression is disabled.
+func (c *Codec) compressOptions(options []byte) ([]byte, error) {
+	threshold := c.compressThreshold.Load()
+	if threshold <= 0 || int64(len(options)) <= threshold {
+		return options, nil
+	}
+
+	buf := bytes.NewBuffer(make([]byte, 0, len(options)/2))
+	w, err := gzip.NewWriterLevel(buf, gzip.BestSpeed)
+	if err != nil {
+		return nil, errors.E(errors.Op("compress_options"), err)
+	}
+
+	_, err = w.Write(options)
+	if err != nil {
+		return nil, errors.E(errors.Op("compress_options"), err)
+	}
+
+	err = w.Close()
+	if err != nil {
+		return nil, errors.E(errors.Op("compress_options"), err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// decompressOptions decompresses the options starting with the gzip marker, the other options are returned as is.
+func decompressOptions(options []byte) ([]byte, error) {
+	if !bytes.HasPrefix(options, gzipMagic) {
+		return options, nil
+	}
+
+	r, err := gzip.NewReader(bytes.NewReader(options))
+	if err != nil {
+		return nil, errors.E(errors.Op("decompress_options"), err)
+	}
+	defer func() {
+		_ = r.Close()
+	}()
+
+	res, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.E(errors.Op("decompress_options"), err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,104 @@
+package proto
+
+import (
+	"strconv"
+
+	protocolV1 "github.com/roadrunner-server/api/v4/build/temporal/v1"
+	"github.com/roadrunner-server/errors"
+	commonpb "go.temporal.io/api/common/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// HeaderDeduplicationFlag is the worker info flag advertising that the worker resolves the header references.
+// Value: index.
+const HeaderDeduplicationFlag string = "HeaderDeduplication"
+
+// headerRefField is the only field of the header referencing the header of the previous message in the same frame,
+// the value is the index of that message in the frame (JSON number).
+const headerRefField string = "__rr_header_ref"
+
+// SetHeaderDeduplication sends the headers larger than threshold bytes only once per frame, the next messages with
+// the same header reference it, 0 disables it. Should be enabled only when the worker advertised the
+// HeaderDeduplicationFlag in the worker info (handshake), negotiated again after the workers are restarted.
+func (c *Codec) SetHeaderDeduplication(threshold int) {
+	c.headerThreshold.Store(int64(threshold))
+}
+
+// headerRefs replaces the repeated headers of the frame messages with the references to the first message sent with
+// the same header. The messages headers are not modified, they are shared with the workflow.
+type headerRefs struct {
+	threshold int
+	// the full headers sent in the frame and the indexes of their messages
+	sent []*commonpb.Header
+	idx  []int
+}
+
+func (c *Codec) newHeaderRefs() *headerRefs {
+	threshold := c.headerThreshold.Load()
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &headerRefs{threshold: int(threshold)}
+}
+
+// header returns the header to send with the i-th message of the frame
+func (h *headerRefs) header(i int, hdr *commonpb.Header) *commonpb.Header {
+	if h == nil || len(hdr.GetFields()) == 0 || proto.Size(hdr) <= h.threshold {
+		return hdr
+	}
+
+	for j, sent := range h.sent {
+		// the workflow header is usually the same pointer
+		if sent == hdr || proto.Equal(sent, hdr) {
+			return &commonpb.Header{Fields: map[string]*commonpb.Payload{
+				headerRefField: {
+					Metadata: map[string][]byte{"encoding": []byte("json/plain")},
+					Data:     strconv.AppendInt(nil, int64(h.idx[j]), 10),
+				},
+			}}
+		}
+	}
+
+	h.sent = append(h.sent, hdr)
+	h.idx = append(h.idx, i)
+
+	return hdr
+}
+
+// resolveHeaderRefs replaces the header references with the referenced headers. The frames of the workers not
+// using the references are not changed.
+func resolveHeaderRefs(messages []*protocolV1.Message) error {
+	const op = errors.Op("resolve_header_refs")
+
+	for i, m := range messages {
+		fields := m.GetHeader().GetFields()
+		if len(fields) != 1 {
+			continue
+		}
+
+		ref, ok := fields[headerRefField]
+		if !ok {
+			continue
+		}
+
+		idx, err := strconv.Atoi(string(ref.GetData()))
+		if err != nil {
+			return errors.E(op, errors.Errorf("message %d: invalid header reference: %s", m.GetId(), ref.GetData()))
+		}
+
+		// only the previous messages with the full headers are referenced
+		if idx < 0 || idx >= i || isHeaderRef(messages[idx].GetHeader()) {
+			return errors.E(op, errors.Errorf("message %d: header reference to the message %d, should be a previous message with the full header", m.GetId(), idx))
+		}
+
+		m.Header = messages[idx].GetHeader()
+	}
+
+	return nil
+}
+
+func isHeaderRef(hdr *commonpb.Header) bool {
+	_, ok := hdr.GetFields()[headerRefField]
+	return ok && len(hdr.GetFields()) == 1
+}
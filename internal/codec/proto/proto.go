@@ -2,6 +2,7 @@ package proto
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/goccy/go-json"
 	protocolV1 "github.com/roadrunner-server/api/v4/build/temporal/v1"
@@ -18,11 +19,36 @@ type Codec struct {
 	log    *zap.Logger
 	dc     converter.DataConverter
 	frPool sync.Pool
+	// nil - the encoded frames are not reused
+	bufPool *bufferPool
+	// command options larger than the threshold are compressed, 0 - disabled
+	compressThreshold atomic.Int64
+	// headers larger than the threshold are sent once per frame, 0 - disabled
+	headerThreshold atomic.Int64
+}
+
+// Option configures the codec.
+type Option func(*Codec)
+
+// WithBufferPool reuses the buffers of the encoded frames, the buffers are returned to the pool by Release.
+// size is the initial capacity of the buffer, buffers grown over maxSize are left to GC.
+func WithBufferPool(size, maxSize int) Option {
+	return func(c *Codec) {
+		c.bufPool = &bufferPool{
+			maxSize: maxSize,
+			pool: sync.Pool{
+				New: func() any {
+					b := make([]byte, 0, size)
+					return &b
+				},
+			},
+		}
+	}
 }
 
 // NewCodec creates new Proto communication Codec.
-func NewCodec(log *zap.Logger, dc converter.DataConverter) *Codec {
-	return &Codec{
+func NewCodec(log *zap.Logger, dc converter.DataConverter, opts ...Option) *Codec {
+	c := &Codec{
 		log: log,
 		dc:  dc,
 		frPool: sync.Pool{
@@ -31,6 +57,43 @@ func NewCodec(log *zap.Logger, dc converter.DataConverter) *Codec {
 			},
 		},
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// bufferPool is safe for concurrent use, the codec is shared by all workflows and activities
+type bufferPool struct {
+	pool    sync.Pool
+	maxSize int
+}
+
+func (b *bufferPool) get() []byte {
+	return (*b.pool.Get().(*[]byte))[:0]
+}
+
+func (b *bufferPool) put(buf []byte) {
+	if buf == nil || cap(buf) > b.maxSize {
+		return
+	}
+
+	buf = buf[:0]
+	b.pool.Put(&buf)
+}
+
+// Release returns the frame buffer of the payload encoded by Encode to the pool, the payload body must not be used
+// after that. It must be called only after the worker responded: after the exec timeout the pool might still write
+// the frame, such buffer is left to the GC.
+func (c *Codec) Release(p *payload.Payload) {
+	if c.bufPool == nil || p == nil {
+		return
+	}
+
+	c.bufPool.put(p.Body)
+	p.Body = nil
 }
 
 func (c *Codec) Encode(ctx *internal.Context, p *payload.Payload, msg ...*internal.Message) error {
@@ -43,6 +106,7 @@ func (c *Codec) Encode(ctx *internal.Context, p *payload.Payload, msg ...*intern
 	defer c.putFrame(request)
 
 	request.Messages = make([]*protocolV1.Message, len(msg))
+	refs := c.newHeaderRefs()
 
 	for i := range msg {
 		pm := &protocolV1.Message{}
@@ -50,6 +114,7 @@ func (c *Codec) Encode(ctx *internal.Context, p *payload.Payload, msg ...*intern
 		if err != nil {
 			return err
 		}
+		pm.Header = refs.header(i, pm.Header)
 		request.Messages[i] = pm
 
 		c.log.Debug("outgoing message", zap.Uint64("id", pm.Id), zap.ByteString("data", p.Body), zap.ByteString("context", p.Context))
@@ -66,7 +131,11 @@ func (c *Codec) Encode(ctx *internal.Context, p *payload.Payload, msg ...*intern
 		return errors.E(errors.Op("encode_context"), err)
 	}
 
-	p.Body, err = proto.Marshal(request)
+	if c.bufPool != nil {
+		p.Body, err = proto.MarshalOptions{}.MarshalAppend(c.bufPool.get(), request)
+	} else {
+		p.Body, err = proto.Marshal(request)
+	}
 	if err != nil {
 		return errors.E(errors.Op("encode_payload"), err)
 	}
@@ -88,6 +157,11 @@ func (c *Codec) Decode(pld *payload.Payload, result *[]*internal.Message) error
 		return errors.E(errors.Op("codec_parse_response"), err)
 	}
 
+	err = resolveHeaderRefs(response.Messages)
+	if err != nil {
+		return err
+	}
+
 	for _, f := range response.Messages {
 		msg, errM := c.parseMessage(f)
 		if errM != nil {
@@ -151,6 +225,10 @@ func (c *Codec) packMessage(msg *internal.Message, ctx *internal.Context, protoM
 	protoMsg.TaskQueue = ctx.TaskQueue
 	protoMsg.TickTime = ctx.TickTime
 	protoMsg.Replay = ctx.Replay
+	// replay state captured when the message was built
+	if msg.Replay != internal.ReplayUnknown {
+		protoMsg.Replay = msg.Replay == internal.ReplayTrue
+	}
 	protoMsg.ContinueAsNewSuggested = ctx.ContinueAsNewSuggested
 	protoMsg.HistorySize = int64(ctx.HistorySize)
 
@@ -164,6 +242,11 @@ func (c *Codec) packMessage(msg *internal.Message, ctx *internal.Context, protoM
 		if err != nil {
 			return err
 		}
+
+		protoMsg.Options, err = c.compressOptions(protoMsg.Options)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -183,10 +266,18 @@ func (c *Codec) parseMessage(frame *protocolV1.Message) (*internal.Message, erro
 	if frame.Command != "" {
 		msg.Command, err = internal.InitCommand(frame.Command)
 		if err != nil {
-			return nil, errors.E(op, err)
+			// the workflow handler decides whether to fail or skip it
+			c.log.Debug("unknown command received", zap.String("command", frame.Command), zap.Error(err))
+			msg.Command = &internal.UnknownCommand{Name: frame.Command}
+			return msg, nil
+		}
+
+		options, errD := decompressOptions(frame.Options)
+		if errD != nil {
+			return nil, errors.E(op, errD)
 		}
 
-		err = json.Unmarshal(frame.Options, &msg.Command)
+		err = json.Unmarshal(options, &msg.Command)
 		if err != nil {
 			return nil, errors.E(op, err)
 		}
@@ -0,0 +1,195 @@
+package proto
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	protocolV1 "github.com/roadrunner-server/api/v4/build/temporal/v1"
+	"github.com/roadrunner-server/pool/payload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/sdk/converter"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+func Test_EncodeHistorySize(t *testing.T) {
+	codec := NewCodec(zap.NewNop(), converter.GetDefaultDataConverter())
+
+	ctx := &internal.Context{
+		TaskQueue:   "default",
+		HistoryLen:  10,
+		HistorySize: 4096,
+	}
+
+	pl := &payload.Payload{}
+	err := codec.Encode(ctx, pl, &internal.Message{ID: 1}, &internal.Message{ID: 2, Command: internal.InvokeSignal{Name: "foo"}})
+	require.NoError(t, err)
+
+	// every message (responses and commands) carries the history length and size
+	frame := &protocolV1.Frame{}
+	require.NoError(t, proto.Unmarshal(pl.Body, frame))
+	require.Len(t, frame.GetMessages(), 2)
+	for _, msg := range frame.GetMessages() {
+		assert.Equal(t, int64(10), msg.GetHistoryLength())
+		assert.Equal(t, int64(4096), msg.GetHistorySize())
+	}
+
+	out := &internal.Context{}
+	require.NoError(t, json.Unmarshal(pl.Context, out))
+	assert.Equal(t, 4096, out.HistorySize)
+
+	// the field is omitted when unknown, older SDKs ignore it anyway
+	pl = &payload.Payload{}
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, &internal.Message{ID: 1}))
+	assert.NotContains(t, string(pl.Context), "history_size")
+}
+
+func Test_EncodeBufferReuse(t *testing.T) {
+	codec := NewCodec(zap.NewNop(), converter.GetDefaultDataConverter(), WithBufferPool(1024, 4096))
+
+	pl := &payload.Payload{}
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, &internal.Message{ID: 1}))
+	body := append([]byte(nil), pl.Body...)
+	codec.Release(pl)
+	assert.Nil(t, pl.Body)
+
+	// the released buffer is reused, the content is the same
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, &internal.Message{ID: 1}))
+	assert.Equal(t, body, pl.Body)
+
+	frame := &protocolV1.Frame{}
+	require.NoError(t, proto.Unmarshal(pl.Body, frame))
+	assert.Equal(t, uint64(1), frame.GetMessages()[0].GetId())
+
+	// oversize buffers are not kept
+	codec.bufPool.put(make([]byte, 0, 8192))
+	codec.Release(pl)
+}
+
+func benchmarkEncode(b *testing.B, opts ...Option) {
+	codec := NewCodec(zap.NewNop(), converter.GetDefaultDataConverter(), opts...)
+	dc := converter.GetDefaultDataConverter()
+	pls, err := dc.ToPayloads(make([]byte, 16*1024))
+	require.NoError(b, err)
+
+	ctx := &internal.Context{TaskQueue: "default", RrID: "rr-id"}
+	msgs := make([]*internal.Message, 0, 10)
+	for i := range 10 {
+		msgs = append(msgs, &internal.Message{ID: uint64(i), Payloads: pls}) //nolint:gosec
+	}
+
+	b.ReportAllocs()
+	pl := &payload.Payload{}
+	for b.Loop() {
+		err = codec.Encode(ctx, pl, msgs...)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		codec.Release(pl)
+	}
+}
+
+func BenchmarkEncode(b *testing.B) {
+	benchmarkEncode(b)
+}
+
+func BenchmarkEncodeBufferPool(b *testing.B) {
+	benchmarkEncode(b, WithBufferPool(4*1024, 1024*1024))
+}
+
+func Test_OptionsCompression(t *testing.T) {
+	codec := NewCodec(zap.NewNop(), converter.GetDefaultDataConverter())
+	codec.SetOptionsCompression(64)
+
+	long := &internal.CancelTimer{Name: strings.Repeat("timer", 100)}
+	short := &internal.CancelTimer{Name: "timer"}
+
+	pl := &payload.Payload{}
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, &internal.Message{ID: 1, Command: long}, &internal.Message{ID: 2, Command: short}))
+
+	frame := &protocolV1.Frame{}
+	require.NoError(t, proto.Unmarshal(pl.Body, frame))
+	require.Len(t, frame.GetMessages(), 2)
+	// only the options over the threshold are compressed
+	assert.Equal(t, gzipMagic, frame.GetMessages()[0].GetOptions()[:2])
+	assert.Less(t, len(frame.GetMessages()[0].GetOptions()), len(long.Name))
+	assert.JSONEq(t, `{"name":"timer"}`, string(frame.GetMessages()[1].GetOptions()))
+
+	// both compressed and plain options are decoded
+	msgs := make([]*internal.Message, 0, 2)
+	require.NoError(t, codec.Decode(pl, &msgs))
+	require.Len(t, msgs, 2)
+	assert.Equal(t, long, msgs[0].Command)
+	assert.Equal(t, short, msgs[1].Command)
+
+	// disabled, e.g. the restarted worker doesn't support it
+	codec.SetOptionsCompression(0)
+	pl = &payload.Payload{}
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, &internal.Message{ID: 1, Command: long}))
+	require.NoError(t, proto.Unmarshal(pl.Body, frame))
+	assert.Equal(t, byte('{'), frame.GetMessages()[0].GetOptions()[0])
+}
+
+func Test_HeaderDeduplication(t *testing.T) {
+	codec := NewCodec(zap.NewNop(), converter.GetDefaultDataConverter())
+	codec.SetHeaderDeduplication(64)
+
+	large := &commonpb.Header{Fields: map[string]*commonpb.Payload{"auth": {Data: []byte(strings.Repeat("token", 50))}}}
+	// equal, but not the same pointer
+	largeCopy := proto.Clone(large).(*commonpb.Header)
+	small := &commonpb.Header{Fields: map[string]*commonpb.Payload{"locale": {Data: []byte("en")}}}
+
+	msgs := []*internal.Message{
+		{ID: 1, Command: &internal.CancelTimer{Name: "a"}, Header: large},
+		{ID: 2, Command: &internal.CancelTimer{Name: "b"}, Header: small},
+		{ID: 3, Command: &internal.CancelTimer{Name: "c"}, Header: large},
+		{ID: 4, Command: &internal.CancelTimer{Name: "d"}, Header: largeCopy},
+		{ID: 5, Command: &internal.CancelTimer{Name: "e"}, Header: small},
+	}
+
+	pl := &payload.Payload{}
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, msgs...))
+
+	frame := &protocolV1.Frame{}
+	require.NoError(t, proto.Unmarshal(pl.Body, frame))
+	require.Len(t, frame.GetMessages(), 5)
+	// the large header is sent once, the small ones with every message
+	assert.True(t, proto.Equal(large, frame.GetMessages()[0].GetHeader()))
+	assert.True(t, proto.Equal(small, frame.GetMessages()[1].GetHeader()))
+	assert.Equal(t, []byte("0"), frame.GetMessages()[2].GetHeader().GetFields()[headerRefField].GetData())
+	assert.Equal(t, []byte("0"), frame.GetMessages()[3].GetHeader().GetFields()[headerRefField].GetData())
+	assert.True(t, proto.Equal(small, frame.GetMessages()[4].GetHeader()))
+	// the messages headers are not modified
+	assert.Contains(t, msgs[2].Header.GetFields(), "auth")
+
+	// the references are resolved on decode
+	out := make([]*internal.Message, 0, 5)
+	require.NoError(t, codec.Decode(pl, &out))
+	require.Len(t, out, 5)
+	for i := range out {
+		assert.True(t, proto.Equal(msgs[i].Header, out[i].Header))
+	}
+
+	// disabled, e.g. the restarted worker doesn't support it
+	codec.SetHeaderDeduplication(0)
+	pl = &payload.Payload{}
+	require.NoError(t, codec.Encode(&internal.Context{TaskQueue: "default"}, pl, msgs...))
+	require.NoError(t, proto.Unmarshal(pl.Body, frame))
+	assert.True(t, proto.Equal(large, frame.GetMessages()[2].GetHeader()))
+}
+
+func Test_HeaderReferenceOutOfFrame(t *testing.T) {
+	codec := NewCodec(zap.NewNop(), converter.GetDefaultDataConverter())
+
+	ref := &commonpb.Header{Fields: map[string]*commonpb.Payload{headerRefField: {Data: []byte("1")}}}
+	body, err := proto.Marshal(&protocolV1.Frame{Messages: []*protocolV1.Message{{Id: 1, Header: ref}, {Id: 2}}})
+	require.NoError(t, err)
+
+	out := make([]*internal.Message, 0, 2)
+	assert.Error(t, codec.Decode(&payload.Payload{Body: body}, &out))
+}
@@ -6,14 +6,19 @@ import (
 	"go.uber.org/zap"
 )
 
+// Hook receives the warn and error entries logged by the SDK, e.g. to react on the workflow task failures.
+type Hook func(msg string, keyvals []any)
+
 type ZapAdapter struct {
-	zl *zap.Logger
+	zl    *zap.Logger
+	hooks []Hook
 }
 
 // NewZapAdapter ... which uses general log interface
-func NewZapAdapter(zapLogger *zap.Logger) *ZapAdapter {
+func NewZapAdapter(zapLogger *zap.Logger, hooks ...Hook) *ZapAdapter {
 	return &ZapAdapter{
-		zl: zapLogger.WithOptions(zap.AddCallerSkip(1)),
+		zl:    zapLogger.WithOptions(zap.AddCallerSkip(1)),
+		hooks: hooks,
 	}
 }
 
@@ -27,10 +32,18 @@ func (log *ZapAdapter) Info(msg string, keyvals ...any) {
 
 func (log *ZapAdapter) Warn(msg string, keyvals ...any) {
 	log.zl.Warn(msg, log.fields(keyvals)...)
+	log.runHooks(msg, keyvals)
 }
 
 func (log *ZapAdapter) Error(msg string, keyvals ...any) {
 	log.zl.Error(msg, log.fields(keyvals)...)
+	log.runHooks(msg, keyvals)
+}
+
+func (log *ZapAdapter) runHooks(msg string, keyvals []any) {
+	for _, h := range log.hooks {
+		h(msg, keyvals)
+	}
 }
 
 func (log *ZapAdapter) fields(keyvals []any) []zap.Field {
@@ -5,11 +5,13 @@ import (
 
 	"github.com/roadrunner-server/errors"
 	commonpb "go.temporal.io/api/common/v1"
+	enumspb "go.temporal.io/api/enums/v1"
 	"go.temporal.io/api/failure/v1"
 	"go.temporal.io/sdk/activity"
 	bindings "go.temporal.io/sdk/internalbindings"
 	"go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/workflow"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/durationpb"
 )
 
@@ -23,6 +25,7 @@ const (
 	invokeQueryCommand         = "InvokeQuery"
 	invokeUpdateCommand        = "InvokeUpdate"
 	destroyWorkflowCommand     = "DestroyWorkflow"
+	nonDeterminismCommand      = "NonDeterminismDetected"
 	cancelWorkflowCommand      = "CancelWorkflow"
 	getStackTraceCommand       = "StackTrace"
 
@@ -41,14 +44,51 @@ const (
 	upsertWorkflowSearchAttributesCommand      = "UpsertWorkflowSearchAttributes"
 	upsertWorkflowTypedSearchAttributesCommand = "UpsertWorkflowTypedSearchAttributes"
 	upsertMemo                                 = "UpsertMemo"
+	setCurrentDetailsCommand                   = "SetCurrentDetails"
+	getWorkflowInfoCommand                     = "GetWorkflowInfo"
+	getCurrentTimeCommand                      = "GetCurrentTime"
+	getContinueAsNewSuggestionCommand          = "GetContinueAsNewSuggestion"
+	getRandomCommand                           = "GetRandom"
+	emitMetricCommand                          = "EmitMetric"
+	reportUpdateProgressCommand                = "ReportUpdateProgress"
+	reportProgressCommand                      = "ReportProgress"
+	forceNewWorkflowTaskCommand                = "ForceNewWorkflowTask"
+	getPendingCommandsCommand                  = "GetPendingCommands"
+	logCommand                                 = "Log"
+	publishQueryStateCommand                   = "PublishQueryState"
+	getParentWorkflowExecutionCommand          = "GetParentWorkflowExecution"
 
 	signalExternalWorkflowCommand = "SignalExternalWorkflow"
 	cancelExternalWorkflowCommand = "CancelExternalWorkflow"
 
 	undefinedResponse = "UndefinedResponse"
 
-	cancelCommand = "Cancel"
-	panicCommand  = "Panic"
+	cancelCommand            = "Cancel"
+	cancelAllCommand         = "CancelAll"
+	cancellationScopeCommand = "CancellationScope"
+	cancelTimerCommand       = "CancelTimer"
+	setActivityOptions       = "SetActivityOptions"
+	selectCommand            = "Select"
+	panicCommand             = "Panic"
+	acquireSemaphoreCommand  = "AcquireSemaphore"
+	releaseSemaphoreCommand  = "ReleaseSemaphore"
+)
+
+type MetricType string
+
+const (
+	CounterMetric MetricType = "counter"
+	GaugeMetric   MetricType = "gauge"
+	TimerMetric   MetricType = "timer"
+)
+
+type LogLevel string
+
+const (
+	DebugLevel LogLevel = "debug"
+	InfoLevel  LogLevel = "info"
+	WarnLevel  LogLevel = "warn"
+	ErrorLevel LogLevel = "error"
 )
 
 type TypedSearchAttributeType string
@@ -89,8 +129,23 @@ type Context struct {
 	// and it is suggested.
 	// This value may change throughout the life of the workflow.
 	ContinueAsNewSuggested bool `json:"continue_as_new_suggested"`
+	// ContinueAsNewThresholdReached is true when the history crossed the continue_as_new_history_length or
+	// continue_as_new_history_size threshold configured in RR, independent of the server suggestion.
+	ContinueAsNewThresholdReached bool `json:"continue_as_new_threshold_reached,omitempty"`
+	// Meta is set by the context decorators (e.g. request ID, tenant), empty if no decorators registered.
+	Meta map[string]string `json:"meta,omitempty"`
 }
 
+// ReplayState is the workflow replay state at the moment the message was built.
+type ReplayState uint8
+
+const (
+	// ReplayUnknown means the replay state of the batch context is used.
+	ReplayUnknown ReplayState = iota
+	ReplayFalse
+	ReplayTrue
+)
+
 // Message used to exchange the send commands and receive responses from underlying workers.
 type Message struct {
 	// ID contains ID of the command, response or error.
@@ -103,6 +158,8 @@ type Message struct {
 	Payloads *commonpb.Payloads `json:"payloads,omitempty"`
 	// Header
 	Header *commonpb.Header `json:"header,omitempty"`
+	// Replay overrides the batch context replay flag for this message.
+	Replay ReplayState `json:"-"`
 }
 
 // IsEmpty only check if task queue set.
@@ -129,6 +186,7 @@ func (msg *Message) Reset() {
 	msg.Failure = nil
 	msg.Payloads = nil
 	msg.Header = nil
+	msg.Replay = ReplayUnknown
 }
 
 // GetWorkerInfo reads worker information.
@@ -142,11 +200,15 @@ type InvokeActivity struct {
 	// Name defines activity name.
 	Name string `json:"name"`
 
-	// Info contains execution context.
+	// Info contains execution context: attempt, scheduled time, deadline and the task token used
+	// for the heartbeats and the async activity completion.
 	Info activity.Info `json:"info"`
 
 	// HeartbeatDetails indicates that the payload also contains last heartbeat details.
 	HeartbeatDetails int `json:"heartbeatDetails,omitempty"`
+
+	// DataConverter requested by the workflow, the worker must decode the input and encode the result with it.
+	DataConverter string `json:"dataConverter,omitempty"`
 }
 
 // InvokeLocalActivity invokes local activity.
@@ -156,6 +218,10 @@ type InvokeLocalActivity struct {
 
 	// Info contains execution context.
 	Info activity.Info `json:"info"`
+
+	// DataConverter requested by the workflow, the worker must decode the input and encode the result with it.
+	// For the raw converter every payload data is the bytes as is (binary/plain encoding).
+	DataConverter string `json:"dataConverter,omitempty"`
 }
 
 // StartWorkflow sends worker command to start workflow.
@@ -166,6 +232,10 @@ type StartWorkflow struct {
 	LastCompletion int `json:"lastCompletion,omitempty"`
 	// Typed search attributes
 	SearchAttributes map[string]*TypedSearchAttribute `json:"search_attributes,omitempty"`
+	// UntypedSearchAttributes are the search attributes set by the starter, decoded, including the ones without the type.
+	UntypedSearchAttributes map[string]any `json:"untyped_search_attributes,omitempty"`
+	// Memo set by the starter, decoded with the data converter.
+	Memo map[string]any `json:"memo,omitempty"`
 }
 
 // InvokeSignal invokes signal with a set of arguments.
@@ -192,7 +262,7 @@ type InvokeUpdate struct {
 	RunID string `json:"runId"`
 	// Name of the query.
 	Name string `json:"name"`
-	// Type of the update request.
+	// Type of the update request: validate_execute or exec (no validation, the update is already accepted).
 	Type string `json:"type"`
 }
 
@@ -208,6 +278,18 @@ type DestroyWorkflow struct {
 	RunID string `json:"runId"`
 }
 
+// NonDeterminismDetected reports the non-deterministic replay detected by the SDK to the worker, sent when the workflow
+// state is dropped after the failed workflow task (before DestroyWorkflow), the response is ignored.
+type NonDeterminismDetected struct {
+	// RunID workflow run id.
+	RunID        string `json:"runId"`
+	WorkflowType string `json:"workflowType"`
+	// Error is the SDK non-determinism error, describes the command expected by the history and the replayed one.
+	Error string `json:"error"`
+	// HistoryLength is the number of the history events when the divergence was detected.
+	HistoryLength int `json:"historyLength"`
+}
+
 // GetStackTrace asks worker to offload workflow from memory.
 type GetStackTrace struct {
 	// RunID workflow run id.
@@ -218,32 +300,69 @@ type GetStackTrace struct {
 type ExecuteActivity struct {
 	// Name defines activity name.
 	Name string `json:"name"`
-	// Options to run activity.
+	// Options to run activity. Options.TaskQueueName routes the activity to another task queue,
+	// the workflow task queue is used when it's empty.
+	// ScheduleToClose, ScheduleToStart, StartToClose and Heartbeat timeouts are passed to the server as is,
+	// each one is applied independently.
 	Options bindings.ExecuteActivityOptions `json:"options"`
+	// DataConverter selects the converter for the activity input and result: empty - the default one, raw -
+	// binary/plain payloads only, any other converter registered in the worker (e.g. encrypting the payloads) is
+	// passed as is. Sent to the activity worker with the InvokeActivity command, see DataConverterHeader.
+	DataConverter string `json:"dataConverter,omitempty"`
+	// VersioningIntent selects the build ID of the worker versioning (build ID based) the activity runs on: compatible -
+	// a build compatible with the workflow one (e.g. not on the new workers mid-execution), default - the default build
+	// of the task queue. Empty - the SDK default (compatible for the same task queue).
+	VersioningIntent string `json:"versioningIntent,omitempty"`
 }
 
+const (
+	// VersioningIntentCompatible runs the activity or child workflow on a build compatible with the workflow build.
+	VersioningIntentCompatible string = "compatible"
+	// VersioningIntentDefault runs the activity or child workflow on the default build of the task queue.
+	VersioningIntentDefault string = "default"
+)
+
+// DataConverterHeader is the activity header key carrying the data converter requested by the ExecuteActivity
+// command: the activity might be processed by another RR instance. Removed from the header sent to the worker.
+const DataConverterHeader = "rr-data-converter"
+
 // ExecuteLocalActivityOptions Since we use proto everywhere, we need to convert Activity options (proto) to non-proto LA options
 type ExecuteLocalActivityOptions struct {
 	ScheduleToCloseTimeout time.Duration
 	StartToCloseTimeout    time.Duration
 	RetryPolicy            *commonpb.RetryPolicy
 	Summary                string
+	// RetryJitter (0-1) randomly shortens the retry backoff by up to this part, the backoff is capped by the
+	// RetryPolicy maximum interval. The retries with the backoff too long to be done in the worker are scheduled by
+	// the workflow (timer) only when set, otherwise the failure is returned.
+	RetryJitter float64
 }
 
+// RawDataConverter passes the local activity input and result as binary/plain payloads, the payload data is the bytes
+// as is, without the JSON encoding.
+const RawDataConverter = "raw"
+
 // ExecuteLocalActivity command by workflow worker.
 type ExecuteLocalActivity struct {
 	// Name defines activity name.
 	Name string `json:"name"`
 	// Options to run activity.
 	Options ExecuteLocalActivityOptions `json:"options"`
+	// DataConverter selects the converter for the activity input and result: empty - the default one,
+	// raw - binary/plain payloads only. Passed to the activity worker with the InvokeLocalActivity command.
+	DataConverter string `json:"dataConverter,omitempty"`
 }
 
 // ExecuteChildWorkflow executes child workflow.
 type ExecuteChildWorkflow struct {
 	// Name defines workflow name.
 	Name string `json:"name"`
-	// Options to run activity.
+	// Options to run the child workflow, including Memo.
 	Options bindings.WorkflowOptions `json:"options"`
+	// SearchAttributes are the typed search attributes of the child workflow.
+	SearchAttributes map[string]*TypedSearchAttribute `json:"search_attributes,omitempty"`
+	// VersioningIntent is compatible or default, see ExecuteActivity.VersioningIntent.
+	VersioningIntent string `json:"versioningIntent,omitempty"`
 }
 
 // GetChildWorkflowExecution returns the WorkflowID and RunId of child workflow.
@@ -258,6 +377,173 @@ type UpsertMemo struct {
 	Memo map[string]any `json:"memo"`
 }
 
+// SetCurrentDetails sets the workflow current details, a human-readable (markdown) string shown in the UI.
+type SetCurrentDetails struct {
+	// Details to set.
+	Details string `json:"details"`
+}
+
+// PublishQueryState publishes the results of the queries answered on the RR side without the worker round-trip,
+// the results are passed in the message payloads in the Queries order. The published result is returned as is until
+// it's published again or removed: it's the state of the workflow at the moment of the command, the worker should
+// publish the new results whenever the state changes. Only the queries without arguments are answered, the queries
+// with arguments and the unpublished ones are sent to the worker. Not a history event, restored on replay as any
+// other command.
+type PublishQueryState struct {
+	// Queries are the names of the published queries.
+	Queries []string `json:"queries,omitempty"`
+	// Remove are the names of the queries sent to the worker again.
+	Remove []string `json:"remove,omitempty"`
+}
+
+// GetWorkflowInfo requests the current workflow info (attempt, cron schedule, parent and root executions, etc.).
+type GetWorkflowInfo struct{}
+
+// GetParentWorkflowExecution requests the parent execution of the child workflow, e.g. to signal the parent back.
+// The response is ParentWorkflowExecution, null for the root workflows.
+type GetParentWorkflowExecution struct{}
+
+// ParentWorkflowExecution is the response to the GetParentWorkflowExecution command.
+type ParentWorkflowExecution struct {
+	Namespace  string `json:"namespace"`
+	WorkflowID string `json:"workflowID"`
+	RunID      string `json:"runID"`
+}
+
+// GetCurrentTime requests the current workflow time in the timezone.
+type GetCurrentTime struct {
+	// Timezone is the IANA timezone name, e.g. Europe/Berlin. Empty or UTC - UTC.
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ForceNewWorkflowTask ends the current workflow task and resolves in a new one, so the commands issued so far are
+// checkpointed in the history. The boundary is made with the 1ms timer: TimerStarted, TimerFired and the new workflow
+// task events (5 events) are added to the history. No-op (resolved with false) when no other command was issued in the
+// current workflow task.
+type ForceNewWorkflowTask struct{}
+
+// GetPendingCommands requests the messages not processed yet, for debugging (e.g. stuck workflow task loops): the
+// messages queued to be sent to the worker and the worker commands waiting in the pipeline, the workflow worker PIDs
+// which handled the recent updates (to correlate the update failures with the worker processes). Read-only, requires the
+// workflows.debug_commands option. The response is PendingCommands.
+type GetPendingCommands struct{}
+
+// PendingCommands is the response to the GetPendingCommands command.
+type PendingCommands struct {
+	// Queue contains the messages (commands and responses) queued to be sent to the worker.
+	Queue []PendingCommand `json:"queue"`
+	// Pipeline contains the worker commands received, but not handled yet.
+	Pipeline []PendingCommand `json:"pipeline"`
+	// Updates contains the workflow worker PIDs which handled the recent updates, in the order they were received.
+	Updates []UpdateWorker `json:"updates,omitempty"`
+}
+
+// UpdateWorker is the PID of the workflow worker which validated and completed the update, 0 - not handled yet.
+type UpdateWorker struct {
+	UpdateID    string `json:"id"`
+	ValidatedBy int    `json:"validated_by,omitempty"`
+	CompletedBy int    `json:"completed_by,omitempty"`
+}
+
+// PendingCommand describes the pending message: the command name, "response" or "failure" for the command responses.
+type PendingCommand struct {
+	ID      uint64 `json:"id"`
+	Command string `json:"command"`
+}
+
+// GetContinueAsNewSuggestion requests the current history length and size and the continue-as-new suggestion.
+type GetContinueAsNewSuggestion struct{}
+
+// ContinueAsNewSuggestion is the response to the GetContinueAsNewSuggestion command.
+type ContinueAsNewSuggestion struct {
+	HistoryLength int `json:"history_length"`
+	HistorySize   int `json:"history_size"`
+	// Suggested is true if the server suggests continue-as-new or one of the configured thresholds is reached.
+	Suggested bool `json:"suggested"`
+	// HistoryLengthThreshold and HistorySizeThreshold are set when configured on the RR side.
+	HistoryLengthThreshold int `json:"history_length_threshold,omitempty"`
+	HistorySizeThreshold   int `json:"history_size_threshold,omitempty"`
+}
+
+// GetRandom requests the deterministic random values. The random source of the workflow is seeded with a single
+// side effect on the first use, the following draws don't add events to the history. The values are the same
+// on replay as long as the workflow requests them in the same order.
+type GetRandom struct {
+	// Count of the values, default: 1.
+	Count int `json:"count,omitempty"`
+	// Max (exclusive) of the values, 0 - any non-negative int64.
+	Max int64 `json:"max,omitempty"`
+}
+
+// EmitMetric emits a metric through the workflow metrics handler, not emitted during replay.
+type EmitMetric struct {
+	Type MetricType `json:"type"`
+	Name string     `json:"name"`
+	// Value is the counter increment, the gauge value or the timer duration in milliseconds.
+	Value float64 `json:"value"`
+	// Tags are added to the workflow tags (namespace, task queue, workflow type).
+	Tags map[string]string `json:"tags,omitempty"`
+}
+
+// Log writes the worker log entry through the workflow logger: the entry is tagged with the workflow ID, run ID and
+// type and not written during replay.
+type Log struct {
+	// Level is the log level, default: info. The unknown levels are written as info.
+	Level   LogLevel `json:"level,omitempty"`
+	Message string   `json:"message"`
+	// Fields are the structured fields of the entry.
+	Fields map[string]any `json:"fields,omitempty"`
+}
+
+// UpdateProgressMemoPrefix is the prefix of the memo key holding the update progress: update_progress:<update id>.
+const UpdateProgressMemoPrefix = "update_progress:"
+
+// ReportUpdateProgress stores the progress of the in-flight update in the workflow memo, so the clients can poll it
+// (DescribeWorkflowExecution). The memo is a part of the history, the command is replayed as any other command.
+type ReportUpdateProgress struct {
+	// UpdateID of the in-flight update.
+	UpdateID string `json:"updateId"`
+	// Progress is any JSON value, null removes the progress from the memo (e.g. when the update is completed).
+	Progress any `json:"progress"`
+}
+
+// MemoKey returns the memo key of the update progress.
+func (cmd *ReportUpdateProgress) MemoKey() string {
+	return UpdateProgressMemoPrefix + cmd.UpdateID
+}
+
+// ProgressMemoKey is the memo key holding the workflow progress reported with the ReportProgress command.
+const ProgressMemoKey = "progress"
+
+// ReportProgress stores the workflow progress in the workflow memo using the standard structure (WorkflowProgress),
+// so the progress of any workflow type could be read the same way (DescribeWorkflowExecution).
+type ReportProgress struct {
+	// Percent of the completed work, 0-100.
+	Percent float64 `json:"percent"`
+	// Stage is the name of the current stage (optional).
+	Stage string `json:"stage,omitempty"`
+	// Message is the human-readable progress description (optional).
+	Message string `json:"message,omitempty"`
+}
+
+// Validate checks the reported progress.
+func (cmd *ReportProgress) Validate() error {
+	if cmd.Percent < 0 || cmd.Percent > 100 {
+		return errors.Errorf("progress percent should be in the 0-100 range, got: %v", cmd.Percent)
+	}
+
+	return nil
+}
+
+// WorkflowProgress is the memo value stored under the ProgressMemoKey.
+type WorkflowProgress struct {
+	Percent float64 `json:"percent"`
+	Stage   string  `json:"stage,omitempty"`
+	Message string  `json:"message,omitempty"`
+	// UpdatedAt is the workflow time of the report.
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
 // NewTimer starts a new timer.
 type NewTimer struct {
 	// Milliseconds defines timer duration.
@@ -268,6 +554,9 @@ type NewTimer struct {
 	//
 	// NOTE: Experimental
 	Summary string `json:"summary"`
+	// Name is the logical name of the timer used to cancel it with the CancelTimer command.
+	// Several pending timers might share the same name. Names are not kept across continue-as-new.
+	Name string `json:"name,omitempty"`
 }
 
 // SideEffect to be recorded into the history.
@@ -280,8 +569,25 @@ type GetVersion struct {
 	MaxSupported int    `json:"maxSupported"`
 }
 
+// FailureCategory defines how the workflow completion failure is reported to Temporal.
+type FailureCategory string
+
+const (
+	// FailureApplication completes the workflow as failed with the application failure. Default.
+	FailureApplication FailureCategory = "application"
+	// FailureCanceled completes the workflow as canceled.
+	FailureCanceled FailureCategory = "canceled"
+	// FailureTimeout completes the workflow as failed with the (start-to-close) timeout failure.
+	FailureTimeout FailureCategory = "timeout"
+)
+
 // CompleteWorkflow sent by the worker to complete workflow. Might include additional error as part of the payload.
-type CompleteWorkflow struct{}
+type CompleteWorkflow struct {
+	// FailureCategory of the failure, empty - the failure is passed as is.
+	FailureCategory FailureCategory `json:"failure_category,omitempty"`
+	// FailureType is the application failure type (application category only), empty - the type of the failure.
+	FailureType string `json:"failure_type,omitempty"`
+}
 
 // UpdateCompleted sent by worker to complete update
 type UpdateCompleted struct {
@@ -303,6 +609,10 @@ type ContinueAsNew struct {
 		TaskQueueName       string
 		WorkflowRunTimeout  time.Duration
 		WorkflowTaskTimeout time.Duration
+		// Memo is upserted before the continue-as-new, the new run inherits the resulting memo.
+		// A nil value removes the key, so the new run starts without it.
+		// Search attributes are copied to the new run by the Temporal server and can't be removed here.
+		Memo map[string]any
 	} `json:"options"`
 }
 
@@ -329,6 +639,9 @@ type SignalExternalWorkflow struct {
 	RunID             string `json:"runID"`
 	Signal            string `json:"signal"`
 	ChildWorkflowOnly bool   `json:"childWorkflowOnly"`
+	// DelayMilliseconds sends the signal after the delay using a workflow timer (not a server feature),
+	// the pending signal is canceled with the command or the workflow.
+	DelayMilliseconds int `json:"delayMs,omitempty"`
 }
 
 // CancelExternalWorkflow canceler external workflow.
@@ -347,12 +660,148 @@ type UndefinedResponse struct {
 type Cancel struct {
 	// CommandIDs to be canceled.
 	CommandIDs []uint64 `json:"ids"`
+	// Scopes to be canceled together with all the commands and nested scopes registered under them.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+// CancellationScope registers commands (activities, local activities, timers, child workflows) under the cancellation scope.
+type CancellationScope struct {
+	// ScopeID of the scope, created on the first use.
+	ScopeID string `json:"scope"`
+	// ParentID of the parent scope, the scope is cancelled together with the parent.
+	ParentID string `json:"parent,omitempty"`
+	// CommandIDs to register under the scope.
+	CommandIDs []uint64 `json:"ids,omitempty"`
+	// Disconnected scope is not cancelled together with its parent (ParentID is ignored), like the Go SDK
+	// NewDisconnectedContext: used to run the cleanup commands (e.g. activities) after the workflow is cancelled.
+	// The scope stays disconnected once set.
+	Disconnected bool `json:"disconnected,omitempty"`
+}
+
+// CancelAll cancels all the pending commands of the workflow (timers, activities, child workflows...), e.g. to recover
+// from a panic. The commands are cancelled in the order of their IDs, so the replayed CancelAll produces the same
+// cancellations as long as the workflow issued the same commands before it.
+type CancelAll struct{}
+
+// CancelTimer cancels the pending timers started with the name, the timers sharing the name are cancelled
+// in the order they were started. Unknown or already fired timers are ignored.
+type CancelTimer struct {
+	// Name of the timer set in the NewTimer command.
+	Name string `json:"name"`
+}
+
+// SetActivityOptions sets the default options of the activities executed after the command in the workflow run.
+// The options replace the previously set defaults, empty options remove them. The defaults are not inherited by the
+// child workflows and the continued-as-new run.
+type SetActivityOptions struct {
+	Options bindings.ExecuteActivityOptions `json:"options"`
+}
+
+// Select waits for the first of the commands (activities, local activities, timers, child workflows) to complete
+// or the first of the signals to be received after the Select command, the response is SelectResult.
+// The commands completed before the Select command are not tracked, the worker should check them first.
+// When several candidates are ready in the same workflow task, the first one in the history order is selected.
+// Select is cancelled with the Cancel command using its ID.
+type Select struct {
+	// CommandIDs of the awaited commands.
+	CommandIDs []uint64 `json:"ids,omitempty"`
+	// Signals are the names of the awaited signals.
+	Signals []string `json:"signals,omitempty"`
 }
 
+// SelectResult is the response to the Select command, contains the first completed command or received signal.
+type SelectResult struct {
+	ID     uint64 `json:"id,omitempty"`
+	Signal string `json:"signal,omitempty"`
+}
+
+// AcquireSemaphore acquires the permits of the named workflow-scoped semaphore, the response ("completed") is sent when
+// the permits are available. The waiters are served in the order of the commands, so the result is the same on replay.
+// The semaphore coordinates the coroutines of the same workflow run only, it's not a cross-workflow lock and it's not
+// kept across continue-as-new. The waiting AcquireSemaphore is cancelled with the Cancel command using its ID.
+type AcquireSemaphore struct {
+	// Name of the semaphore.
+	Name string `json:"name"`
+	// Capacity is the number of the semaphore permits, set by the first command of the semaphore, default: 1 (mutex).
+	Capacity int64 `json:"capacity,omitempty"`
+	// Permits to acquire, default: 1.
+	Permits int64 `json:"permits,omitempty"`
+}
+
+// ReleaseSemaphore releases the permits of the named semaphore acquired with the AcquireSemaphore command.
+type ReleaseSemaphore struct {
+	// Name of the semaphore.
+	Name string `json:"name"`
+	// Permits to release, default: 1.
+	Permits int64 `json:"permits,omitempty"`
+}
+
+// UnknownCommand is a command not supported by this RoadRunner version, e.g. sent by a newer SDK.
+type UnknownCommand struct {
+	// Name of the command.
+	Name string `json:"-"`
+}
+
+// PanicPolicy defines how the workflow panic is reported to Temporal.
+type PanicPolicy string
+
+const (
+	// PanicFailTask fails the workflow task, the task is retried until the worker (code) is fixed. Default.
+	PanicFailTask PanicPolicy = "fail_task"
+	// PanicFailWorkflow fails the workflow execution with the panic failure. The failure is recorded in the history
+	// and terminal, the same code path must fail the same way on replay, otherwise it's a non-determinism error.
+	// The PanicError failure type is recorded as the PanicError application failure with the message and the stack
+	// trace only, the details are dropped by the SDK.
+	PanicFailWorkflow PanicPolicy = "fail_workflow"
+)
+
 // Panic triggers panic in a workflow process.
 type Panic struct {
 	// Message to include in the error.
 	Message string `json:"message"`
+	// Policy chosen by the worker, empty - fail_task.
+	Policy PanicPolicy `json:"policy,omitempty"`
+}
+
+// InheritOptions fills the options omitted in the command with the defaults set by the SetActivityOptions command:
+// the task queue, the timeouts and the retry policy. Boolean options, the activity ID and summary are never inherited.
+func (cmd *ExecuteActivity) InheritOptions(defaults *bindings.ExecuteActivityOptions) {
+	if defaults == nil {
+		return
+	}
+
+	if cmd.Options.TaskQueueName == "" {
+		cmd.Options.TaskQueueName = defaults.TaskQueueName
+	}
+	if cmd.Options.ScheduleToCloseTimeout == 0 {
+		cmd.Options.ScheduleToCloseTimeout = defaults.ScheduleToCloseTimeout
+	}
+	if cmd.Options.ScheduleToStartTimeout == 0 {
+		cmd.Options.ScheduleToStartTimeout = defaults.ScheduleToStartTimeout
+	}
+	if cmd.Options.StartToCloseTimeout == 0 {
+		cmd.Options.StartToCloseTimeout = defaults.StartToCloseTimeout
+	}
+	if cmd.Options.HeartbeatTimeout == 0 {
+		cmd.Options.HeartbeatTimeout = defaults.HeartbeatTimeout
+	}
+	if cmd.Options.RetryPolicy == nil {
+		cmd.Options.RetryPolicy = defaults.RetryPolicy
+	}
+}
+
+// VersioningIntent maps the versioning intent of the command to the SDK one, empty - unspecified.
+func VersioningIntent(intent string) (temporal.VersioningIntent, error) {
+	switch intent {
+	case "":
+		return temporal.VersioningIntentUnspecified, nil
+	case VersioningIntentCompatible:
+		return temporal.VersioningIntentCompatible, nil
+	case VersioningIntentDefault:
+		return temporal.VersioningIntentDefault, nil
+	default:
+		return temporal.VersioningIntentUnspecified, errors.Errorf("unknown versioning intent: %s, supported: compatible, default", intent)
+	}
 }
 
 // ActivityParams maps activity command to activity params.
@@ -403,7 +852,7 @@ func (cmd ExecuteLocalActivity) LocalActivityParams(env bindings.WorkflowEnviron
 		ExecuteLocalActivityOptions: truTemOptions,
 		ActivityFn:                  fn,
 		ActivityType:                cmd.Name,
-		InputArgs:                   []any{header, payloads},
+		InputArgs:                   []any{header, payloads, cmd.DataConverter},
 		WorkflowInfo:                env.WorkflowInfo(),
 		ScheduledTime:               time.Now(),
 		Header:                      header,
@@ -435,6 +884,57 @@ func (cmd ExecuteChildWorkflow) WorkflowParams(env bindings.WorkflowEnvironment,
 	return params
 }
 
+// Failure maps the completion failure onto the failure kind of the category, the message, the cause and the details are kept.
+func (cmd CompleteWorkflow) Failure(f *failure.Failure) (*failure.Failure, error) {
+	const op = errors.Op("complete_workflow_failure")
+
+	if cmd.FailureCategory == "" {
+		return f, nil
+	}
+
+	if f == nil {
+		return nil, errors.E(op, errors.Errorf("failure category %s requires the failure", cmd.FailureCategory))
+	}
+
+	var details *commonpb.Payloads
+	switch info := f.GetFailureInfo().(type) {
+	case *failure.Failure_ApplicationFailureInfo:
+		details = info.ApplicationFailureInfo.GetDetails()
+	case *failure.Failure_CanceledFailureInfo:
+		details = info.CanceledFailureInfo.GetDetails()
+	case *failure.Failure_TimeoutFailureInfo:
+		details = info.TimeoutFailureInfo.GetLastHeartbeatDetails()
+	}
+
+	res := proto.Clone(f).(*failure.Failure)
+	switch cmd.FailureCategory {
+	case FailureApplication:
+		errType := cmd.FailureType
+		if errType == "" {
+			errType = f.GetApplicationFailureInfo().GetType()
+		}
+
+		res.FailureInfo = &failure.Failure_ApplicationFailureInfo{ApplicationFailureInfo: &failure.ApplicationFailureInfo{
+			Type:         errType,
+			NonRetryable: f.GetApplicationFailureInfo().GetNonRetryable(),
+			Details:      details,
+		}}
+	case FailureCanceled:
+		res.FailureInfo = &failure.Failure_CanceledFailureInfo{CanceledFailureInfo: &failure.CanceledFailureInfo{
+			Details: details,
+		}}
+	case FailureTimeout:
+		res.FailureInfo = &failure.Failure_TimeoutFailureInfo{TimeoutFailureInfo: &failure.TimeoutFailureInfo{
+			TimeoutType:          enumspb.TIMEOUT_TYPE_START_TO_CLOSE,
+			LastHeartbeatDetails: details,
+		}}
+	default:
+		return nil, errors.E(op, errors.Errorf("unknown failure category: %s", cmd.FailureCategory))
+	}
+
+	return res, nil
+}
+
 // ToDuration converts timer command to time.Duration.
 func (cmd NewTimer) ToDuration() time.Duration {
 	return time.Millisecond * time.Duration(cmd.Milliseconds)
@@ -454,6 +954,8 @@ func CommandName(cmd any) (string, error) {
 		return invokeQueryCommand, nil
 	case DestroyWorkflow, *DestroyWorkflow:
 		return destroyWorkflowCommand, nil
+	case NonDeterminismDetected, *NonDeterminismDetected:
+		return nonDeterminismCommand, nil
 	case CancelWorkflow, *CancelWorkflow:
 		return cancelWorkflowCommand, nil
 	case GetStackTrace, *GetStackTrace:
@@ -494,12 +996,52 @@ func CommandName(cmd any) (string, error) {
 		return cancelExternalWorkflowCommand, nil
 	case Cancel, *Cancel:
 		return cancelCommand, nil
+	case CancelAll, *CancelAll:
+		return cancelAllCommand, nil
+	case CancellationScope, *CancellationScope:
+		return cancellationScopeCommand, nil
+	case CancelTimer, *CancelTimer:
+		return cancelTimerCommand, nil
+	case SetActivityOptions, *SetActivityOptions:
+		return setActivityOptions, nil
+	case Select, *Select:
+		return selectCommand, nil
+	case AcquireSemaphore, *AcquireSemaphore:
+		return acquireSemaphoreCommand, nil
+	case ReleaseSemaphore, *ReleaseSemaphore:
+		return releaseSemaphoreCommand, nil
 	case Panic, *Panic:
 		return panicCommand, nil
 	case UpsertMemo, *UpsertMemo:
 		return upsertMemo, nil
 	case InvokeUpdate, *InvokeUpdate:
 		return invokeUpdateCommand, nil
+	case SetCurrentDetails, *SetCurrentDetails:
+		return setCurrentDetailsCommand, nil
+	case GetWorkflowInfo, *GetWorkflowInfo:
+		return getWorkflowInfoCommand, nil
+	case GetCurrentTime, *GetCurrentTime:
+		return getCurrentTimeCommand, nil
+	case GetContinueAsNewSuggestion, *GetContinueAsNewSuggestion:
+		return getContinueAsNewSuggestionCommand, nil
+	case GetRandom, *GetRandom:
+		return getRandomCommand, nil
+	case EmitMetric, *EmitMetric:
+		return emitMetricCommand, nil
+	case ReportUpdateProgress, *ReportUpdateProgress:
+		return reportUpdateProgressCommand, nil
+	case ReportProgress, *ReportProgress:
+		return reportProgressCommand, nil
+	case ForceNewWorkflowTask, *ForceNewWorkflowTask:
+		return forceNewWorkflowTaskCommand, nil
+	case GetPendingCommands, *GetPendingCommands:
+		return getPendingCommandsCommand, nil
+	case Log, *Log:
+		return logCommand, nil
+	case PublishQueryState, *PublishQueryState:
+		return publishQueryStateCommand, nil
+	case GetParentWorkflowExecution, *GetParentWorkflowExecution:
+		return getParentWorkflowExecutionCommand, nil
 	default:
 		return "", errors.E(op, errors.Errorf("undefined command type: %s", cmd))
 	}
@@ -524,6 +1066,9 @@ func InitCommand(name string) (any, error) {
 	case destroyWorkflowCommand:
 		return &DestroyWorkflow{}, nil
 
+	case nonDeterminismCommand:
+		return &NonDeterminismDetected{}, nil
+
 	case cancelWorkflowCommand:
 		return &CancelWorkflow{}, nil
 
@@ -584,6 +1129,25 @@ func InitCommand(name string) (any, error) {
 	case cancelCommand:
 		return &Cancel{}, nil
 
+	case cancellationScopeCommand:
+		return &CancellationScope{}, nil
+
+	case cancelAllCommand:
+		return &CancelAll{}, nil
+	case cancelTimerCommand:
+		return &CancelTimer{}, nil
+	case setActivityOptions:
+		return &SetActivityOptions{}, nil
+
+	case selectCommand:
+		return &Select{}, nil
+
+	case acquireSemaphoreCommand:
+		return &AcquireSemaphore{}, nil
+
+	case releaseSemaphoreCommand:
+		return &ReleaseSemaphore{}, nil
+
 	case panicCommand:
 		return &Panic{}, nil
 
@@ -593,6 +1157,45 @@ func InitCommand(name string) (any, error) {
 	case invokeUpdateCommand:
 		return &InvokeUpdate{}, nil
 
+	case setCurrentDetailsCommand:
+		return &SetCurrentDetails{}, nil
+
+	case getWorkflowInfoCommand:
+		return &GetWorkflowInfo{}, nil
+
+	case getCurrentTimeCommand:
+		return &GetCurrentTime{}, nil
+
+	case getContinueAsNewSuggestionCommand:
+		return &GetContinueAsNewSuggestion{}, nil
+
+	case getRandomCommand:
+		return &GetRandom{}, nil
+
+	case emitMetricCommand:
+		return &EmitMetric{}, nil
+
+	case reportUpdateProgressCommand:
+		return &ReportUpdateProgress{}, nil
+
+	case reportProgressCommand:
+		return &ReportProgress{}, nil
+
+	case forceNewWorkflowTaskCommand:
+		return &ForceNewWorkflowTask{}, nil
+
+	case getPendingCommandsCommand:
+		return &GetPendingCommands{}, nil
+
+	case logCommand:
+		return &Log{}, nil
+
+	case publishQueryStateCommand:
+		return &PublishQueryState{}, nil
+
+	case getParentWorkflowExecutionCommand:
+		return &GetParentWorkflowExecution{}, nil
+
 	default:
 		return nil, errors.E(op, errors.Errorf("undefined command name: %s, possible outdated RoadRunner version", name))
 	}
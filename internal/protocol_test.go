@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/failure/v1"
+	"go.temporal.io/sdk/activity"
+	bindings "go.temporal.io/sdk/internalbindings"
+	"go.temporal.io/sdk/workflow"
+)
+
+// testEnv implements only the parts of the workflow environment used by the commands
+type testEnv struct {
+	bindings.WorkflowEnvironment
+	info *workflow.Info
+}
+
+func (e *testEnv) WorkflowInfo() *workflow.Info {
+	return e.info
+}
+
+func newTestEnv() *testEnv {
+	return &testEnv{
+		info: &workflow.Info{
+			TaskQueueName: "default",
+		},
+	}
+}
+
+func Test_ActivityParamsDefaultTaskQueue(t *testing.T) {
+	cmd := ExecuteActivity{Name: "SimpleActivity"}
+
+	params := cmd.ActivityParams(newTestEnv(), nil, nil)
+	assert.Equal(t, "default", params.TaskQueueName)
+	assert.Equal(t, "SimpleActivity", params.ActivityType.Name)
+}
+
+func Test_ActivityParamsTaskQueueOverride(t *testing.T) {
+	cmd := ExecuteActivity{Name: "SimpleActivity"}
+	cmd.Options.TaskQueueName = "gpu"
+
+	params := cmd.ActivityParams(newTestEnv(), nil, nil)
+	assert.Equal(t, "gpu", params.TaskQueueName)
+}
+
+func Test_ContinueAsNewMemoTombstone(t *testing.T) {
+	cmd := &ContinueAsNew{}
+	err := json.Unmarshal([]byte(`{"name":"ContinuableWorkflow","options":{"Memo":{"keep":"value","drop":null}}}`), cmd)
+	require.NoError(t, err)
+
+	require.Len(t, cmd.Options.Memo, 2)
+	assert.Equal(t, "value", cmd.Options.Memo["keep"])
+
+	v, ok := cmd.Options.Memo["drop"]
+	assert.True(t, ok)
+	assert.Nil(t, v)
+}
+
+func Test_ActivityParamsTimeouts(t *testing.T) {
+	cmd := &ExecuteActivity{}
+	err := json.Unmarshal([]byte(`{"name":"SimpleActivity","options":{"ScheduleToCloseTimeout":4000000000,"ScheduleToStartTimeout":1000000000,"StartToCloseTimeout":2000000000,"HeartbeatTimeout":3000000000}}`), cmd)
+	require.NoError(t, err)
+
+	params := cmd.ActivityParams(newTestEnv(), nil, nil)
+	assert.Equal(t, time.Second*4, params.ScheduleToCloseTimeout)
+	assert.Equal(t, time.Second, params.ScheduleToStartTimeout)
+	assert.Equal(t, time.Second*2, params.StartToCloseTimeout)
+	assert.Equal(t, time.Second*3, params.HeartbeatTimeout)
+}
+
+func Test_InvokeActivityInfo(t *testing.T) {
+	deadline := time.Date(2024, 1, 1, 0, 0, 10, 0, time.UTC)
+	cmd := InvokeActivity{
+		Name: "SimpleActivity.echo",
+		Info: activity.Info{
+			TaskToken:     []byte("token"),
+			Attempt:       2,
+			ScheduledTime: deadline.Add(-time.Second * 10),
+			Deadline:      deadline,
+		},
+	}
+
+	data, err := json.Marshal(cmd)
+	require.NoError(t, err)
+
+	// the worker reads the activity info from the command options
+	out := &InvokeActivity{}
+	require.NoError(t, json.Unmarshal(data, out))
+	assert.Equal(t, int32(2), out.Info.Attempt)
+	assert.Equal(t, []byte("token"), out.Info.TaskToken)
+	assert.True(t, deadline.Equal(out.Info.Deadline))
+}
+
+func Test_LocalActivityDataConverter(t *testing.T) {
+	cmd := &ExecuteLocalActivity{}
+	err := json.Unmarshal([]byte(`{"name":"LocalActivity.sha512","dataConverter":"raw"}`), cmd)
+	require.NoError(t, err)
+	assert.Equal(t, RawDataConverter, cmd.DataConverter)
+
+	// the converter is passed to the local activity function as the last argument
+	params := cmd.LocalActivityParams(newTestEnv(), nil, nil, nil)
+	require.Len(t, params.InputArgs, 3)
+	assert.Equal(t, RawDataConverter, params.InputArgs[2])
+
+	data, err := json.Marshal(InvokeLocalActivity{Name: cmd.Name, DataConverter: cmd.DataConverter})
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"dataConverter":"raw"`)
+}
+
+func Test_ChildWorkflowTimeouts(t *testing.T) {
+	cmd := &ExecuteChildWorkflow{}
+	require.NoError(t, json.Unmarshal([]byte(`{"name":"Child","options":{"WorkflowExecutionTimeout":30000000000,"WorkflowRunTimeout":2000000000,"WorkflowTaskTimeout":1000000000}}`), cmd))
+
+	params := cmd.WorkflowParams(newTestEnv(), nil, nil)
+	assert.Equal(t, time.Second*30, params.WorkflowExecutionTimeout)
+	assert.Equal(t, time.Second*2, params.WorkflowRunTimeout)
+	assert.Equal(t, time.Second, params.WorkflowTaskTimeout)
+	assert.Equal(t, "default", params.TaskQueueName)
+}
+
+func Test_CompleteWorkflowFailure(t *testing.T) {
+	f := &failure.Failure{Message: "boom", Cause: &failure.Failure{Message: "cause"}}
+
+	// no category, the failure is passed as is
+	res, err := CompleteWorkflow{}.Failure(f)
+	require.NoError(t, err)
+	assert.Same(t, f, res)
+
+	res, err = CompleteWorkflow{FailureCategory: FailureCanceled}.Failure(f)
+	require.NoError(t, err)
+	assert.NotNil(t, res.GetCanceledFailureInfo())
+	assert.Equal(t, "boom", res.GetMessage())
+	assert.Equal(t, "cause", res.GetCause().GetMessage())
+	// the original failure is not modified
+	assert.Nil(t, f.GetFailureInfo())
+
+	_, err = CompleteWorkflow{FailureCategory: FailureTimeout}.Failure(nil)
+	assert.Error(t, err)
+}
+
+func Test_ExecuteActivityInheritOptions(t *testing.T) {
+	defaults := &bindings.ExecuteActivityOptions{
+		TaskQueueName:       "activities",
+		StartToCloseTimeout: time.Minute,
+		HeartbeatTimeout:    time.Second * 10,
+		RetryPolicy:         &commonpb.RetryPolicy{MaximumAttempts: 3},
+		WaitForCancellation: true,
+	}
+
+	cmd := &ExecuteActivity{Name: "SendEmail", Options: bindings.ExecuteActivityOptions{
+		StartToCloseTimeout: time.Second * 5,
+	}}
+	cmd.InheritOptions(defaults)
+
+	// the command options win
+	assert.Equal(t, time.Second*5, cmd.Options.StartToCloseTimeout)
+	assert.Equal(t, "activities", cmd.Options.TaskQueueName)
+	assert.Equal(t, time.Second*10, cmd.Options.HeartbeatTimeout)
+	assert.Equal(t, int32(3), cmd.Options.RetryPolicy.GetMaximumAttempts())
+	// booleans are never inherited
+	assert.False(t, cmd.Options.WaitForCancellation)
+
+	cmd = &ExecuteActivity{Name: "SendEmail"}
+	cmd.InheritOptions(nil)
+	assert.Equal(t, bindings.ExecuteActivityOptions{}, cmd.Options)
+}
@@ -31,8 +31,15 @@ type WorkflowInfo struct {
 	Queries []string `json:"queries"`
 	// Signals pre-defined for the workflow type.
 	Signals []string `json:"signals"`
+	// ExecOnlyUpdates are the updates without validator, executed without the validation round-trip.
+	ExecOnlyUpdates []string `json:"exec_only_updates,omitempty"`
 	// VersioningBehavior for the workflow.
 	VersioningBehavior workflow.VersioningBehavior `json:"versioning_behavior,omitempty"`
+	// TaskQueue polled for the workflow (RR side only).
+	TaskQueue string `json:"-"`
+	// RegisteredTaskQueue is the task queue the workflow is registered on in the PHP worker, set when the workflow type
+	// is routed to another task queue (RR side only).
+	RegisteredTaskQueue string `json:"-"`
 }
 
 // ActivityInfo describes single worker activity.
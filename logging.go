@@ -0,0 +1,65 @@
+package rrtemporal
+
+import (
+	"sync/atomic"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// handlerLogger builds the logger used by the workflow and activity handlers
+func handlerLogger(log *zap.Logger, cfg *Logs) (*zap.Logger, error) {
+	const op = errors.Op("temporal_handler_logger")
+
+	if cfg == nil {
+		return log, nil
+	}
+
+	var opts []zap.Option
+	if cfg.Level != "" {
+		lvl, err := zapcore.ParseLevel(cfg.Level)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+
+		// the level can only be increased, entries below the global level are dropped by the base core
+		opts = append(opts, zap.IncreaseLevel(lvl))
+	}
+
+	if cfg.SampleDebug > 1 {
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &debugSampler{Core: core, n: uint64(cfg.SampleDebug), counter: &atomic.Uint64{}}
+		}))
+	}
+
+	if len(cfg.Fields) > 0 {
+		fields := make([]zap.Field, 0, len(cfg.Fields))
+		for k, v := range cfg.Fields {
+			fields = append(fields, zap.String(k, v))
+		}
+
+		opts = append(opts, zap.Fields(fields...))
+	}
+
+	return log.WithOptions(opts...), nil
+}
+
+// debugSampler passes only 1 of n debug entries, other levels are not affected
+type debugSampler struct {
+	zapcore.Core
+	n       uint64
+	counter *atomic.Uint64
+}
+
+func (d *debugSampler) With(fields []zapcore.Field) zapcore.Core {
+	return &debugSampler{Core: d.Core.With(fields), n: d.n, counter: d.counter}
+}
+
+func (d *debugSampler) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if ent.Level == zapcore.DebugLevel && d.Enabled(ent.Level) && d.counter.Add(1)%d.n != 1 {
+		return ce
+	}
+
+	return d.Core.Check(ent, ce)
+}
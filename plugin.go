@@ -20,6 +20,7 @@ import (
 	"github.com/temporalio/roadrunner-temporal/v5/internal"
 	"github.com/temporalio/roadrunner-temporal/v5/internal/codec/proto"
 	tclient "go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/converter"
 	"go.temporal.io/sdk/worker"
 	"go.uber.org/zap"
 
@@ -59,13 +60,18 @@ type temporal struct {
 	tallyCloser   io.Closer
 	tlsCfg        *tls.Config
 	client        tclient.Client
+	dc            converter.DataConverter
 	workers       []worker.Worker
 
 	interceptors map[string]api.Interceptor
+	decorators   map[string]api.ContextDecorator
+	middleware   map[string]api.CommandMiddleware
 }
 
 type Plugin struct {
 	mu sync.RWMutex
+	// serializes the pools reset and replacement, the rolling replacement holds mu only to swap the pools
+	poolsMu sync.Mutex
 
 	server        api.Server
 	log           *zap.Logger
@@ -81,10 +87,21 @@ type Plugin struct {
 	wwPID     int
 	rrVersion string
 	temporal  *temporal
+	// time of the last pools start, replacement or reset
+	poolsStartedAt time.Time
+	// nil - the worker info is not cached (or not read yet)
+	wiCache *workerInfoCache
+	// running workflows of all the pools, used to report the non-deterministic replays
+	runs *aggregatedpool.RunRegistry
 
 	eventBus events.EventBus
 	events   chan events.Event
 	stopCh   chan struct{}
+
+	// nil - readiness doesn't depend on the pollers
+	pollers *pollers
+	// set while the failed reset is retried (reset_failure.policy: degraded)
+	degraded atomic.Bool
 }
 
 func (p *Plugin) Init(cfg api.Configurer, log Logger, server api.Server) error {
@@ -141,6 +158,7 @@ func (p *Plugin) Init(cfg api.Configurer, log Logger, server api.Server) error {
 	p.eventBus, p.id = events.NewEventBus()
 	p.stopCh = make(chan struct{}, 1)
 	p.statsExporter = newStatsExporter(p)
+	p.runs = aggregatedpool.NewRunRegistry()
 
 	// initialize TLS
 	if p.config.TLS != nil {
@@ -160,6 +178,8 @@ func (p *Plugin) Init(cfg api.Configurer, log Logger, server api.Server) error {
 
 	// initialize interceptors
 	p.temporal.interceptors = make(map[string]api.Interceptor)
+	p.temporal.decorators = make(map[string]api.ContextDecorator)
+	p.temporal.middleware = make(map[string]api.CommandMiddleware)
 	// empty
 	p.apiKey.Store(ptrTo(""))
 
@@ -173,7 +193,13 @@ func (p *Plugin) Serve() chan error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	err := p.initPool()
+	err := p.initPoolWithRetry(p.initPool)
+	if err != nil {
+		errCh <- errors.E(op, err)
+		return errCh
+	}
+
+	err = p.validateSearchAttributes()
 	if err != nil {
 		errCh <- errors.E(op, err)
 		return errCh
@@ -185,39 +211,58 @@ func (p *Plugin) Serve() chan error {
 		return errCh
 	}
 
-	go func() {
-		for {
-			select {
-			case ev := <-p.events:
-				p.log.Debug("worker stopped, restarting pool and temporal workers", zap.String("message", ev.Message()))
-
-				// check pid, message from the go sdk is: process exited, pid: 334455 <-- we are looking for this pid
-				// sdk 2.18.1
-				// TODO: potential bug here, if the pid contains the WW pid, it will reset everything (btw, should not be a problem)
-				switch strings.Contains(ev.Message(), strconv.Itoa(p.wwPID)) {
-				// stopped workflow worker
-				case true:
-					errR := p.Reset()
-					if errR != nil {
-						errCh <- errors.E(op, errors.Errorf("error during reset: %#v, event: %s", errR, ev.Message()))
-						return
-					}
-					// stopped one of the activity workers
-				case false:
-					errR := p.ResetAP()
-					if errR != nil {
-						errCh <- errors.E(op, errors.Errorf("error during reset: %#v, event: %s", errR, ev.Message()))
-						return
-					}
+	go p.watchWorkers(errCh, p.ResetAP, p.Reset)
+
+	return errCh
+}
+
+// watchWorkers restarts the pools and the Temporal workers after a worker stopped: resetAP after an activity worker,
+// resetWW after the workflow worker. The reset error is sent to errCh or retried in the degraded mode
+// (reset_failure.policy). Runs until the plugin is stopped.
+func (p *Plugin) watchWorkers(errCh chan error, resetAP, resetWW func() error) {
+	const op = errors.Op("temporal_plugin_serve")
+
+	var heartbeats <-chan time.Time
+	if p.config.HeartbeatMonitor != nil {
+		ticker := time.NewTicker(p.config.HeartbeatMonitor.Interval)
+		defer ticker.Stop()
+		heartbeats = ticker.C
+	}
+
+	for {
+		select {
+		case <-heartbeats:
+			p.getActDef().CheckHeartbeats(time.Now(), p.config.HeartbeatMonitor.Threshold, p.temporal.mh)
+
+		case ev := <-p.events:
+			p.log.Debug("worker stopped, restarting pool and temporal workers", zap.String("message", ev.Message()))
+
+			// check pid, message from the go sdk is: process exited, pid: 334455 <-- we are looking for this pid
+			// sdk 2.18.1
+			// TODO: potential bug here, if the pid contains the WW pid, it will reset everything (btw, should not be a problem)
+			reset := resetAP
+			// stopped workflow worker
+			if strings.Contains(ev.Message(), strconv.Itoa(p.wwPID)) {
+				reset = resetWW
+			}
+
+			errR := reset()
+			if errR != nil {
+				if p.config.ResetFailure.Policy != ResetFailureDegraded {
+					errCh <- errors.E(op, errors.Errorf("error during reset: %#v, event: %s", errR, ev.Message()))
+					return
 				}
 
-			case <-p.stopCh:
-				return
+				p.log.Error("reset failed, retrying in the degraded mode", zap.String("event", ev.Message()), zap.Error(errR))
+				if p.retryReset(reset) {
+					return
+				}
 			}
-		}
-	}()
 
-	return errCh
+		case <-p.stopCh:
+			return
+		}
+	}
 }
 
 func (p *Plugin) Stop(ctx context.Context) error {
@@ -227,6 +272,11 @@ func (p *Plugin) Stop(ctx context.Context) error {
 		p.mu.Lock()
 		defer p.mu.Unlock()
 
+		if p.pollers != nil {
+			p.pollers.cancel()
+			p.pollers = nil
+		}
+
 		// stop events
 		p.eventBus.Unsubscribe(p.id)
 		p.stopCh <- struct{}{}
@@ -327,13 +377,110 @@ func (p *Plugin) ResetAP() error {
 	return nil
 }
 
+// Reset restarts the Temporal workers after the workflow worker was stopped, the workflow worker itself is
+// restarted by the pool supervisor.
 func (p *Plugin) Reset() error {
 	const op = errors.Op("temporal_reset")
 
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	return p.reset(ctx, op, false)
+}
+
+// ReplacePools replaces both workflow and activity worker pools (e.g. to load the new PHP code) and restarts
+// the Temporal workers. It returns when the new workers are polling or the error occurred.
+// With the reset_overlap option the new pools start polling before the old ones are drained (rolling replacement).
+func (p *Plugin) ReplacePools(ctx context.Context) error {
+	const op = errors.Op("temporal_replace_pools")
+
+	if p.config.ResetOverlap > 0 {
+		return p.rollingReplace(ctx, op)
+	}
+
+	return p.reset(ctx, op, true)
+}
+
+// rollingReplace starts the Temporal workers on the new pools, keeps both sets polling during the overlap window,
+// then drains and destroys the old ones. The plugin lock is held only to swap the pools, the RPCs (e.g. the
+// heartbeats of the running activities) are served during the replacement.
+func (p *Plugin) rollingReplace(ctx context.Context, op errors.Op) error {
+	p.poolsMu.Lock()
+	defer p.poolsMu.Unlock()
+
+	p.log.Info("replacing worker pools", zap.Duration("overlap", p.config.ResetOverlap))
+
+	ps, err := p.newPoolSet(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	workers, err := aggregatedpool.TemporalWorkers(ps.wfDef, ps.actDef, ps.wi, p.log, p.temporal.client, p.temporal.interceptors, p.config.Identity)
+	if err != nil {
+		destroyPools(ps.wfP, ps.actP)
+		return errors.E(op, err)
+	}
+
+	for i := range workers {
+		err = workers[i].Start()
+		if err != nil {
+			for j := range i {
+				workers[j].Stop()
+			}
+
+			destroyPools(ps.wfP, ps.actP)
+			return errors.E(op, err)
+		}
+	}
+
+	p.mu.Lock()
+	oldWorkers, oldWfP, oldActP := p.temporal.workers, p.wfP, p.actP
+	p.usePoolSet(ps, workers)
+	p.watchPollers(ps.wi)
+	p.mu.Unlock()
+
+	p.log.Info("new worker pools started", zap.Int("workflow_worker_pid", ps.wwPID))
+
+	// both old and new workers are polling during the overlap
+	select {
+	case <-time.After(p.config.ResetOverlap):
+	case <-ctx.Done():
+	}
+
+	// Stop waits for the in-flight activities of the old workers (worker_stop_timeout)
+	for i := range oldWorkers {
+		oldWorkers[i].Stop()
+	}
+
+	// Workflows cached by the old workers are bound to the old workflow pool, evict them (and send the destroy
+	// commands) while the pool is alive, they are replayed on the new pool with the next workflow task.
+	// The SDK cache is shared by all the workers of the process, so the workflows cached by the new workers during
+	// the overlap are evicted (and replayed) as well, there is no way to evict only the old ones.
+	worker.PurgeStickyWorkflowCache()
+
+	destroyPools(oldWfP, oldActP)
+	p.log.Info("old worker pools destroyed")
+
+	return nil
+}
+
+func destroyPools(pools ...*static_pool.Pool) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	for _, pl := range pools {
+		pl.Destroy(ctx)
+	}
+}
+
+func (p *Plugin) reset(ctx context.Context, op errors.Op, replaceWorkflowPool bool) error {
+	p.poolsMu.Lock()
+	defer p.poolsMu.Unlock()
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.log.Info("reset signal received, resetting activity and workflow worker pools")
+	p.log.Info("reset signal received, resetting activity and workflow worker pools", zap.Bool("replace_workflow_pool", replaceWorkflowPool))
 
 	// stop temporal workers
 	for i := 0; i < len(p.temporal.workers); i++ {
@@ -343,24 +490,39 @@ func (p *Plugin) Reset() error {
 	p.temporal.workers = nil
 	worker.PurgeStickyWorkflowCache()
 
+	if replaceWorkflowPool {
+		err := p.wfP.Reset(ctx)
+		if err != nil {
+			return errors.E(op, err)
+		}
+		p.log.Info("workflow pool restarted")
+	}
+
 	if len(p.wfP.Workers()) < 1 {
 		return errors.E(op, errors.Str("failed to allocate a workflow worker"))
 	}
 
 	p.wwPID = int(p.wfP.Workers()[0].Pid())
 
-	ctxA, cancelA := context.WithTimeout(context.Background(), time.Second*30)
-	defer cancelA()
-	errAp := p.actP.Reset(ctxA)
+	errAp := p.actP.Reset(ctx)
 	if errAp != nil {
 		return errors.E(op, errAp)
 	}
 	p.log.Info("activity pool restarted")
 
 	// get worker info
-	wi, err := WorkerInfo(p.codec, p.wfP, p.rrVersion, p.wwPID)
+	wi, err := p.workerInfo(p.codec, p.wfP, p.wwPID)
 	if err != nil {
-		return err
+		return errors.E(op, err)
+	}
+
+	wi = p.routeWorkflows(wi)
+	p.applyWorkerOptions(wi)
+	// the restarted worker might be another version
+	if len(wi) > 0 {
+		p.negotiateOptionsCompression(p.codec, wi[0].Flags)
+		p.negotiateHeaderDeduplication(p.codec, wi[0].Flags)
+		p.negotiateContextFields(p.temporal.rrWorkflowDef, wi[0].Flags)
 	}
 
 	// based on the worker info -> initialize workers
@@ -371,27 +533,30 @@ func (p *Plugin) Reset() error {
 		p.log,
 		p.temporal.client,
 		p.temporal.interceptors,
+		p.config.Identity,
 	)
 	if err != nil {
-		return err
+		return errors.E(op, err)
 	}
 
 	// start workers
 	for i := range workers {
 		err = workers[i].Start()
 		if err != nil {
-			return err
+			return errors.E(op, err)
 		}
 	}
 
 	p.temporal.activities = ActivitiesInfo(wi)
 	p.temporal.workflows = WorkflowsInfo(wi)
 	p.temporal.workers = workers
+	p.poolsStartedAt = time.Now()
+	p.watchPollers(wi)
 
 	return nil
 }
 
-// Collects collecting grpc interceptors
+// Collects collecting grpc interceptors, context decorators and command middleware
 func (p *Plugin) Collects() []*dep.In {
 	return []*dep.In{
 		dep.Fits(func(pp any) {
@@ -401,6 +566,18 @@ func (p *Plugin) Collects() []*dep.In {
 			p.temporal.interceptors[mdw.Name()] = mdw
 			p.mu.Unlock()
 		}, (*api.Interceptor)(nil)),
+		dep.Fits(func(pp any) {
+			d := pp.(api.ContextDecorator)
+			p.mu.Lock()
+			p.temporal.decorators[d.Name()] = d
+			p.mu.Unlock()
+		}, (*api.ContextDecorator)(nil)),
+		dep.Fits(func(pp any) {
+			m := pp.(api.CommandMiddleware)
+			p.mu.Lock()
+			p.temporal.middleware[m.Name()] = m
+			p.mu.Unlock()
+		}, (*api.CommandMiddleware)(nil)),
 	}
 }
 
@@ -10,8 +10,10 @@ import (
 
 type MessageQueue struct {
 	SeqID func() uint64
-	mu    sync.Mutex
-	queue []*internal.Message
+	// IsReplaying (optional) captures the replay state of every pushed message
+	IsReplaying func() bool
+	mu          sync.Mutex
+	queue       []*internal.Message
 }
 
 func NewMessageQueue(sedID func() uint64) *MessageQueue {
@@ -35,6 +37,7 @@ func (mq *MessageQueue) AllocateMessage(cmd any, payloads *common.Payloads, head
 	ret.Command = cmd
 	ret.Payloads = payloads
 	ret.Header = header
+	ret.Replay = mq.replay()
 }
 
 func (mq *MessageQueue) PushCommand(cmd any, payloads *common.Payloads, header *common.Header) {
@@ -44,6 +47,7 @@ func (mq *MessageQueue) PushCommand(cmd any, payloads *common.Payloads, header *
 		Command:  cmd,
 		Payloads: payloads,
 		Header:   header,
+		Replay:   mq.replay(),
 	})
 	mq.mu.Unlock()
 }
@@ -53,13 +57,14 @@ func (mq *MessageQueue) PushResponse(id uint64, payloads *common.Payloads) {
 	mq.queue = append(mq.queue, &internal.Message{
 		ID:       id,
 		Payloads: payloads,
+		Replay:   mq.replay(),
 	})
 	mq.mu.Unlock()
 }
 
 func (mq *MessageQueue) PushError(id uint64, failure *failure.Failure) {
 	mq.mu.Lock()
-	mq.queue = append(mq.queue, &internal.Message{ID: id, Failure: failure})
+	mq.queue = append(mq.queue, &internal.Message{ID: id, Failure: failure, Replay: mq.replay()})
 	mq.mu.Unlock()
 }
 
@@ -68,3 +73,14 @@ func (mq *MessageQueue) Messages() []*internal.Message {
 	defer mq.mu.Unlock()
 	return mq.queue
 }
+
+func (mq *MessageQueue) replay() internal.ReplayState {
+	switch {
+	case mq.IsReplaying == nil:
+		return internal.ReplayUnknown
+	case mq.IsReplaying():
+		return internal.ReplayTrue
+	default:
+		return internal.ReplayFalse
+	}
+}
@@ -50,3 +50,27 @@ func Test_MessageQueueCommandID(t *testing.T) {
 	mq.Flush()
 	assert.Len(t, mq.Messages(), 0)
 }
+
+func Test_MessageQueueReplay(t *testing.T) {
+	var index uint64
+	mq := NewMessageQueue(func() uint64 {
+		return atomic.AddUint64(&index, 1)
+	})
+
+	mq.PushResponse(1, &common.Payloads{})
+
+	replaying := true
+	mq.IsReplaying = func() bool {
+		return replaying
+	}
+
+	mq.PushResponse(2, &common.Payloads{})
+	replaying = false
+	mq.PushError(3, &failure.Failure{})
+
+	msgs := mq.Messages()
+	assert.Len(t, msgs, 3)
+	assert.Equal(t, internal.ReplayUnknown, msgs[0].Replay)
+	assert.Equal(t, internal.ReplayTrue, msgs[1].Replay)
+	assert.Equal(t, internal.ReplayFalse, msgs[2].Replay)
+}
@@ -0,0 +1,167 @@
+package rrtemporal
+
+import (
+	"context"
+	stderr "errors"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/taskqueue/v1"
+	"go.uber.org/zap"
+)
+
+const readinessCheckInterval = time.Second
+
+// Readiness waits for the Temporal workers pollers before reporting the plugin as ready.
+type Readiness struct {
+	// Timeout to wait for the pollers after the workers start (or reset), the readiness check fails after that. Default: 1m.
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// pollers tracks whether the started workers are polling their task queues
+type pollers struct {
+	ready  atomic.Bool
+	failed atomic.Bool
+	// done is closed when the pollers are ready or the timeout is reached
+	done   chan struct{}
+	cancel context.CancelFunc
+}
+
+// watchPollers starts watching the pollers of the started workers, the previous watcher (if any) is stopped.
+// Should be called under the plugin lock.
+func (p *Plugin) watchPollers(wi []*internal.WorkerInfo) {
+	if p.config.Readiness == nil {
+		return
+	}
+
+	if p.pollers != nil {
+		p.pollers.cancel()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.Readiness.Timeout)
+	pl := &pollers{done: make(chan struct{}), cancel: cancel}
+	p.pollers = pl
+
+	// copy the task queues, worker info is modified on reset
+	type queue struct {
+		name       string
+		identity   string
+		workflows  bool
+		activities bool
+	}
+
+	queues := make([]queue, 0, len(wi))
+	for i := range wi {
+		queues = append(queues, queue{
+			name:       wi[i].TaskQueue,
+			identity:   wi[i].Options.Identity,
+			workflows:  len(wi[i].Workflows) > 0,
+			activities: len(wi[i].Activities) > 0 && !p.config.DisableActivityWorkers,
+		})
+	}
+
+	go func() {
+		defer cancel()
+		defer close(pl.done)
+
+		ticker := time.NewTicker(readinessCheckInterval)
+		defer ticker.Stop()
+
+		for {
+			ready := true
+			for _, q := range queues {
+				if q.workflows && !p.polling(ctx, q.name, q.identity, enums.TASK_QUEUE_TYPE_WORKFLOW) ||
+					q.activities && !p.polling(ctx, q.name, q.identity, enums.TASK_QUEUE_TYPE_ACTIVITY) {
+					ready = false
+					break
+				}
+			}
+
+			if ready {
+				pl.ready.Store(true)
+				p.log.Info("temporal workers are polling", zap.Int("task_queues", len(queues)))
+				return
+			}
+
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				// replaced by the new watcher
+				if stderr.Is(ctx.Err(), context.Canceled) {
+					return
+				}
+
+				pl.failed.Store(true)
+				p.log.Error("temporal workers are not polling", zap.Duration("timeout", p.config.Readiness.Timeout))
+				return
+			}
+		}
+	}()
+}
+
+// polling returns true if the worker with the identity polls the task queue
+func (p *Plugin) polling(ctx context.Context, taskQueue, identity string, tp enums.TaskQueueType) bool {
+	resp, err := p.temporal.client.DescribeTaskQueue(ctx, taskQueue, tp)
+	if err != nil {
+		p.log.Debug("failed to describe the task queue", zap.String("task_queue", taskQueue), zap.Error(err))
+		return false
+	}
+
+	return slices.ContainsFunc(resp.GetPollers(), func(pi *taskqueue.PollerInfo) bool {
+		return pi.GetIdentity() == identity
+	})
+}
+
+// pollersReady returns false while the pollers are not established, and the error if they are not established within the timeout.
+// Should be called under the plugin lock.
+func (p *Plugin) pollersReady() (bool, error) {
+	const op = errors.Op("temporal_pollers_ready")
+
+	if p.pollers == nil {
+		return true, nil
+	}
+
+	if p.pollers.failed.Load() {
+		return false, errors.E(op, errors.Errorf("temporal workers are not polling after %s", p.config.Readiness.Timeout))
+	}
+
+	return p.pollers.ready.Load(), nil
+}
+
+// WaitPollers blocks until the Temporal workers are polling their task queues (readiness option), useful for the
+// test harnesses and the deploy scripts. Returns immediately if the readiness option is not set.
+func (p *Plugin) WaitPollers(ctx context.Context) error {
+	for {
+		p.mu.RLock()
+		pl := p.pollers
+		p.mu.RUnlock()
+
+		if pl == nil {
+			return nil
+		}
+
+		select {
+		case <-pl.done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+
+		p.mu.RLock()
+		replaced := p.pollers != pl
+		ready, err := p.pollersReady()
+		p.mu.RUnlock()
+
+		if replaced {
+			// workers were reset while waiting, wait for the new ones
+			continue
+		}
+
+		if err != nil || ready {
+			return err
+		}
+	}
+}
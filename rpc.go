@@ -9,12 +9,17 @@ import (
 	commonV1 "github.com/roadrunner-server/api/v4/build/common/v1"
 	protoApi "github.com/roadrunner-server/api/v4/build/temporal/v1"
 	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/aggregatedpool"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
 	"github.com/temporalio/roadrunner-temporal/v5/internal/logger"
 	commonpb "go.temporal.io/api/common/v1"
 	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/failure/v1"
 	"go.temporal.io/api/history/v1"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/activity"
 	"go.temporal.io/sdk/client"
+	sdktemporal "go.temporal.io/sdk/temporal"
 	"go.temporal.io/sdk/worker"
 	"go.temporal.io/sdk/workflow"
 	"go.uber.org/zap"
@@ -77,6 +82,7 @@ func (r *rpc) RecordActivityHeartbeat(in RecordHeartbeatRequest, out *RecordHear
 	r.plugin.mu.RUnlock()
 
 	activity.RecordHeartbeat(ctx, details)
+	r.plugin.getActDef().MarkHeartbeat(in.TaskToken)
 
 	err = context.Cause(ctx)
 	if err != nil {
@@ -429,3 +435,286 @@ func (r *rpc) UpdateAPIKey(in *string, out *bool) error {
 	*out = false
 	return nil
 }
+
+// ReplacePoolsRequest replaces the worker pools, Timeout is a duration string (e.g. 1m), default: 30s.
+type ReplacePoolsRequest struct {
+	Timeout string `json:"timeout"`
+}
+
+// ReplacePoolsResponse describes the workers started after the pools replacement.
+type ReplacePoolsResponse struct {
+	WorkflowWorkerPID int `json:"workflow_worker_pid"`
+	// TemporalWorkers is the number of started Temporal workers (task queues)
+	TemporalWorkers int `json:"temporal_workers"`
+}
+
+// ReplacePools synchronously replaces the workflow and activity worker pools, e.g. after deploying the new PHP code.
+// Unlike the event-driven reset, the call returns the error if the new workers were not started within the timeout.
+// The timeout bounds the pools allocation (and the overlap of the rolling replacement), the call always returns after
+// the replacement is finished, so the plugin is never left locked by an abandoned replacement.
+func (r *rpc) ReplacePools(in *ReplacePoolsRequest, out *ReplacePoolsResponse) error {
+	const op = errors.Op("temporal_rpc_replace_pools")
+
+	timeout := time.Second * 30
+	if in.Timeout != "" {
+		var err error
+		timeout, err = time.ParseDuration(in.Timeout)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		if timeout <= 0 {
+			return errors.E(op, errors.Str("timeout should be positive"))
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := r.plugin.ReplacePools(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return errors.E(op, errors.Errorf("pools were not replaced within %s: %v", timeout, err))
+		}
+
+		return errors.E(op, err)
+	}
+
+	r.plugin.mu.RLock()
+	out.WorkflowWorkerPID = r.plugin.wwPID
+	out.TemporalWorkers = len(r.plugin.temporal.workers)
+	r.plugin.mu.RUnlock()
+
+	return nil
+}
+
+// DescribeTaskQueueRequest describes the task queue in the plugin namespace.
+type DescribeTaskQueueRequest struct {
+	TaskQueue string `json:"task_queue"`
+}
+
+// PollerInfo describes a worker polling the task queue.
+type PollerInfo struct {
+	Identity       string    `json:"identity"`
+	LastAccessTime time.Time `json:"last_access_time"`
+	RatePerSecond  float64   `json:"rate_per_second"`
+}
+
+// DescribeTaskQueueResponse contains pollers attached to the workflow and activity task queues.
+type DescribeTaskQueueResponse struct {
+	WorkflowPollers []*PollerInfo `json:"workflow_pollers"`
+	ActivityPollers []*PollerInfo `json:"activity_pollers"`
+}
+
+// DescribeTaskQueue returns the pollers attached to the task queue, useful to check whether the workers are actually polling.
+func (r *rpc) DescribeTaskQueue(in *DescribeTaskQueueRequest, out *DescribeTaskQueueResponse) error {
+	const op = errors.Op("temporal_rpc_describe_task_queue")
+
+	r.plugin.log.Debug("describe task queue request", zap.String("task_queue", in.TaskQueue))
+
+	if in.TaskQueue == "" {
+		return errors.E(op, errors.Str("task_queue should not be empty"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	for _, tp := range []enums.TaskQueueType{enums.TASK_QUEUE_TYPE_WORKFLOW, enums.TASK_QUEUE_TYPE_ACTIVITY} {
+		resp, err := r.plugin.temporal.client.DescribeTaskQueue(ctx, in.TaskQueue, tp)
+		if err != nil {
+			return errors.E(op, err)
+		}
+
+		pollers := make([]*PollerInfo, 0, len(resp.GetPollers()))
+		for _, p := range resp.GetPollers() {
+			pollers = append(pollers, &PollerInfo{
+				Identity:       p.GetIdentity(),
+				LastAccessTime: p.GetLastAccessTime().AsTime(),
+				RatePerSecond:  p.GetRatePerSecond(),
+			})
+		}
+
+		if tp == enums.TASK_QUEUE_TYPE_WORKFLOW {
+			out.WorkflowPollers = pollers
+		} else {
+			out.ActivityPollers = pollers
+		}
+	}
+
+	return nil
+}
+
+// AsyncActivityRequest identifies the activity completed out of band, by the task token or by the workflow, run and activity IDs.
+type AsyncActivityRequest struct {
+	TaskToken []byte `json:"taskToken"`
+	// WorkflowID, RunID and ActivityID are used when the task token is empty
+	WorkflowID string `json:"workflowId"`
+	RunID      string `json:"runId"`
+	ActivityID string `json:"activityId"`
+	// Payloads are proto serialized Payloads: the result on completion or the details on heartbeat
+	Payloads []byte `json:"payloads"`
+	// Failure is the proto serialized Failure, completes the activity with the error
+	Failure []byte `json:"failure"`
+}
+
+// AsyncActivityResponse describes the activity state reported by the server.
+type AsyncActivityResponse struct {
+	// Canceled is true if the activity cancellation was requested (heartbeat only)
+	Canceled bool `json:"canceled"`
+	// NotFound is true if the activity is already completed, timed out or doesn't exist
+	NotFound bool `json:"notFound"`
+}
+
+// CompleteActivity completes the activity which returned doNotCompleteOnReturn (async completion) with the result or the failure.
+func (r *rpc) CompleteActivity(in *AsyncActivityRequest, out *AsyncActivityResponse) error {
+	const op = errors.Op("temporal_rpc_complete_activity")
+
+	if len(in.TaskToken) == 0 && (in.WorkflowID == "" || in.ActivityID == "") {
+		return errors.E(op, errors.Str("taskToken or workflowId and activityId should not be empty"))
+	}
+
+	result := &commonpb.Payloads{}
+	if len(in.Payloads) != 0 {
+		if err := proto.Unmarshal(in.Payloads, result); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	var actErr error
+	if len(in.Failure) != 0 {
+		f := &failure.Failure{}
+		if err := proto.Unmarshal(in.Failure, f); err != nil {
+			return errors.E(op, err)
+		}
+
+		actErr = sdktemporal.GetDefaultFailureConverter().FailureToError(f)
+		// the result is ignored for the failed activity
+		result = nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	var err error
+	if len(in.TaskToken) != 0 {
+		err = r.plugin.temporal.client.CompleteActivity(ctx, in.TaskToken, result, actErr)
+	} else {
+		err = r.plugin.temporal.client.CompleteActivityByID(ctx, r.plugin.config.Namespace, in.WorkflowID, in.RunID, in.ActivityID, result, actErr)
+	}
+
+	return asyncActivityResult(op, err, out)
+}
+
+// RecordAsyncActivityHeartbeat records the heartbeat for the activity completed out of band,
+// for the activities running in the worker use RecordActivityHeartbeat.
+func (r *rpc) RecordAsyncActivityHeartbeat(in *AsyncActivityRequest, out *AsyncActivityResponse) error {
+	const op = errors.Op("temporal_rpc_record_async_activity_heartbeat")
+
+	if len(in.TaskToken) == 0 && (in.WorkflowID == "" || in.ActivityID == "") {
+		return errors.E(op, errors.Str("taskToken or workflowId and activityId should not be empty"))
+	}
+
+	details := &commonpb.Payloads{}
+	if len(in.Payloads) != 0 {
+		if err := proto.Unmarshal(in.Payloads, details); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	var err error
+	if len(in.TaskToken) != 0 {
+		err = r.plugin.temporal.client.RecordActivityHeartbeat(ctx, in.TaskToken, details)
+	} else {
+		err = r.plugin.temporal.client.RecordActivityHeartbeatByID(ctx, r.plugin.config.Namespace, in.WorkflowID, in.RunID, in.ActivityID, details)
+	}
+
+	return asyncActivityResult(op, err, out)
+}
+
+// asyncActivityResult maps the not found and canceled errors to the response, other errors are returned as is
+func asyncActivityResult(op errors.Op, err error, out *AsyncActivityResponse) error {
+	if err == nil {
+		return nil
+	}
+
+	var notFound *serviceerror.NotFound
+	var canceled *sdktemporal.CanceledError
+
+	switch {
+	case stderr.As(err, &notFound):
+		out.NotFound = true
+		return nil
+	case stderr.As(err, &canceled):
+		out.Canceled = true
+		return nil
+	default:
+		return errors.E(op, err)
+	}
+}
+
+// TypedSearchAttribute is the search attribute with its type, the same as in the UpsertWorkflowTypedSearchAttributes command.
+type TypedSearchAttribute = internal.TypedSearchAttribute
+
+// WorkflowExecutionRef identifies the workflow run, the latest run is used when RunID is empty.
+type WorkflowExecutionRef struct {
+	WorkflowID string `json:"workflowId"`
+	RunID      string `json:"runId"`
+}
+
+// UpsertSearchAttributesRequest updates the search attributes of the running workflows, e.g. to re-tag them during a migration.
+type UpsertSearchAttributesRequest struct {
+	Executions []WorkflowExecutionRef `json:"executions"`
+	// SearchAttributes use the same format as the UpsertWorkflowTypedSearchAttributes command
+	SearchAttributes map[string]*TypedSearchAttribute `json:"searchAttributes"`
+}
+
+// UpsertSearchAttributesResponse lists the workflows which were not updated.
+type UpsertSearchAttributesResponse struct {
+	// Signaled is the number of the workflows the update was sent to
+	Signaled int `json:"signaled"`
+	// NotFound are the workflow IDs which are already closed or don't exist
+	NotFound []string `json:"notFound"`
+}
+
+// UpsertSearchAttributes updates the search attributes of the running workflows from outside. The server can't change the
+// search attributes of a running workflow, so the attributes are sent with the reserved signal and upserted by the workflow
+// on the RR side (the worker doesn't receive the signal). The workflows should run on RR workers.
+func (r *rpc) UpsertSearchAttributes(in *UpsertSearchAttributesRequest, out *UpsertSearchAttributesResponse) error {
+	const op = errors.Op("temporal_rpc_upsert_search_attributes")
+
+	if len(in.Executions) == 0 {
+		return errors.E(op, errors.Str("executions should not be empty"))
+	}
+
+	// validated here, the workflow can only log the invalid attributes
+	err := aggregatedpool.ValidateTypedSearchAttributes(in.SearchAttributes)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	for _, e := range in.Executions {
+		if e.WorkflowID == "" {
+			return errors.E(op, errors.Str("workflowId should not be empty"))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+		err = r.plugin.temporal.client.SignalWorkflow(ctx, e.WorkflowID, e.RunID, aggregatedpool.UpsertSearchAttributesSignal, in.SearchAttributes)
+		cancel()
+
+		var notFound *serviceerror.NotFound
+		switch {
+		case err == nil:
+			out.Signaled++
+		case stderr.As(err, &notFound):
+			// closed workflows can't be updated
+			out.NotFound = append(out.NotFound, e.WorkflowID)
+		default:
+			return errors.E(op, errors.Errorf("workflow '%s': %v", e.WorkflowID, err))
+		}
+	}
+
+	return nil
+}
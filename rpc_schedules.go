@@ -0,0 +1,183 @@
+package rrtemporal
+
+import (
+	"context"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+// defaultNextRuns is the number of the next run times returned by DescribeSchedule by default
+const defaultNextRuns = 10
+
+// DescribeScheduleRequest describes the schedule in the plugin namespace, NextRuns is the number of the next run
+// times to return, default: 10. The server computes a limited number of the next run times (10 by default).
+type DescribeScheduleRequest struct {
+	ScheduleID string `json:"schedule_id"`
+	NextRuns   int    `json:"next_runs"`
+}
+
+// ScheduleAction is the workflow started by the schedule.
+type ScheduleAction struct {
+	ScheduleTime time.Time `json:"schedule_time"`
+	ActualTime   time.Time `json:"actual_time"`
+	WorkflowID   string    `json:"workflow_id,omitempty"`
+	RunID        string    `json:"run_id,omitempty"`
+}
+
+// DescribeScheduleResponse contains the schedule spec, state, recent actions and next run times.
+type DescribeScheduleResponse struct {
+	ScheduleID string               `json:"schedule_id"`
+	Spec       *client.ScheduleSpec `json:"spec"`
+	Paused     bool                 `json:"paused"`
+	Note       string               `json:"note,omitempty"`
+	// RemainingActions is the number of the actions left, -1 if the actions are not limited
+	RemainingActions int `json:"remaining_actions"`
+	// NumActions is the number of the actions taken so far
+	NumActions    int               `json:"num_actions"`
+	RecentActions []*ScheduleAction `json:"recent_actions"`
+	NextRuns      []time.Time       `json:"next_runs"`
+	// NextRunsSuppressed is true when the schedule is paused: the next runs are not taken until it's unpaused
+	NextRunsSuppressed bool      `json:"next_runs_suppressed"`
+	CreatedAt          time.Time `json:"created_at"`
+	LastUpdateAt       time.Time `json:"last_update_at"`
+}
+
+// DescribeSchedule returns the schedule spec, state, recent actions and the next run times.
+func (r *rpc) DescribeSchedule(in *DescribeScheduleRequest, out *DescribeScheduleResponse) error {
+	const op = errors.Op("temporal_rpc_describe_schedule")
+
+	r.plugin.log.Debug("describe schedule request", zap.String("schedule_id", in.ScheduleID))
+
+	if in.ScheduleID == "" {
+		return errors.E(op, errors.Str("schedule_id should not be empty"))
+	}
+
+	nextRuns := in.NextRuns
+	if nextRuns == 0 {
+		nextRuns = defaultNextRuns
+	}
+
+	if nextRuns < 0 {
+		return errors.E(op, errors.Str("next_runs should be positive"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	desc, err := r.plugin.temporal.client.ScheduleClient().GetHandle(ctx, in.ScheduleID).Describe(ctx)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	out.ScheduleID = in.ScheduleID
+	out.Spec = desc.Schedule.Spec
+	out.RemainingActions = -1
+	if state := desc.Schedule.State; state != nil {
+		out.Paused = state.Paused
+		out.Note = state.Note
+		if state.LimitedActions {
+			out.RemainingActions = state.RemainingActions
+		}
+	}
+
+	out.NumActions = desc.Info.NumActions
+	out.CreatedAt = desc.Info.CreatedAt
+	out.LastUpdateAt = desc.Info.LastUpdateAt
+
+	out.RecentActions = make([]*ScheduleAction, 0, len(desc.Info.RecentActions))
+	for _, ra := range desc.Info.RecentActions {
+		action := &ScheduleAction{
+			ScheduleTime: ra.ScheduleTime,
+			ActualTime:   ra.ActualTime,
+		}
+
+		if ra.StartWorkflowResult != nil {
+			action.WorkflowID = ra.StartWorkflowResult.WorkflowID
+			action.RunID = ra.StartWorkflowResult.FirstExecutionRunID
+		}
+
+		out.RecentActions = append(out.RecentActions, action)
+	}
+
+	out.NextRuns = desc.Info.NextActionTimes
+	if len(out.NextRuns) > nextRuns {
+		out.NextRuns = out.NextRuns[:nextRuns]
+	}
+
+	// the times are computed from the spec, but no action is taken while paused
+	out.NextRunsSuppressed = out.Paused
+
+	return nil
+}
+
+// ListSchedulesRequest lists the schedules in the plugin namespace. PageSize is the maximum number of the schedules
+// in the response (server default if 0), NextPageToken is the token from the previous response, Query is the
+// visibility query (optional).
+type ListSchedulesRequest struct {
+	PageSize      int32  `json:"page_size"`
+	NextPageToken []byte `json:"next_page_token"`
+	Query         string `json:"query"`
+}
+
+// ScheduleListEntry is the short schedule description.
+type ScheduleListEntry struct {
+	ScheduleID   string      `json:"schedule_id"`
+	WorkflowType string      `json:"workflow_type"`
+	Paused       bool        `json:"paused"`
+	Note         string      `json:"note,omitempty"`
+	NextRuns     []time.Time `json:"next_runs"`
+}
+
+// ListSchedulesResponse contains a page of the schedules, NextPageToken is empty on the last page.
+type ListSchedulesResponse struct {
+	Schedules     []*ScheduleListEntry `json:"schedules"`
+	NextPageToken []byte               `json:"next_page_token,omitempty"`
+}
+
+// ListSchedules returns a page of the schedules in the plugin namespace.
+func (r *rpc) ListSchedules(in *ListSchedulesRequest, out *ListSchedulesResponse) error {
+	const op = errors.Op("temporal_rpc_list_schedules")
+
+	if in.PageSize < 0 {
+		return errors.E(op, errors.Str("page_size should be positive"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	// the SDK iterator hides the page token, the service is used directly to page through the schedules
+	resp, err := r.plugin.temporal.client.WorkflowService().ListSchedules(ctx, &workflowservice.ListSchedulesRequest{
+		Namespace:       r.plugin.config.Namespace,
+		MaximumPageSize: in.PageSize,
+		NextPageToken:   in.NextPageToken,
+		Query:           in.Query,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	out.Schedules = make([]*ScheduleListEntry, 0, len(resp.GetSchedules()))
+	for _, s := range resp.GetSchedules() {
+		entry := &ScheduleListEntry{
+			ScheduleID:   s.GetScheduleId(),
+			WorkflowType: s.GetInfo().GetWorkflowType().GetName(),
+			Paused:       s.GetInfo().GetPaused(),
+			Note:         s.GetInfo().GetNotes(),
+			NextRuns:     make([]time.Time, 0, len(s.GetInfo().GetFutureActionTimes())),
+		}
+
+		for _, t := range s.GetInfo().GetFutureActionTimes() {
+			entry.NextRuns = append(entry.NextRuns, t.AsTime())
+		}
+
+		out.Schedules = append(out.Schedules, entry)
+	}
+
+	out.NextPageToken = resp.GetNextPageToken()
+
+	return nil
+}
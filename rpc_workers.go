@@ -0,0 +1,88 @@
+package rrtemporal
+
+import (
+	"time"
+
+	"github.com/roadrunner-server/pool/pool/static_pool"
+	"github.com/roadrunner-server/pool/state/process"
+	"go.uber.org/zap"
+)
+
+const (
+	workflowPoolName = "workflow"
+	activityPoolName = "activity"
+)
+
+// WorkerState is the state of the workflow or activity worker process.
+type WorkerState struct {
+	// Pool is the worker pool: workflow or activity
+	Pool   string `json:"pool"`
+	PID    int64  `json:"pid"`
+	Status string `json:"status"`
+	// NumExecs is the number of the executions handled by the worker
+	NumExecs uint64 `json:"num_execs"`
+	// MemoryUsage is the worker RSS in bytes
+	MemoryUsage uint64    `json:"memory_usage"`
+	CPUPercent  float64   `json:"cpu_percent"`
+	CreatedAt   time.Time `json:"created_at"`
+	// LastUsedAt is zero if the worker was not used yet
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// GetWorkersResponse contains the states of the worker processes.
+type GetWorkersResponse struct {
+	// WorkflowWorkerPID is the PID of the workflow worker, the same PID is logged by the workflow handler
+	// (worker pid) and on the pools start (workflow_worker_pid). 0 if the pools are not started.
+	WorkflowWorkerPID int            `json:"workflow_worker_pid"`
+	Workers           []*WorkerState `json:"workers"`
+}
+
+// GetWorkers returns the states of the workflow and activity worker processes, the list is empty if the pools are not
+// started yet.
+func (r *rpc) GetWorkers(_ bool, out *GetWorkersResponse) error {
+	r.plugin.mu.RLock()
+	defer r.plugin.mu.RUnlock()
+
+	out.WorkflowWorkerPID = r.plugin.wwPID
+	out.Workers = make([]*WorkerState, 0, 2)
+	out.Workers = append(out.Workers, r.plugin.workerStates(workflowPoolName, r.plugin.wfP)...)
+	out.Workers = append(out.Workers, r.plugin.workerStates(activityPoolName, r.plugin.actP)...)
+
+	return nil
+}
+
+// workerStates returns the states of the pool workers, nil if the pool is not started
+func (p *Plugin) workerStates(name string, pool *static_pool.Pool) []*WorkerState {
+	if pool == nil {
+		return nil
+	}
+
+	workers := pool.Workers()
+	states := make([]*WorkerState, 0, len(workers))
+	for i := range workers {
+		st, err := process.WorkerProcessState(workers[i])
+		if err != nil {
+			// log error and continue
+			p.log.Error("worker process state error", zap.String("pool", name), zap.Error(err))
+			continue
+		}
+
+		ws := &WorkerState{
+			Pool:        name,
+			PID:         st.Pid,
+			Status:      st.StatusStr,
+			NumExecs:    st.NumExecs,
+			MemoryUsage: st.MemoryUsage,
+			CPUPercent:  st.CPUPercent,
+			CreatedAt:   time.Unix(0, st.Created),
+		}
+
+		if lastUsed := workers[i].State().LastUsed(); lastUsed > 0 {
+			ws.LastUsedAt = time.Unix(0, int64(lastUsed)) //nolint:gosec
+		}
+
+		states = append(states, ws)
+	}
+
+	return states
+}
@@ -0,0 +1,86 @@
+package rrtemporal
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.uber.org/zap"
+)
+
+// indexedValueType maps the RR typed search attribute type to the Temporal indexed value type
+func indexedValueType(tp string) (enums.IndexedValueType, bool) {
+	switch internal.TypedSearchAttributeType(tp) {
+	case internal.BoolType:
+		return enums.INDEXED_VALUE_TYPE_BOOL, true
+	case internal.FloatType:
+		return enums.INDEXED_VALUE_TYPE_DOUBLE, true
+	case internal.IntType:
+		return enums.INDEXED_VALUE_TYPE_INT, true
+	case internal.KeywordType:
+		return enums.INDEXED_VALUE_TYPE_KEYWORD, true
+	case internal.KeywordListType:
+		return enums.INDEXED_VALUE_TYPE_KEYWORD_LIST, true
+	case internal.StringType:
+		return enums.INDEXED_VALUE_TYPE_TEXT, true
+	case internal.DatetimeType:
+		return enums.INDEXED_VALUE_TYPE_DATETIME, true
+	default:
+		return enums.INDEXED_VALUE_TYPE_UNSPECIFIED, false
+	}
+}
+
+// validateSearchAttributes checks that the declared search attributes are registered in the namespace with the expected types
+func (p *Plugin) validateSearchAttributes() error {
+	const op = errors.Op("temporal_validate_search_attributes")
+
+	if p.config.SearchAttributes == nil || len(p.config.SearchAttributes.Expected) == 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*30)
+	defer cancel()
+
+	resp, err := p.temporal.client.OperatorService().ListSearchAttributes(ctx, &operatorservice.ListSearchAttributesRequest{
+		Namespace: p.config.Namespace,
+	})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	var mismatched []string
+	for name, tp := range p.config.SearchAttributes.Expected {
+		// already validated in the config
+		expected, _ := indexedValueType(tp)
+
+		registered, ok := resp.GetCustomAttributes()[name]
+		if !ok {
+			registered, ok = resp.GetSystemAttributes()[name]
+		}
+
+		switch {
+		case !ok:
+			p.log.Warn("search attribute is not registered in the namespace", zap.String("name", name), zap.String("namespace", p.config.Namespace))
+			mismatched = append(mismatched, name)
+		case registered != expected:
+			p.log.Warn("search attribute registered with a different type",
+				zap.String("name", name),
+				zap.String("expected", expected.String()),
+				zap.String("registered", registered.String()),
+			)
+			mismatched = append(mismatched, name)
+		}
+	}
+
+	if len(mismatched) > 0 && p.config.SearchAttributes.FailOnMismatch {
+		slices.Sort(mismatched)
+		return errors.E(op, errors.Errorf("search attributes are not registered or have a wrong type: %s", strings.Join(mismatched, ", ")))
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package rrtemporal
+
+import (
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// StartRetry retries the initial start of the pools and Temporal workers, e.g. when the Temporal frontend is started
+// slightly after RR in orchestrated environments.
+type StartRetry struct {
+	// Timeout is the retry window, the plugin fails to start if the workers are not started within it. Default: 1m.
+	Timeout time.Duration `mapstructure:"timeout"`
+	// InitialInterval between the attempts, doubled after each attempt. Default: 1s.
+	InitialInterval time.Duration `mapstructure:"initial_interval"`
+	// MaxInterval between the attempts. Default: 10s.
+	MaxInterval time.Duration `mapstructure:"max_interval"`
+}
+
+func (s *StartRetry) InitDefaults() {
+	if s.Timeout == 0 {
+		s.Timeout = time.Minute
+	}
+
+	if s.InitialInterval == 0 {
+		s.InitialInterval = time.Second
+	}
+
+	if s.MaxInterval == 0 {
+		s.MaxInterval = time.Second * 10
+	}
+}
+
+func (s *StartRetry) validate() error {
+	if s.Timeout < 0 || s.InitialInterval < 0 {
+		return errors.Str("start_retry.timeout and start_retry.initial_interval should be positive")
+	}
+
+	if s.MaxInterval < s.InitialInterval {
+		return errors.Str("start_retry.max_interval should not be lower than start_retry.initial_interval")
+	}
+
+	return nil
+}
+
+// initPoolWithRetry starts the pools and workers with initPool, retrying with the exponential backoff within the
+// start_retry window. Should be called with p.mu held, the lock is released while waiting for the next attempt so the
+// plugin can be stopped meanwhile.
+func (p *Plugin) initPoolWithRetry(initPool func() error) error {
+	if p.config.StartRetry == nil {
+		return initPool()
+	}
+
+	deadline := time.Now().Add(p.config.StartRetry.Timeout)
+	interval := p.config.StartRetry.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := initPool()
+		if err == nil {
+			if attempt > 1 {
+				p.log.Info("workers started", zap.Int("attempts", attempt))
+			}
+
+			return nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			p.log.Error("failed to start the workers, start_retry window exceeded", zap.Int("attempts", attempt), zap.Error(err))
+			return errors.Errorf("failed to start the workers after %d attempts: %v", attempt, err)
+		}
+
+		p.log.Warn("failed to start the workers, retrying", zap.Int("attempt", attempt), zap.Duration("next_attempt", interval), zap.Error(err))
+		if p.waitStartRetry(interval) {
+			return errors.Errorf("the plugin was stopped while starting the workers, attempts: %d, last error: %v", attempt, err)
+		}
+
+		interval = min(interval*2, p.config.StartRetry.MaxInterval)
+	}
+}
+
+// waitStartRetry waits for the next start attempt without holding p.mu, returns true if the plugin was stopped.
+func (p *Plugin) waitStartRetry(interval time.Duration) bool {
+	p.mu.Unlock()
+	timer := time.NewTimer(interval)
+	select {
+	case <-timer.C:
+	case <-p.stopCh:
+		timer.Stop()
+		p.mu.Lock()
+		return true
+	}
+	p.mu.Lock()
+
+	// Stop sends to stopCh with p.mu held, it might have been called right before the lock was acquired
+	select {
+	case <-p.stopCh:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,88 @@
+package rrtemporal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newStartingPlugin(timeout time.Duration) *Plugin {
+	return &Plugin{
+		log: zap.NewNop(),
+		config: &Config{StartRetry: &StartRetry{
+			Timeout:         timeout,
+			InitialInterval: time.Millisecond,
+			MaxInterval:     time.Millisecond * 5,
+		}},
+		stopCh: make(chan struct{}, 1),
+	}
+}
+
+func Test_StartRetry(t *testing.T) {
+	p := newStartingPlugin(time.Second)
+
+	attempts := 0
+	initPool := func() error {
+		attempts++
+		if attempts < 3 {
+			return errors.New("temporal is not available")
+		}
+		return nil
+	}
+
+	p.mu.Lock()
+	err := p.initPoolWithRetry(initPool)
+	p.mu.Unlock()
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, attempts)
+}
+
+func Test_StartRetryWindowExceeded(t *testing.T) {
+	p := newStartingPlugin(time.Millisecond * 20)
+
+	p.mu.Lock()
+	err := p.initPoolWithRetry(func() error { return errors.New("temporal is not available") })
+	p.mu.Unlock()
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "temporal is not available")
+}
+
+func Test_StartRetryStopped(t *testing.T) {
+	p := newStartingPlugin(time.Minute)
+
+	attempts := make(chan struct{}, 1)
+	initPool := func() error {
+		select {
+		case attempts <- struct{}{}:
+		default:
+		}
+		return errors.New("temporal is not available")
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		errCh <- p.initPoolWithRetry(initPool)
+	}()
+
+	// the lock is released between the attempts, stopped the same way as Stop does
+	<-attempts
+	p.mu.Lock()
+	p.stopCh <- struct{}{}
+	p.mu.Unlock()
+
+	select {
+	case err := <-errCh:
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "stopped")
+	case <-time.After(time.Second * 5):
+		t.Fatal("the start retry is not stopped")
+	}
+}
@@ -4,33 +4,30 @@ import (
 	"net/http"
 
 	"github.com/roadrunner-server/pool/fsm"
+	"go.uber.org/zap"
 
 	"github.com/roadrunner-server/api/v4/plugins/v1/status"
 )
 
-// Status return status of the particular plugin
+// Status return status of the particular plugin: the worker pools should have an active worker and the Temporal server
+// should be reachable
 func (p *Plugin) Status() (*status.Status, error) {
-	p.mu.RLock()
-	defer p.mu.RUnlock()
-
-	if p.config.DisableActivityWorkers && len(p.wfP.Workers()) > 0 && p.wfP.Workers()[0].State().IsActive() {
+	if !p.serving() {
 		return &status.Status{
-			Code: http.StatusOK,
+			Code: http.StatusServiceUnavailable,
 		}, nil
 	}
 
-	workers := p.actP.Workers()
-
-	for i := range workers {
-		if workers[i].State().IsActive() {
-			return &status.Status{
-				Code: http.StatusOK,
-			}, nil
-		}
+	err := p.checkConnection()
+	if err != nil {
+		p.log.Warn("temporal server health check failed", zap.Error(err))
+		return &status.Status{
+			Code: http.StatusServiceUnavailable,
+		}, nil
 	}
-	// if there are no workers, threat this as error
+
 	return &status.Status{
-		Code: http.StatusServiceUnavailable,
+		Code: http.StatusOK,
 	}, nil
 }
 
@@ -39,6 +36,18 @@ func (p *Plugin) Ready() (*status.Status, error) {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
 
+	// workers should poll the task queues to receive the tasks
+	ready, err := p.pollersReady()
+	if err != nil {
+		return nil, err
+	}
+
+	if !ready || p.Degraded() {
+		return &status.Status{
+			Code: http.StatusServiceUnavailable,
+		}, nil
+	}
+
 	if p.config.DisableActivityWorkers && len(p.wfP.Workers()) > 0 && p.wfP.Workers()[0].State().Compare(fsm.StateReady) {
 		return &status.Status{
 			Code: http.StatusOK,
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"testing"
 	"tests/helpers"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"go.temporal.io/sdk/client"
@@ -193,3 +194,27 @@ func Test_SignalChildViaStubWorkflowLAProto(t *testing.T) {
 	stopCh <- struct{}{}
 	wg.Wait()
 }
+
+func Test_ExecuteChildWorkflowRunTimeoutProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+			// the parent outlives the child run timeout
+			WorkflowExecutionTimeout: time.Minute,
+		},
+		"ChildRunTimeoutWorkflow",
+	)
+	assert.NoError(t, err)
+
+	var result string
+	assert.NoError(t, w.Get(context.Background(), &result))
+	assert.Equal(t, "child timed out", result)
+	stopCh <- struct{}{}
+	wg.Wait()
+}
@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"context"
+	"path"
+	"sync"
+	"testing"
+	"tests/helpers"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/history/v1"
+	"go.temporal.io/sdk/client"
+)
+
+func Test_ContinueAsNewSignalReplay(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-continue-as-new.yaml")
+
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+		},
+		"ContinueAsNewSignalWorkflow")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var result struct {
+		Iterations    int `json:"iterations"`
+		HistoryLength int `json:"history_length"`
+	}
+	require.NoError(t, w.Get(ctx, &result))
+	// the loop is stopped by the signal sent by RR at continue_as_new_history_length: 20
+	assert.Positive(t, result.Iterations)
+	assert.GreaterOrEqual(t, result.HistoryLength, 20)
+
+	// the signal is not recorded in the history
+	s.AssertNotContainsEvent(s.Client, t, w, func(event *history.HistoryEvent) bool {
+		return event.EventType == enums.EVENT_TYPE_WORKFLOW_EXECUTION_SIGNALED
+	})
+
+	time.Sleep(time.Second)
+	tmp := path.Join(t.TempDir(), "replay.json")
+
+	// replayed with the same continue_as_new_* config, the signal is sent again at the same history length
+	t.Run("downloadWFHistory", downloadWFHistory("127.0.0.1:6001", w.GetID(), w.GetRunID(), "ContinueAsNewSignalWorkflow", tmp))
+	t.Run("replayFromJSON", replayFromJSON("127.0.0.1:6001", tmp, "ContinueAsNewSignalWorkflow"))
+
+	stopCh <- struct{}{}
+	wg.Wait()
+	time.Sleep(time.Second)
+}
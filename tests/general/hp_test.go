@@ -15,6 +15,7 @@ import (
 
 	goridgeRpc "github.com/roadrunner-server/goridge/v3/pkg/rpc"
 	"github.com/stretchr/testify/require"
+	rrtemporal "github.com/temporalio/roadrunner-temporal/v5"
 	"go.temporal.io/api/common/v1"
 
 	"github.com/fatih/color"
@@ -847,6 +848,70 @@ func Test_UpsertSearchAttributesWorkflowProto(t *testing.T) {
 	wg.Wait()
 }
 
+func Test_UpsertSearchAttributesRPCProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	ctx := context.Background()
+	_, err := s.Client.OperatorService().AddSearchAttributes(ctx, &operatorservice.AddSearchAttributesRequest{
+		SearchAttributes: map[string]enums.IndexedValueType{
+			"attr1": enums.INDEXED_VALUE_TYPE_KEYWORD,
+		},
+		Namespace: "default",
+	})
+	require.NoError(t, err)
+
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+		},
+		"WaitWorkflow",
+	)
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:6001")
+	require.NoError(t, err)
+	c := rpc.NewClientWithCodec(goridgeRpc.NewClientCodec(conn))
+
+	out := &rrtemporal.UpsertSearchAttributesResponse{}
+	require.NoError(t, c.Call("temporal.UpsertSearchAttributes", &rrtemporal.UpsertSearchAttributesRequest{
+		Executions: []rrtemporal.WorkflowExecutionRef{{WorkflowID: w.GetID()}, {WorkflowID: "not-exists"}},
+		SearchAttributes: map[string]*rrtemporal.TypedSearchAttribute{
+			"attr1": {Type: "keyword", Value: "migrated"},
+		},
+	}, out))
+	assert.Equal(t, 1, out.Signaled)
+	assert.Equal(t, []string{"not-exists"}, out.NotFound)
+
+	// the signal is handled by RR, the workflow is still waiting for the unlock signal
+	require.NoError(t, s.Client.SignalWorkflow(ctx, w.GetID(), w.GetRunID(), "unlock", "done"))
+
+	var result string
+	require.NoError(t, w.Get(ctx, &result))
+	assert.Equal(t, "done", result)
+
+	we, err := s.Client.DescribeWorkflowExecution(ctx, w.GetID(), w.GetRunID())
+	require.NoError(t, err)
+	assert.Equal(t, `"migrated"`, string(we.WorkflowExecutionInfo.GetSearchAttributes().GetIndexedFields()["attr1"].GetData()))
+
+	// the closed workflow is reported, not updated
+	out = &rrtemporal.UpsertSearchAttributesResponse{}
+	require.NoError(t, c.Call("temporal.UpsertSearchAttributes", &rrtemporal.UpsertSearchAttributesRequest{
+		Executions: []rrtemporal.WorkflowExecutionRef{{WorkflowID: w.GetID(), RunID: w.GetRunID()}},
+		SearchAttributes: map[string]*rrtemporal.TypedSearchAttribute{
+			"attr1": {Type: "keyword", Value: "late"},
+		},
+	}, out))
+	assert.Zero(t, out.Signaled)
+	assert.Equal(t, []string{w.GetID()}, out.NotFound)
+
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
 func Test_SagaWorkflowLAProto(t *testing.T) {
 	stopCh := make(chan struct{}, 1)
 	wg := &sync.WaitGroup{}
@@ -893,3 +958,247 @@ func getWorkflows(t *testing.T) []string {
 
 	return res
 }
+
+func Test_ActivityScheduleToStartTimeoutProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+		},
+		"ScheduleToStartTimeoutWorkflow",
+	)
+	assert.NoError(t, err)
+
+	// the activity is never picked up, schedule-to-start should fire before start-to-close
+	var result int
+	assert.NoError(t, w.Get(context.Background(), &result))
+	assert.Equal(t, int(enums.TIMEOUT_TYPE_SCHEDULE_TO_START), result)
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
+func Test_ActivityInfoAttemptProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+		},
+		"ActivityAttemptWorkflow",
+		3,
+	)
+	assert.NoError(t, err)
+
+	// the activity fails on the first two attempts, the attempt comes from the activity info sent to the worker
+	var result struct {
+		Attempt      int  `json:"attempt"`
+		HasTaskToken bool `json:"has_task_token"`
+	}
+	assert.NoError(t, w.Get(context.Background(), &result))
+	assert.Equal(t, 3, result.Attempt)
+	assert.True(t, result.HasTaskToken)
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
+func Test_ReplacePoolsRPCProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	conn, err := net.Dial("tcp", "127.0.0.1:6001")
+	require.NoError(t, err)
+	c := rpc.NewClientWithCodec(goridgeRpc.NewClientCodec(conn))
+
+	out := &rrtemporal.ReplacePoolsResponse{}
+	require.NoError(t, c.Call("temporal.ReplacePools", &rrtemporal.ReplacePoolsRequest{Timeout: "1m"}, out))
+	assert.NotZero(t, out.WorkflowWorkerPID)
+	assert.NotZero(t, out.TemporalWorkers)
+
+	// new workers should serve the workflows right after the call
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+		},
+		"SimpleWorkflow",
+		"Hello World",
+	)
+	assert.NoError(t, err)
+
+	var result string
+	assert.NoError(t, w.Get(context.Background(), &result))
+	assert.Equal(t, "HELLO WORLD", result)
+
+	assert.Error(t, c.Call("temporal.ReplacePools", &rrtemporal.ReplacePoolsRequest{Timeout: "foo"}, out))
+
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
+func Test_GetWorkersRPCProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	_ = helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	conn, err := net.Dial("tcp", "127.0.0.1:6001")
+	require.NoError(t, err)
+	c := rpc.NewClientWithCodec(goridgeRpc.NewClientCodec(conn))
+
+	out := &rrtemporal.GetWorkersResponse{}
+	require.NoError(t, c.Call("temporal.GetWorkers", true, out))
+	require.NotEmpty(t, out.Workers)
+	assert.NotZero(t, out.WorkflowWorkerPID)
+
+	// the single workflow worker has the PID logged by the workflow handler
+	assert.Equal(t, "workflow", out.Workers[0].Pool)
+	assert.Equal(t, int64(out.WorkflowWorkerPID), out.Workers[0].PID)
+
+	for _, w := range out.Workers {
+		assert.NotZero(t, w.PID)
+		assert.NotEmpty(t, w.Status)
+		assert.False(t, w.CreatedAt.IsZero())
+	}
+
+	assert.Equal(t, "activity", out.Workers[len(out.Workers)-1].Pool)
+
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
+func Test_HealthRPCProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	_ = helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	conn, err := net.Dial("tcp", "127.0.0.1:6001")
+	require.NoError(t, err)
+	c := rpc.NewClientWithCodec(goridgeRpc.NewClientCodec(conn))
+
+	out := &rrtemporal.HealthResponse{}
+	require.NoError(t, c.Call("temporal.Health", true, out))
+	assert.True(t, out.Serving)
+	assert.True(t, out.Connected)
+	assert.Empty(t, out.Error)
+	assert.NotZero(t, out.Workflows)
+	assert.False(t, out.PoolsStartedAt.IsZero())
+
+	// the replacement time is updated
+	started := out.PoolsStartedAt
+	require.NoError(t, c.Call("temporal.ReplacePools", &rrtemporal.ReplacePoolsRequest{Timeout: "1m"}, &rrtemporal.ReplacePoolsResponse{}))
+	require.NoError(t, c.Call("temporal.Health", true, out))
+	assert.True(t, out.PoolsStartedAt.After(started))
+
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
+func Test_ReplacePoolsRollingProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto-rolling.yaml")
+
+	// the workflow is started on the old pool and completed on the new one, the new pool reuses the cached worker info
+	w, err := s.Client.ExecuteWorkflow(
+		context.Background(),
+		client.StartWorkflowOptions{
+			TaskQueue: "default",
+		},
+		"TimerWorkflow",
+		"Hello World",
+	)
+	require.NoError(t, err)
+
+	conn, err := net.Dial("tcp", "127.0.0.1:6001")
+	require.NoError(t, err)
+	c := rpc.NewClientWithCodec(goridgeRpc.NewClientCodec(conn))
+
+	out := &rrtemporal.ReplacePoolsResponse{}
+	require.NoError(t, c.Call("temporal.ReplacePools", &rrtemporal.ReplacePoolsRequest{Timeout: "1m"}, out))
+	assert.NotZero(t, out.WorkflowWorkerPID)
+
+	var result string
+	assert.NoError(t, w.Get(context.Background(), &result))
+	assert.Equal(t, "hello world", result)
+
+	stopCh <- struct{}{}
+	wg.Wait()
+}
+
+func Test_DescribeScheduleRPCProto(t *testing.T) {
+	stopCh := make(chan struct{}, 1)
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	s := helpers.NewTestServer(t, stopCh, wg, "../configs/.rr-proto.yaml")
+
+	ctx := context.Background()
+	id := "schedule-" + strconv.Itoa(int(time.Now().UnixNano()))
+	handle, err := s.Client.ScheduleClient().Create(ctx, client.ScheduleOptions{
+		ID: id,
+		Spec: client.ScheduleSpec{
+			Intervals: []client.ScheduleIntervalSpec{{Every: time.Hour}},
+		},
+		Action: &client.ScheduleWorkflowAction{
+			ID:        id + "-workflow",
+			Workflow:  "SimpleWorkflow",
+			Args:      []any{"Hello World"},
+			TaskQueue: "default",
+		},
+		Paused: true,
+		Note:   "maintenance",
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = handle.Delete(ctx)
+	})
+
+	conn, err := net.Dial("tcp", "127.0.0.1:6001")
+	require.NoError(t, err)
+	c := rpc.NewClientWithCodec(goridgeRpc.NewClientCodec(conn))
+
+	out := &rrtemporal.DescribeScheduleResponse{}
+	require.NoError(t, c.Call("temporal.DescribeSchedule", &rrtemporal.DescribeScheduleRequest{ScheduleID: id, NextRuns: 3}, out))
+	assert.Equal(t, id, out.ScheduleID)
+	assert.True(t, out.Paused)
+	assert.True(t, out.NextRunsSuppressed)
+	assert.Equal(t, "maintenance", out.Note)
+	assert.Equal(t, -1, out.RemainingActions)
+	assert.LessOrEqual(t, len(out.NextRuns), 3)
+	require.NotNil(t, out.Spec)
+	require.Len(t, out.Spec.Intervals, 1)
+	assert.Equal(t, time.Hour, out.Spec.Intervals[0].Every)
+
+	assert.Error(t, c.Call("temporal.DescribeSchedule", &rrtemporal.DescribeScheduleRequest{}, out))
+
+	// the visibility is eventually consistent
+	require.Eventually(t, func() bool {
+		list := &rrtemporal.ListSchedulesResponse{}
+		if c.Call("temporal.ListSchedules", &rrtemporal.ListSchedulesRequest{PageSize: 100}, list) != nil {
+			return false
+		}
+
+		for _, entry := range list.Schedules {
+			if entry.ScheduleID == id {
+				return entry.Paused && entry.WorkflowType == "SimpleWorkflow"
+			}
+		}
+
+		return false
+	}, time.Second*30, time.Second)
+
+	stopCh <- struct{}{}
+	wg.Wait()
+}
@@ -2,6 +2,7 @@
 
 namespace Temporal\Tests\Activity;
 
+use Temporal\Activity;
 use Temporal\Activity\ActivityInterface;
 use Temporal\Activity\ActivityMethod;
 use Temporal\Api\Common\V1\WorkflowExecution;
@@ -60,4 +61,18 @@ class SimpleActivity
     {
         throw new \Error("failed activity");
     }
-}
\ No newline at end of file
+
+    #[ActivityMethod]
+    public function attempt(int $succeedOn): array
+    {
+        $info = Activity::getInfo();
+        if ($info->attempt < $succeedOn) {
+            throw new \Error(sprintf("failed attempt %d", $info->attempt));
+        }
+
+        return [
+            'attempt' => $info->attempt,
+            'has_task_token' => $info->taskToken !== '',
+        ];
+    }
+}
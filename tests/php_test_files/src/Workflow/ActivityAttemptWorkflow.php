@@ -0,0 +1,32 @@
+<?php
+
+declare(strict_types=1);
+
+namespace Temporal\Tests\Workflow;
+
+use Temporal\Activity\ActivityOptions;
+use Temporal\Common\RetryOptions;
+use Temporal\Workflow;
+use Temporal\Workflow\WorkflowMethod;
+use Temporal\Tests\Activity\SimpleActivity;
+
+#[Workflow\WorkflowInterface]
+class ActivityAttemptWorkflow
+{
+    #[WorkflowMethod(name: 'ActivityAttemptWorkflow')]
+    public function handler(int $succeedOn): iterable
+    {
+        $simple = Workflow::newActivityStub(
+            SimpleActivity::class,
+            ActivityOptions::new()
+                ->withStartToCloseTimeout(5)
+                ->withRetryOptions(
+                    RetryOptions::new()
+                        ->withInitialInterval(1)
+                        ->withMaximumAttempts($succeedOn)
+                )
+        );
+
+        return yield $simple->attempt($succeedOn);
+    }
+}
@@ -0,0 +1,41 @@
+<?php
+
+declare(strict_types=1);
+
+namespace Temporal\Tests\Workflow;
+
+use Temporal\Exception\Failure\ChildWorkflowFailure;
+use Temporal\Exception\Failure\TimeoutFailure;
+use Temporal\Workflow;
+use Temporal\Workflow\WorkflowMethod;
+
+#[Workflow\WorkflowInterface]
+class ChildRunTimeoutWorkflow
+{
+    #[WorkflowMethod(name: 'ChildRunTimeoutWorkflow')]
+    public function handler(): iterable
+    {
+        try {
+            // never unlocked, the child run timeout fires
+            yield Workflow::executeChildWorkflow(
+                'WaitWorkflow',
+                [],
+                Workflow\ChildWorkflowOptions::new()
+                    ->withWorkflowExecutionTimeout(30)
+                    ->withWorkflowRunTimeout(2)
+                    ->withWorkflowTaskTimeout(1)
+            );
+        } catch (ChildWorkflowFailure $e) {
+            if (!$e->getPrevious() instanceof TimeoutFailure) {
+                throw $e;
+            }
+
+            // the parent keeps running after the child timed out
+            yield Workflow::timer(1);
+
+            return 'child timed out';
+        }
+
+        return 'child completed';
+    }
+}
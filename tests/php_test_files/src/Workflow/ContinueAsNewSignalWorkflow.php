@@ -0,0 +1,44 @@
+<?php
+
+declare(strict_types=1);
+
+namespace Temporal\Tests\Workflow;
+
+use Temporal\Activity\ActivityOptions;
+use Temporal\Tests\Activity\SimpleActivity;
+use Temporal\Workflow;
+use Temporal\Workflow\SignalMethod;
+use Temporal\Workflow\WorkflowMethod;
+
+#[Workflow\WorkflowInterface]
+class ContinueAsNewSignalWorkflow
+{
+    private ?array $suggestion = null;
+
+    #[SignalMethod(name: 'continueAsNewThreshold')]
+    public function continueAsNewThreshold(array $suggestion)
+    {
+        $this->suggestion = $suggestion;
+    }
+
+    #[WorkflowMethod(name: 'ContinueAsNewSignalWorkflow')]
+    public function handler(): iterable
+    {
+        $simple = Workflow::newActivityStub(
+            SimpleActivity::class,
+            ActivityOptions::new()->withStartToCloseTimeout(5)
+        );
+
+        // the endless loop stopped only by the signal sent by RR
+        $iterations = 0;
+        while ($this->suggestion === null) {
+            yield $simple->echo('iteration');
+            $iterations++;
+        }
+
+        return [
+            'iterations' => $iterations,
+            'history_length' => $this->suggestion['history_length'],
+        ];
+    }
+}
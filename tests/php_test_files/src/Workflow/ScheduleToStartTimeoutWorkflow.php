@@ -0,0 +1,44 @@
+<?php
+
+declare(strict_types=1);
+
+namespace Temporal\Tests\Workflow;
+
+use Temporal\Activity\ActivityOptions;
+use Temporal\Common\RetryOptions;
+use Temporal\Exception\Failure\ActivityFailure;
+use Temporal\Exception\Failure\TimeoutFailure;
+use Temporal\Workflow;
+use Temporal\Workflow\WorkflowMethod;
+use Temporal\Tests\Activity\SimpleActivity;
+
+#[Workflow\WorkflowInterface]
+class ScheduleToStartTimeoutWorkflow
+{
+    #[WorkflowMethod(name: 'ScheduleToStartTimeoutWorkflow')]
+    public function handler(): iterable
+    {
+        // nobody polls this task queue, so only the schedule-to-start timeout may fire
+        $simple = Workflow::newActivityStub(
+            SimpleActivity::class,
+            ActivityOptions::new()
+                ->withTaskQueue('no_workers')
+                ->withScheduleToStartTimeout(1)
+                ->withStartToCloseTimeout(30)
+                ->withRetryOptions(RetryOptions::new()->withMaximumAttempts(1))
+        );
+
+        try {
+            yield $simple->echo('test');
+        } catch (ActivityFailure $e) {
+            $previous = $e->getPrevious();
+            if ($previous instanceof TimeoutFailure) {
+                return $previous->getTimeoutType();
+            }
+
+            throw $e;
+        }
+
+        return 'completed';
+    }
+}
@@ -0,0 +1,87 @@
+package rrtemporal
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/temporalio/roadrunner-temporal/v5/api"
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	"github.com/temporalio/roadrunner-temporal/v5/internal/codec/proto"
+	"go.uber.org/zap"
+)
+
+// workerInfoCache is the worker info read from the worker with the code hash, see WorkerInfoCache
+type workerInfoCache struct {
+	hash string
+	wi   []*internal.WorkerInfo
+}
+
+// workerInfo returns the worker info of the workflow worker, the cached one if the worker code hash is not changed.
+// The returned worker info is a copy, it's modified by the workflow routing and the worker options.
+func (p *Plugin) workerInfo(codec *proto.Codec, wp api.Pool, wwPID int) ([]*internal.WorkerInfo, error) {
+	if p.config.WorkerInfoCache == nil {
+		return WorkerInfo(codec, wp, p.rrVersion, wwPID)
+	}
+
+	hash, err := p.workerCodeHash()
+	if err != nil {
+		// not cached, the worker info is read on every restart until the files are readable
+		p.log.Warn("failed to hash the worker code, worker info is not cached", zap.Error(err))
+		p.wiCache = nil
+		return WorkerInfo(codec, wp, p.rrVersion, wwPID)
+	}
+
+	if p.wiCache != nil && p.wiCache.hash == hash {
+		p.log.Info("worker info cache hit, the worker info request is skipped", zap.String("hash", hash), zap.Int("workflow_worker_pid", wwPID))
+		return cloneWorkerInfo(p.wiCache.wi), nil
+	}
+
+	wi, err := WorkerInfo(codec, wp, p.rrVersion, wwPID)
+	if err != nil {
+		return nil, err
+	}
+
+	p.log.Info("worker info cache miss, the worker info is cached", zap.String("hash", hash), zap.Bool("code_changed", p.wiCache != nil))
+	p.wiCache = &workerInfoCache{hash: hash, wi: cloneWorkerInfo(wi)}
+
+	return wi, nil
+}
+
+// workerCodeHash hashes the worker command and the configured files
+func (p *Plugin) workerCodeHash() (string, error) {
+	const op = errors.Op("worker_code_hash")
+
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%v\n", p.config.Activities.Command)
+
+	for _, file := range p.config.WorkerInfoCache.Files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", errors.E(op, err)
+		}
+
+		sum := sha256.Sum256(data)
+		_, _ = fmt.Fprintf(h, "%s:%x\n", file, sum)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cloneWorkerInfo copies the worker info fields modified by the routing and the worker options
+func cloneWorkerInfo(wi []*internal.WorkerInfo) []*internal.WorkerInfo {
+	res := make([]*internal.WorkerInfo, 0, len(wi))
+	for _, w := range wi {
+		c := *w
+		c.Flags = maps.Clone(w.Flags)
+		c.Workflows = slices.Clone(w.Workflows)
+		c.Activities = slices.Clone(w.Activities)
+		res = append(res, &c)
+	}
+
+	return res
+}
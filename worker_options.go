@@ -0,0 +1,176 @@
+package rrtemporal
+
+import (
+	"slices"
+	"time"
+
+	"github.com/temporalio/roadrunner-temporal/v5/internal"
+	tclient "go.temporal.io/sdk/client"
+	"go.uber.org/zap"
+)
+
+const (
+	activitiesRateLimitMetricName          string = "rr_activities_rate_limit"
+	taskQueueActivitiesRateLimitMetricName string = "rr_task_queue_activities_rate_limit"
+
+	// defaultWorkerStopTimeout drains the in-flight activities before the pools are reset, replaced or destroyed
+	defaultWorkerStopTimeout = time.Second * 10
+)
+
+// applyWorkerOptions overrides options received from the PHP worker with the options from the configuration
+func (p *Plugin) applyWorkerOptions(wi []*internal.WorkerInfo) {
+	for i := range wi {
+		// stop timeout is controlled by the RR configuration only
+		wi[i].Options.WorkerStopTimeout = defaultWorkerStopTimeout
+
+		taskQueue := wi[i].TaskQueue
+		// sync with the aggregatedpool.TemporalWorkers
+		if taskQueue == "" {
+			taskQueue = tclient.DefaultNamespace
+		}
+
+		opts, ok := p.config.Workers[taskQueue]
+		if !ok || opts == nil {
+			continue
+		}
+
+		if opts.DisableEagerActivities {
+			wi[i].Options.DisableEagerActivities = true
+		}
+
+		if opts.DeadlockDetectionTimeout > 0 {
+			wi[i].Options.DeadlockDetectionTimeout = opts.DeadlockDetectionTimeout
+		}
+
+		if opts.WorkerStopTimeout > 0 {
+			wi[i].Options.WorkerStopTimeout = opts.WorkerStopTimeout
+		}
+
+		if opts.MaxConcurrentWorkflowTaskPollers > 0 {
+			wi[i].Options.MaxConcurrentWorkflowTaskPollers = opts.MaxConcurrentWorkflowTaskPollers
+		}
+
+		if opts.MaxConcurrentActivityTaskPollers > 0 {
+			wi[i].Options.MaxConcurrentActivityTaskPollers = opts.MaxConcurrentActivityTaskPollers
+		}
+
+		if opts.WorkerActivitiesPerSecond > 0 {
+			wi[i].Options.WorkerActivitiesPerSecond = opts.WorkerActivitiesPerSecond
+		}
+
+		if opts.TaskQueueActivitiesPerSecond > 0 {
+			wi[i].Options.TaskQueueActivitiesPerSecond = opts.TaskQueueActivitiesPerSecond
+		}
+
+		// effective limits, 0 - unlimited (or the SDK default)
+		if p.temporal.mh != nil {
+			mh := p.temporal.mh.WithTags(map[string]string{"task_queue": taskQueue})
+			mh.Gauge(activitiesRateLimitMetricName).Update(wi[i].Options.WorkerActivitiesPerSecond)
+			mh.Gauge(taskQueueActivitiesRateLimitMetricName).Update(wi[i].Options.TaskQueueActivitiesPerSecond)
+		}
+
+		p.log.Info("worker options overridden",
+			zap.String("task_queue", taskQueue),
+			zap.Bool("disable_eager_activities", wi[i].Options.DisableEagerActivities),
+			zap.Duration("deadlock_detection_timeout", wi[i].Options.DeadlockDetectionTimeout),
+			zap.Duration("worker_stop_timeout", wi[i].Options.WorkerStopTimeout),
+			zap.Int("max_concurrent_workflow_task_pollers", wi[i].Options.MaxConcurrentWorkflowTaskPollers),
+			zap.Int("max_concurrent_activity_task_pollers", wi[i].Options.MaxConcurrentActivityTaskPollers),
+			zap.Float64("worker_activities_per_second", wi[i].Options.WorkerActivitiesPerSecond),
+			zap.Float64("task_queue_activities_per_second", wi[i].Options.TaskQueueActivitiesPerSecond),
+		)
+	}
+}
+
+// routeWorkflows moves the workflow types configured in the workflow_task_queues to their task queues, the workers
+// for the new task queues are created with the options of the worker the workflow was registered on.
+// Activities stay on the task queues they were registered on.
+func (p *Plugin) routeWorkflows(wi []*internal.WorkerInfo) []*internal.WorkerInfo {
+	if len(p.config.WorkflowTaskQueues) == 0 {
+		return wi
+	}
+
+	// workflow type -> task queue, validated to be unique in the config
+	routes := make(map[string]string)
+	for taskQueue, workflows := range p.config.WorkflowTaskQueues {
+		for _, name := range workflows {
+			routes[name] = taskQueue
+		}
+	}
+
+	type move struct {
+		wf     internal.WorkflowInfo
+		source *internal.WorkerInfo
+	}
+
+	var moves []move
+	for i := range wi {
+		// sync with the aggregatedpool.TemporalWorkers
+		if wi[i].TaskQueue == "" {
+			wi[i].TaskQueue = tclient.DefaultNamespace
+		}
+
+		workflows := make([]internal.WorkflowInfo, 0, len(wi[i].Workflows))
+		for j := range wi[i].Workflows {
+			taskQueue, ok := routes[wi[i].Workflows[j].Name]
+			if !ok || taskQueue == wi[i].TaskQueue {
+				workflows = append(workflows, wi[i].Workflows[j])
+				continue
+			}
+
+			moves = append(moves, move{wf: wi[i].Workflows[j], source: wi[i]})
+		}
+
+		wi[i].Workflows = workflows
+	}
+
+	for _, m := range moves {
+		taskQueue := routes[m.wf.Name]
+
+		idx := slices.IndexFunc(wi, func(w *internal.WorkerInfo) bool { return w.TaskQueue == taskQueue })
+		if idx == -1 {
+			target := &internal.WorkerInfo{
+				TaskQueue:     taskQueue,
+				Options:       m.source.Options,
+				PhpSdkVersion: m.source.PhpSdkVersion,
+				Flags:         m.source.Flags,
+			}
+
+			// interceptors are added per worker
+			target.Options.Interceptors = nil
+
+			wi = append(wi, target)
+			idx = len(wi) - 1
+		}
+
+		// the same workflow type registered on several task queues is moved only once
+		if slices.ContainsFunc(wi[idx].Workflows, func(wf internal.WorkflowInfo) bool { return wf.Name == m.wf.Name }) {
+			continue
+		}
+
+		// the PHP worker still serves the workflow on the task queue it was registered on
+		m.wf.RegisteredTaskQueue = m.source.TaskQueue
+		wi[idx].Workflows = append(wi[idx].Workflows, m.wf)
+
+		p.log.Info("workflow routed to the task queue",
+			zap.String("workflow", m.wf.Name),
+			zap.String("from", m.source.TaskQueue),
+			zap.String("task_queue", taskQueue),
+		)
+	}
+
+	// drop the workers left without workflows and activities, there is nothing to poll
+	wi = slices.DeleteFunc(wi, func(w *internal.WorkerInfo) bool {
+		return len(w.Workflows) == 0 && len(w.Activities) == 0
+	})
+
+	for name, taskQueue := range routes {
+		if !slices.ContainsFunc(wi, func(w *internal.WorkerInfo) bool {
+			return w.TaskQueue == taskQueue && slices.ContainsFunc(w.Workflows, func(wf internal.WorkflowInfo) bool { return wf.Name == name })
+		}) {
+			p.log.Warn("workflow is not registered by the worker, task queue route ignored", zap.String("workflow", name), zap.String("task_queue", taskQueue))
+		}
+	}
+
+	return wi
+}